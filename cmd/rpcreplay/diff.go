@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// diffJSON compares the recorded and the replayed JSON values, and returns the
+// differences keyed by their path in the value.
+func diffJSON(recorded, replayed json.RawMessage) ([]string, error) {
+	var a, b interface{}
+	if err := json.Unmarshal(recorded, &a); err != nil {
+		return nil, fmt.Errorf("invalid recorded result: %v", err)
+	}
+	if err := json.Unmarshal(replayed, &b); err != nil {
+		return nil, fmt.Errorf("invalid replayed result: %v", err)
+	}
+	return diffValues("$", a, b, nil), nil
+}
+
+func diffValues(path string, a, b interface{}, diffs []string) []string {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			av, aok := a[key]
+			bv, bok := b[key]
+			switch {
+			case !bok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: recorded %s, replayed missing", path, key, encode(av)))
+			case !aok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: recorded missing, replayed %s", path, key, encode(bv)))
+			default:
+				diffs = diffValues(path+"."+key, av, bv, diffs)
+			}
+		}
+		return diffs
+
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+		for i := range a {
+			diffs = diffValues(path+"["+strconv.Itoa(i)+"]", a[i], b[i], diffs)
+		}
+		return diffs
+	}
+	if !reflect.DeepEqual(a, b) {
+		diffs = append(diffs, fmt.Sprintf("%s: recorded %s, replayed %s", path, encode(a), encode(b)))
+	}
+	return diffs
+}
+
+func encode(v interface{}) string {
+	blob, _ := json.Marshal(v)
+	return string(blob)
+}
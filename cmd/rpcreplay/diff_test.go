@@ -0,0 +1,52 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		recorded, replayed string
+		want               []string
+	}{
+		{`"0x1"`, `"0x1"`, nil},
+		{`null`, `{}`, []string{`$: recorded null, replayed {}`}},
+		{
+			`{"a":"0x1","b":[1,2],"c":{"d":true}}`,
+			`{"a":"0x2","b":[1,3],"c":{"e":true}}`,
+			[]string{
+				`$.a: recorded "0x1", replayed "0x2"`,
+				`$.b[1]: recorded 2, replayed 3`,
+				`$.c.d: recorded true, replayed missing`,
+				`$.c.e: recorded missing, replayed true`,
+			},
+		},
+		{`[1]`, `[1,2]`, []string{`$: recorded [1], replayed [1,2]`}},
+	}
+	for i, test := range tests {
+		diffs, err := diffJSON([]byte(test.recorded), []byte(test.replayed))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(diffs, test.want) {
+			t.Errorf("test %d: diff mismatch\n have: %q\n want: %q", i, diffs, test.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// rpcreplay replays the RPC calls recorded by geth (--rpc.record) against a node
+// and reports the responses which differ from the recorded ones.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	targetFlag = &cli.StringFlag{
+		Name:     "target",
+		Usage:    "RPC endpoint of the node to replay the calls against",
+		Required: true,
+	}
+	methodsFlag = &cli.StringFlag{
+		Name:  "methods",
+		Usage: "Comma separated list of the replayed methods, trailing '*' matches by prefix (default = all)",
+	}
+	timeoutFlag = &cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Timeout of a single replayed call",
+		Value: 30 * time.Second,
+	}
+	maxDiffsFlag = &cli.IntFlag{
+		Name:  "maxdiffs",
+		Usage: "Maximum number of differences reported for a single call",
+		Value: 10,
+	}
+)
+
+var app = flags.NewApp("replays recorded RPC calls and diffs the responses")
+
+func init() {
+	app.ArgsUsage = "<recording.jsonl> [<recording.jsonl> ...]"
+	app.Flags = []cli.Flag{targetFlag, methodsFlag, timeoutFlag, maxDiffsFlag}
+	app.Action = replay
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// replayStats is the summary of the replay.
+type replayStats struct {
+	replayed   int
+	skipped    int
+	mismatched int
+	failed     int
+}
+
+func replay(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("no recording files given")
+	}
+	client, err := rpc.Dial(ctx.String(targetFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var (
+		stats   replayStats
+		methods []string
+	)
+	for _, method := range strings.Split(ctx.String(methodsFlag.Name), ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			methods = append(methods, method)
+		}
+	}
+	for _, file := range ctx.Args().Slice() {
+		if err := replayFile(ctx, client, file, methods, &stats); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Replayed %d calls: %d mismatched, %d failed, %d skipped\n", stats.replayed, stats.mismatched, stats.failed, stats.skipped)
+	if stats.mismatched > 0 || stats.failed > 0 {
+		return errors.New("replay found differences")
+	}
+	return nil
+}
+
+// replayFile replays the calls recorded in the file.
+func replayFile(ctx *cli.Context, client *rpc.Client, file string, methods []string, stats *replayStats) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 128*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec rpc.Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("%s:%d: %v", file, line, err)
+		}
+		if !replayable(&rec, methods) {
+			stats.skipped++
+			continue
+		}
+		stats.replayed++
+
+		diffs, err := replayCall(ctx, client, &rec)
+		if err != nil {
+			stats.failed++
+			fmt.Printf("%s:%d %s %s\n  replay failed: %v\n", file, line, rec.Method, rec.Params, err)
+			continue
+		}
+		if len(diffs) > 0 {
+			stats.mismatched++
+			fmt.Printf("%s:%d %s %s\n", file, line, rec.Method, rec.Params)
+			if limit := ctx.Int(maxDiffsFlag.Name); len(diffs) > limit {
+				diffs = append(diffs[:limit], fmt.Sprintf("... %d more differences", len(diffs)-limit))
+			}
+			for _, diff := range diffs {
+				fmt.Printf("  %s\n", diff)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// replayable reports whether the recorded call can be replayed. The notifications
+// and the subscriptions can't be, as their responses aren't recorded, neither can
+// the redacted calls and the calls with streamed results.
+func replayable(rec *rpc.Recording, methods []string) bool {
+	if rec.Redacted || rec.Streamed || (rec.Result == nil && rec.Error == nil) {
+		return false
+	}
+	if strings.HasSuffix(rec.Method, "_subscribe") || strings.HasSuffix(rec.Method, "_unsubscribe") {
+		return false
+	}
+	if len(methods) == 0 {
+		return true
+	}
+	for _, method := range methods {
+		if prefix, ok := strings.CutSuffix(method, "*"); ok && strings.HasPrefix(rec.Method, prefix) {
+			return true
+		}
+		if method == rec.Method {
+			return true
+		}
+	}
+	return false
+}
+
+// replayCall replays the recorded call and returns the differences of the
+// response. The error is only returned if the call couldn't be performed.
+func replayCall(ctx *cli.Context, client *rpc.Client, rec *rpc.Recording) ([]string, error) {
+	var args []interface{}
+	if len(rec.Params) > 0 {
+		var params []json.RawMessage
+		if err := json.Unmarshal(rec.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		for _, param := range params {
+			args = append(args, param)
+		}
+	}
+	callCtx, cancel := context.WithTimeout(ctx.Context, ctx.Duration(timeoutFlag.Name))
+	defer cancel()
+
+	var result json.RawMessage
+	err := client.CallContext(callCtx, &result, rec.Method, args...)
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+
+	var rpcErr rpc.Error
+	switch {
+	case err != nil && !errors.As(err, &rpcErr):
+		return nil, err
+	case rec.Error != nil && err == nil:
+		return []string{fmt.Sprintf("recorded error %d %q, replayed result %s", rec.Error.Code, rec.Error.Message, result)}, nil
+	case rec.Error == nil && err != nil:
+		return []string{fmt.Sprintf("recorded result %s, replayed error %d %q", rec.Result, rpcErr.ErrorCode(), rpcErr.Error())}, nil
+	case rec.Error != nil:
+		if rec.Error.Code != rpcErr.ErrorCode() || rec.Error.Message != rpcErr.Error() {
+			return []string{fmt.Sprintf("recorded error %d %q, replayed error %d %q", rec.Error.Code, rec.Error.Message, rpcErr.ErrorCode(), rpcErr.Error())}, nil
+		}
+		return nil, nil
+	default:
+		return diffJSON(rec.Result, result)
+	}
+}
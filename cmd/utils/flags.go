@@ -342,6 +342,11 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Journal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolSnapshotFlag = &cli.StringFlag{
+		Name:     "txpool.snapshot",
+		Usage:    "Disk snapshot of the pooled transactions written on shutdown and reloaded on startup (disabled if empty)",
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolRejournalFlag = &cli.DurationFlag{
 		Name:     "txpool.rejournal",
 		Usage:    "Time interval to regenerate the local transaction journal",
@@ -492,6 +497,22 @@ var (
 		Value:    ethconfig.Defaults.Miner.Recommit,
 		Category: flags.MinerCategory,
 	}
+	MinerStrategyFlag = &cli.StringFlag{
+		Name:     "miner.strategy",
+		Usage:    "Ordering of the transactions in mined blocks (price, arrival, fair)",
+		Value:    miner.StrategyPrice,
+		Category: flags.MinerCategory,
+	}
+	MinerSenderCapFlag = &cli.Uint64Flag{
+		Name:     "miner.sendercap",
+		Usage:    "Maximum number of transactions of a sender in a mined block (fair strategy)",
+		Category: flags.MinerCategory,
+	}
+	MinerBundlesFlag = &cli.BoolFlag{
+		Name:     "miner.bundles",
+		Usage:    "Accept transaction bundles over eth_sendBundle for inclusion in mined blocks",
+		Category: flags.MinerCategory,
+	}
 	MinerPendingFeeRecipientFlag = &cli.StringFlag{
 		Name:     "miner.pending.feeRecipient",
 		Usage:    "0x prefixed public address for the pending block producer (not used for actual block production)",
@@ -644,6 +665,11 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	HTTPSSEFlag = &cli.BoolFlag{
+		Name:     "http.sse",
+		Usage:    "Enable the subscriptions over the HTTP-RPC server as Server-Sent Events streams",
+		Category: flags.APICategory,
+	}
 	GraphQLEnabledFlag = &cli.BoolFlag{
 		Name:     "graphql",
 		Usage:    "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -723,6 +749,38 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Cost units replenished per second for each HTTP and WebSocket RPC client (0 = unlimited)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitBurstFlag = &cli.Float64Flag{
+		Name:     "rpc.ratelimit.burst",
+		Usage:    "Maximum cost units an RPC client can spend at once (default = rate)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitKeyFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.key",
+		Usage:    "Identification of the rate limited RPC clients ('ip' or 'header:<name>')",
+		Value:    rpc.RateLimitKeyIP,
+		Category: flags.APICategory,
+	}
+	RPCRateLimitCostsFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.costs",
+		Usage:    "Comma separated method costs, trailing '*' matches by prefix (e.g. 'eth_getLogs=10,debug_trace*=50')",
+		Category: flags.APICategory,
+	}
+	RPCRecordFlag = &cli.StringFlag{
+		Name:     "rpc.record",
+		Usage:    "File to record the HTTP and WebSocket RPC calls to as JSON lines (relative to the instance directory). The parameters and results of most methods are recorded as is, keep the file private",
+		Category: flags.APICategory,
+	}
+	RPCRecordMaxSizeFlag = &cli.IntFlag{
+		Name:     "rpc.record.maxsize",
+		Usage:    "Size in megabytes above which the RPC record file is rotated",
+		Value:    100,
+		Category: flags.APICategory,
+	}
 
 	// Network Settings
 	MaxPeersFlag = &cli.IntFlag{
@@ -1143,6 +1201,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(HTTPPathPrefixFlag.Name) {
 		cfg.HTTPPathPrefix = ctx.String(HTTPPathPrefixFlag.Name)
 	}
+	if ctx.IsSet(HTTPSSEFlag.Name) {
+		cfg.HTTPSSE = ctx.Bool(HTTPSSEFlag.Name)
+	}
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
@@ -1154,6 +1215,40 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+	setRPCRateLimit(ctx, cfg)
+
+	if ctx.IsSet(RPCRecordFlag.Name) {
+		cfg.RPCRecordFile = ctx.String(RPCRecordFlag.Name)
+		cfg.RPCRecordMaxSize = ctx.Int(RPCRecordMaxSizeFlag.Name)
+	}
+}
+
+// setRPCRateLimit configures the per-client rate limiting of the RPC endpoints
+// from the set command line flags.
+func setRPCRateLimit(ctx *cli.Context, cfg *node.Config) {
+	if ctx.IsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimit.Rate = ctx.Float64(RPCRateLimitFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitBurstFlag.Name) {
+		cfg.RPCRateLimit.Burst = ctx.Float64(RPCRateLimitBurstFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitKeyFlag.Name) {
+		cfg.RPCRateLimit.Key = ctx.String(RPCRateLimitKeyFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitCostsFlag.Name) {
+		cfg.RPCRateLimit.Costs = make(map[string]float64)
+		for _, entry := range SplitAndTrim(ctx.String(RPCRateLimitCostsFlag.Name)) {
+			method, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				Fatalf("Invalid rpc method cost entry: %s", entry)
+			}
+			cost, err := strconv.ParseFloat(value, 64)
+			if err != nil || cost < 0 {
+				Fatalf("Invalid rpc method cost entry: %s", entry)
+			}
+			cfg.RPCRateLimit.Costs[method] = cost
+		}
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1444,6 +1539,9 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.String(TxPoolSnapshotFlag.Name)
+	}
 	if ctx.IsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.Uint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1499,6 +1597,18 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 		log.Warn("The flag --miner.newpayload-timeout is deprecated and will be removed, please use --miner.recommit")
 		cfg.Recommit = ctx.Duration(MinerNewPayloadTimeoutFlag.Name)
 	}
+	if ctx.IsSet(MinerStrategyFlag.Name) {
+		cfg.Strategy = ctx.String(MinerStrategyFlag.Name)
+	}
+	if ctx.IsSet(MinerSenderCapFlag.Name) {
+		cfg.SenderCap = ctx.Uint64(MinerSenderCapFlag.Name)
+	}
+	if ctx.IsSet(MinerBundlesFlag.Name) {
+		cfg.Bundles = ctx.Bool(MinerBundlesFlag.Name)
+	}
+	if _, err := miner.NewStrategy(cfg.Strategy, cfg.SenderCap); err != nil {
+		Fatalf("Invalid miner configuration: %v", err)
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1932,7 +2042,10 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (*eth.EthAPIBac
 	if err != nil {
 		Fatalf("Failed to register the Ethereum service: %v", err)
 	}
-	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	tracers.Register(stack, backend.APIBackend, &tracers.APIConfig{
+		ChainTraceDir: stack.ResolvePath("chaintrace"),
+		CallIndex:     backend.CallAddressIndex(),
+	})
 	return backend.APIBackend, backend
 }
 
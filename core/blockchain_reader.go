@@ -356,6 +356,19 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.statedb)
 }
 
+// HistoricState returns a historic state specified by the given root.
+// Live states are not available and won't be served, please use `State`
+// or `StateAt` instead.
+//
+// Historic states are resolved from the state histories, it's only supported
+// by the path scheme.
+func (bc *BlockChain) HistoricState(root common.Hash) (*state.StateDB, error) {
+	if bc.triedb.Scheme() != rawdb.PathScheme {
+		return nil, errors.New("historic state is only supported in path scheme")
+	}
+	return state.New(root, state.NewHistoricDatabase(bc.triedb))
+}
+
 // Config retrieves the chain's fork configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
 
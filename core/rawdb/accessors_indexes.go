@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +180,47 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// ReadCallAddressIndexRange retrieves the range of blocks covered by the call
+// address index. False is returned if nothing is indexed yet.
+func ReadCallAddressIndexRange(db ethdb.KeyValueReader) (uint64, uint64, bool) {
+	data, _ := db.Get(callAddressIndexRangeKey)
+	if len(data) != 16 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(data[:8]), binary.BigEndian.Uint64(data[8:]), true
+}
+
+// WriteCallAddressIndexRange stores the range of blocks covered by the call
+// address index into database.
+func WriteCallAddressIndexRange(db ethdb.KeyValueWriter, first uint64, last uint64) {
+	if err := db.Put(callAddressIndexRangeKey, append(encodeBlockNumber(first), encodeBlockNumber(last)...)); err != nil {
+		log.Crit("Failed to store the call address index range", "err", err)
+	}
+}
+
+// WriteCallAddressIndex stores the index entry that the specified address is
+// involved in the call frames of the block with the given number.
+func WriteCallAddressIndex(db ethdb.KeyValueWriter, address common.Address, number uint64) {
+	if err := db.Put(callAddressIndexKey(address, number), nil); err != nil {
+		log.Crit("Failed to store call address index", "err", err)
+	}
+}
+
+// ReadCallAddressBlocks retrieves the numbers of the blocks within the range
+// [from, to] in which the specified address is involved in the call frames.
+func ReadCallAddressBlocks(db ethdb.Iteratee, address common.Address, from uint64, to uint64) []uint64 {
+	prefix := callAddressIndexPrefix(address)
+	it := NewKeyLengthIterator(db.NewIterator(prefix, encodeBlockNumber(from)), len(prefix)+8)
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		number := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		if number > to {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
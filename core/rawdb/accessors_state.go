@@ -160,6 +160,79 @@ func DeleteTrieJournal(db ethdb.KeyValueWriter) {
 	}
 }
 
+// ReadStateHistoryIndexHead retrieves the id of the latest indexed state history.
+// Nil is returned if the state histories are never indexed.
+func ReadStateHistoryIndexHead(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(headStateHistoryIndexKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteStateHistoryIndexHead stores the id of the latest indexed state history
+// into database.
+func WriteStateHistoryIndexHead(db ethdb.KeyValueWriter, id uint64) {
+	if err := db.Put(headStateHistoryIndexKey, encodeBlockNumber(id)); err != nil {
+		log.Crit("Failed to store the state history index head", "err", err)
+	}
+}
+
+// DeleteStateHistoryIndexHead deletes the id of the latest indexed state
+// history from the database.
+func DeleteStateHistoryIndexHead(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headStateHistoryIndexKey); err != nil {
+		log.Crit("Failed to delete the state history index head", "err", err)
+	}
+}
+
+// WriteAccountHistoryIndex stores the index entry that the specified account
+// is mutated in the state history with the given id.
+func WriteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, id uint64, block uint64) {
+	if err := db.Put(accountHistoryIndexKey(address, id), encodeBlockNumber(block)); err != nil {
+		log.Crit("Failed to store account history index", "err", err)
+	}
+}
+
+// DeleteAccountHistoryIndex deletes the specified account history index entry.
+func DeleteAccountHistoryIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Delete(accountHistoryIndexKey(address, id)); err != nil {
+		log.Crit("Failed to delete account history index", "err", err)
+	}
+}
+
+// IterateAccountHistoryIndex returns an iterator for walking the history index
+// entries of the specified account, starting from the given state id. The last
+// 8 bytes of the key is the state id and the value is the block number.
+func IterateAccountHistoryIndex(db ethdb.Iteratee, address common.Address, start uint64) ethdb.Iterator {
+	it := db.NewIterator(accountHistoryIndexPrefix(address), encodeBlockNumber(start))
+	return NewKeyLengthIterator(it, len(StateHistoryAccountIndexPrefix)+common.AddressLength+8)
+}
+
+// WriteStorageHistoryIndex stores the index entry that the specified storage
+// slot is mutated in the state history with the given id.
+func WriteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64, block uint64) {
+	if err := db.Put(storageHistoryIndexKey(address, slot, id), encodeBlockNumber(block)); err != nil {
+		log.Crit("Failed to store storage history index", "err", err)
+	}
+}
+
+// DeleteStorageHistoryIndex deletes the specified storage history index entry.
+func DeleteStorageHistoryIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64) {
+	if err := db.Delete(storageHistoryIndexKey(address, slot, id)); err != nil {
+		log.Crit("Failed to delete storage history index", "err", err)
+	}
+}
+
+// IterateStorageHistoryIndex returns an iterator for walking the history index
+// entries of the specified storage slot, starting from the given state id. The
+// last 8 bytes of the key is the state id and the value is the block number.
+func IterateStorageHistoryIndex(db ethdb.Iteratee, address common.Address, slot common.Hash, start uint64) ethdb.Iterator {
+	it := db.NewIterator(storageHistoryIndexPrefix(address, slot), encodeBlockNumber(start))
+	return NewKeyLengthIterator(it, len(StateHistoryStorageIndexPrefix)+common.AddressLength+common.HashLength+8)
+}
+
 // ReadStateHistoryMeta retrieves the metadata corresponding to the specified
 // state history. Compute the position of state history in freezer by minus
 // one since the id of first state history starts from one(zero for initial
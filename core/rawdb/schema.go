@@ -96,6 +96,13 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// headStateHistoryIndexKey tracks the id of the latest indexed state history.
+	headStateHistoryIndexKey = []byte("LastStateHistoryIndex")
+
+	// callAddressIndexRangeKey tracks the range of blocks covered by the call
+	// address index.
+	callAddressIndexRangeKey = []byte("CallAddressIndexRange")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -117,6 +124,13 @@ var (
 	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// State history indexes of path-based storage scheme.
+	StateHistoryAccountIndexPrefix = []byte("ma") // StateHistoryAccountIndexPrefix + address + state id (uint64 big endian) -> block number
+	StateHistoryStorageIndexPrefix = []byte("ms") // StateHistoryStorageIndexPrefix + address + slot hash + state id (uint64 big endian) -> block number
+
+	// Index of the addresses involved in the call frames of blocks.
+	CallAddressIndexPrefix = []byte("ci") // CallAddressIndexPrefix + address + num (uint64 big endian) -> nil
+
 	// VerklePrefix is the database prefix for Verkle trie data, which includes:
 	// (a) Trie nodes
 	// (b) In-memory trie node journal
@@ -272,6 +286,40 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// accountHistoryIndexPrefix = StateHistoryAccountIndexPrefix + address
+func accountHistoryIndexPrefix(address common.Address) []byte {
+	return append(StateHistoryAccountIndexPrefix, address.Bytes()...)
+}
+
+// accountHistoryIndexKey = StateHistoryAccountIndexPrefix + address + id (uint64 big endian)
+func accountHistoryIndexKey(address common.Address, id uint64) []byte {
+	return append(accountHistoryIndexPrefix(address), encodeBlockNumber(id)...)
+}
+
+// callAddressIndexPrefix = CallAddressIndexPrefix + address
+func callAddressIndexPrefix(address common.Address) []byte {
+	return append(CallAddressIndexPrefix, address.Bytes()...)
+}
+
+// callAddressIndexKey = CallAddressIndexPrefix + address + num (uint64 big endian)
+func callAddressIndexKey(address common.Address, number uint64) []byte {
+	return append(callAddressIndexPrefix(address), encodeBlockNumber(number)...)
+}
+
+// storageHistoryIndexPrefix = StateHistoryStorageIndexPrefix + address + slot hash
+func storageHistoryIndexPrefix(address common.Address, slot common.Hash) []byte {
+	buf := make([]byte, len(StateHistoryStorageIndexPrefix)+common.AddressLength+common.HashLength)
+	n := copy(buf, StateHistoryStorageIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	copy(buf[n:], slot.Bytes())
+	return buf
+}
+
+// storageHistoryIndexKey = StateHistoryStorageIndexPrefix + address + slot hash + id (uint64 big endian)
+func storageHistoryIndexKey(address common.Address, slot common.Hash, id uint64) []byte {
+	return append(storageHistoryIndexPrefix(address, slot), encodeBlockNumber(id)...)
+}
+
 // accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(TrieNodeAccountPrefix, path...)
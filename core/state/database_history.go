@@ -0,0 +1,256 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+// errHistoricTrie is returned if the trie of a historic state is accessed,
+// which is not available as the trie nodes have been overwritten already.
+var errHistoricTrie = errors.New("trie is not available for historic state")
+
+// historicReader wraps a historical state reader defined in path database,
+// providing historic state serving over the path scheme.
+type historicReader struct {
+	reader *pathdb.HistoricalStateReader
+	buff   crypto.KeccakState
+}
+
+// newHistoricReader constructs a reader for historic state serving.
+func newHistoricReader(r *pathdb.HistoricalStateReader) *historicReader {
+	return &historicReader{
+		reader: r,
+		buff:   crypto.NewKeccakState(),
+	}
+}
+
+// Account implements Reader, retrieving the account specified by the address.
+//
+// An error will be returned if the associated state history is already pruned.
+//
+// The returned account might be nil if it's not existent.
+func (r *historicReader) Account(addr common.Address) (*types.StateAccount, error) {
+	account, err := r.reader.Account(addr)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	acct := &types.StateAccount{
+		Nonce:    account.Nonce,
+		Balance:  account.Balance,
+		CodeHash: account.CodeHash,
+		Root:     common.BytesToHash(account.Root),
+	}
+	if len(acct.CodeHash) == 0 {
+		acct.CodeHash = types.EmptyCodeHash.Bytes()
+	}
+	if acct.Root == (common.Hash{}) {
+		acct.Root = types.EmptyRootHash
+	}
+	return acct, nil
+}
+
+// Storage implements Reader, retrieving the storage slot specified by the
+// address and slot key.
+//
+// An error will be returned if the associated state history is already pruned.
+//
+// The returned storage slot might be empty if it's not existent.
+func (r *historicReader) Storage(addr common.Address, key common.Hash) (common.Hash, error) {
+	slotHash := crypto.HashData(r.buff, key.Bytes())
+	ret, err := r.reader.Storage(addr, slotHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(ret) == 0 {
+		return common.Hash{}, nil
+	}
+	// Perform the rlp-decode as the slot value is RLP-encoded in the state
+	// history.
+	_, content, _, err := rlp.Split(ret)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var value common.Hash
+	value.SetBytes(content)
+	return value, nil
+}
+
+// Copy implements Reader, returning a deep-copied historic reader.
+func (r *historicReader) Copy() Reader {
+	return &historicReader{
+		reader: r.reader,
+		buff:   crypto.NewKeccakState(),
+	}
+}
+
+// historicTrie is a placeholder of the trie belonging to a historic state.
+// The historic state is read-only and its trie nodes are no longer available,
+// so all trie operations are rejected.
+type historicTrie struct {
+	root common.Hash
+}
+
+func (t *historicTrie) GetKey([]byte) []byte { return nil }
+
+func (t *historicTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	return nil, errHistoricTrie
+}
+
+func (t *historicTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	return nil, errHistoricTrie
+}
+
+func (t *historicTrie) UpdateAccount(address common.Address, account *types.StateAccount, codeLen int) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) DeleteAccount(address common.Address) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) Hash() common.Hash { return t.root }
+
+func (t *historicTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet) { return t.root, nil }
+
+func (t *historicTrie) Witness() map[string]struct{} { return nil }
+
+func (t *historicTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errHistoricTrie
+}
+
+func (t *historicTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errHistoricTrie
+}
+
+func (t *historicTrie) IsVerkle() bool { return false }
+
+// HistoricDB is an implementation of Database interface, providing access to
+// the historic states which are no longer maintained by the trie database,
+// by resolving them from the state histories of path database.
+//
+// The states opened with it are meant for read-only usage, e.g. serving the
+// RPC requests, any state mutation can't be committed.
+type HistoricDB struct {
+	disk          ethdb.KeyValueStore
+	triedb        *triedb.Database
+	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
+	codeSizeCache *lru.Cache[common.Hash, int]
+	pointCache    *utils.PointCache
+}
+
+// NewHistoricDatabase creates a historic state database with the provided
+// trie database, which must be in path mode.
+func NewHistoricDatabase(triedb *triedb.Database) *HistoricDB {
+	return &HistoricDB{
+		disk:          triedb.Disk(),
+		triedb:        triedb,
+		codeCache:     lru.NewSizeConstrainedCache[common.Hash, []byte](codeCacheSize),
+		codeSizeCache: lru.NewCache[common.Hash, int](codeSizeCacheSize),
+		pointCache:    utils.NewPointCache(pointCacheSize),
+	}
+}
+
+// Reader returns a state reader associated with the specified state root.
+func (db *HistoricDB) Reader(stateRoot common.Hash) (Reader, error) {
+	hr, err := db.triedb.HistoricReader(stateRoot)
+	if err != nil {
+		return nil, err
+	}
+	return newHistoricReader(hr), nil
+}
+
+// OpenTrie opens the main account trie. The returned trie is a placeholder
+// as the trie of historic state is not available.
+func (db *HistoricDB) OpenTrie(root common.Hash) (Trie, error) {
+	return &historicTrie{root: root}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account. The returned trie is
+// a placeholder as the trie of historic state is not available.
+func (db *HistoricDB) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash, self Trie) (Trie, error) {
+	return &historicTrie{root: root}, nil
+}
+
+// ContractCode retrieves a particular contract's code.
+func (db *HistoricDB) ContractCode(address common.Address, codeHash common.Hash) ([]byte, error) {
+	code, _ := db.codeCache.Get(codeHash)
+	if len(code) > 0 {
+		return code, nil
+	}
+	code = rawdb.ReadCode(db.disk, codeHash)
+	if len(code) > 0 {
+		db.codeCache.Add(codeHash, code)
+		db.codeSizeCache.Add(codeHash, len(code))
+		return code, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// ContractCodeSize retrieves a particular contracts code's size.
+func (db *HistoricDB) ContractCodeSize(addr common.Address, codeHash common.Hash) (int, error) {
+	if cached, ok := db.codeSizeCache.Get(codeHash); ok {
+		return cached, nil
+	}
+	code, err := db.ContractCode(addr, codeHash)
+	return len(code), err
+}
+
+// TrieDB returns the underlying trie database for managing trie nodes.
+func (db *HistoricDB) TrieDB() *triedb.Database {
+	return db.triedb
+}
+
+// PointCache returns the cache of evaluated curve points.
+func (db *HistoricDB) PointCache() *utils.PointCache {
+	return db.pointCache
+}
+
+// Snapshot returns the underlying state snapshot, which is not available
+// for historic state.
+func (db *HistoricDB) Snapshot() *snapshot.Tree {
+	return nil
+}
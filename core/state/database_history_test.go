@@ -0,0 +1,85 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+func TestHistoricState(t *testing.T) {
+	disk, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	var (
+		tdb   = triedb.NewDatabase(disk, &triedb.Config{PathDB: pathdb.Defaults})
+		db    = NewDatabase(tdb, nil)
+		addrA = common.HexToAddress("0xaaaa")
+		addrB = common.HexToAddress("0xbbbb")
+		slot  = common.HexToHash("0x01")
+		roots []common.Hash
+	)
+	defer tdb.Close()
+
+	root := types.EmptyRootHash
+	for i := uint64(1); i <= 4; i++ {
+		state, _ := New(root, db)
+		state.SetBalance(addrA, uint256.NewInt(i), tracing.BalanceChangeUnspecified)
+		state.SetState(addrA, slot, common.BigToHash(uint256.NewInt(i*10).ToBig()))
+		if i == 2 {
+			state.SetBalance(addrB, uint256.NewInt(100), tracing.BalanceChangeUnspecified)
+		}
+		root, err = state.Commit(i, false)
+		if err != nil {
+			t.Fatalf("Failed to commit state: %v", err)
+		}
+		// Flush the state into disk to produce state histories
+		if err := tdb.Commit(root, false); err != nil {
+			t.Fatalf("Failed to flush state: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	hdb := NewHistoricDatabase(tdb)
+	for i, root := range roots {
+		number := uint64(i + 1)
+
+		if _, err := New(root, db); err == nil && i != len(roots)-1 {
+			t.Fatalf("Unexpected live state %d", number)
+		}
+		state, err := New(root, hdb)
+		if err != nil {
+			t.Fatalf("Failed to open historic state %d: %v", number, err)
+		}
+		if balance := state.GetBalance(addrA); balance.Uint64() != number {
+			t.Fatalf("Unexpected balance for state %d, want: %d, got: %d", number, number, balance.Uint64())
+		}
+		if value := state.GetState(addrA, slot); value != common.BigToHash(uint256.NewInt(number*10).ToBig()) {
+			t.Fatalf("Unexpected slot for state %d: %x", number, value)
+		}
+		if exist := state.Exist(addrB); exist != (number >= 2) {
+			t.Fatalf("Unexpected existence for state %d, got: %v", number, exist)
+		}
+	}
+}
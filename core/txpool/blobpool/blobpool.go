@@ -317,6 +317,9 @@ type BlobPool struct {
 
 	discoverFeed event.Feed // Event feed to send out new tx events on pool discovery (reorg excluded)
 	insertFeed   event.Feed // Event feed to send out new tx events on pool inclusion (reorg included)
+	txEventFeed  event.Feed // Event feed to send out the lifecycle transitions of the pooled txs
+
+	txEvents []txpool.TxEvent // Lifecycle events recorded under the lock, waiting to be sent
 
 	// txValidationFn defaults to txpool.ValidateTransaction, but can be
 	// overridden for testing purposes.
@@ -439,6 +442,7 @@ func (p *BlobPool) Init(gasTip uint64, head *types.Header, reserve txpool.Addres
 	// Update the metrics and return the constructed pool
 	datacapGauge.Update(int64(p.config.Datacap))
 	p.updateStorageMetrics()
+	p.flushTxEvents()
 	return nil
 }
 
@@ -545,6 +549,11 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			if filled && inclusions != nil {
 				p.offload(addr, txs[i].nonce, txs[i].id, inclusions)
 			}
+			if filled {
+				p.emitStale(txs[i].hash, inclusions)
+			} else {
+				p.emitTxEvent(txs[i].hash, txpool.TxDropped, txpool.TxReasonGapped)
+			}
 		}
 		delete(p.index, addr)
 		delete(p.spent, addr)
@@ -586,6 +595,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			if inclusions != nil {
 				p.offload(addr, txs[0].nonce, txs[0].id, inclusions)
 			}
+			p.emitStale(txs[0].hash, inclusions)
 			txs = txs[1:]
 		}
 		log.Trace("Dropping overlapped blob transactions", "from", addr, "overlapped", nonces, "ids", ids, "left", len(txs))
@@ -637,6 +647,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 			p.stored -= uint64(txs[i].size)
 			p.lookup.untrack(txs[i])
+			p.emitTxEvent(txs[i].hash, txpool.TxDropped, txpool.TxReasonInvalid)
 
 			if err := p.store.Delete(id); err != nil {
 				log.Error("Failed to delete blob transaction", "from", addr, "id", id, "err", err)
@@ -659,6 +670,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[j].costCap)
 			p.stored -= uint64(txs[j].size)
 			p.lookup.untrack(txs[j])
+			p.emitTxEvent(txs[j].hash, txpool.TxDropped, txpool.TxReasonGapped)
 		}
 		txs = txs[:i]
 
@@ -697,6 +709,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			p.lookup.untrack(last)
+			p.emitTxEvent(last.hash, txpool.TxDropped, txpool.TxReasonUnexecutable)
 		}
 		if len(txs) == 0 {
 			delete(p.index, addr)
@@ -737,6 +750,7 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
 			p.lookup.untrack(last)
+			p.emitTxEvent(last.hash, txpool.TxEvicted, txpool.TxReasonCapacity)
 		}
 		p.index[addr] = txs
 
@@ -785,9 +799,40 @@ func (p *BlobPool) offload(addr common.Address, nonce uint64, id uint64, inclusi
 	}
 }
 
+// emitTxEvent records a lifecycle transition of a pooled transaction, to be sent
+// out on the next flush. The pool lock must be held.
+func (p *BlobPool) emitTxEvent(hash common.Hash, kind txpool.TxEventKind, reason txpool.TxEventReason) {
+	p.txEvents = append(p.txEvents, txpool.TxEvent{Hash: hash, Kind: kind, Reason: reason})
+}
+
+// emitStale records the removal of a transaction whose nonce was used up on chain,
+// reporting it as included if it is contained in the given inclusions.
+func (p *BlobPool) emitStale(hash common.Hash, inclusions map[common.Hash]uint64) {
+	if block, ok := inclusions[hash]; ok {
+		p.txEvents = append(p.txEvents, txpool.TxEvent{Hash: hash, Kind: txpool.TxIncluded, Block: block})
+		return
+	}
+	p.emitTxEvent(hash, txpool.TxDropped, txpool.TxReasonNonceTooLow)
+}
+
+// flushTxEvents sends out the lifecycle events recorded since the last flush.
+// The pool lock must not be held, since subscribers may call back into the pool.
+func (p *BlobPool) flushTxEvents() {
+	p.lock.Lock()
+	events := p.txEvents
+	p.txEvents = nil
+	p.lock.Unlock()
+
+	if len(events) > 0 {
+		p.txEventFeed.Send(events)
+	}
+}
+
 // Reset implements txpool.SubPool, allowing the blob pool's internal state to be
 // kept in sync with the main transaction pool's internal state.
 func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
+	defer p.flushTxEvents()
+
 	waitStart := time.Now()
 	p.lock.Lock()
 	resetwaitHist.Update(time.Since(waitStart).Nanoseconds())
@@ -1022,6 +1067,8 @@ func (p *BlobPool) reinject(addr common.Address, txhash common.Hash) error {
 // SetGasTip implements txpool.SubPool, allowing the blob pool's gas requirements
 // to be kept in sync with the main transaction pool's gas requirements.
 func (p *BlobPool) SetGasTip(tip *big.Int) {
+	defer p.flushTxEvents()
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -1042,6 +1089,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 					p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 					p.stored -= uint64(tx.size)
 					p.lookup.untrack(tx)
+					p.emitTxEvent(tx.hash, txpool.TxEvicted, txpool.TxReasonUnderpriced)
 					txs[i] = nil
 
 					// Drop everything afterwards, no gaps allowed
@@ -1052,6 +1100,7 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 						p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], tx.costCap)
 						p.stored -= uint64(tx.size)
 						p.lookup.untrack(tx)
+						p.emitTxEvent(tx.hash, txpool.TxDropped, txpool.TxReasonGapped)
 						txs[i+1+j] = nil
 					}
 					// Clear out the dropped transactions from the index
@@ -1283,6 +1332,7 @@ func (p *BlobPool) Add(txs []*types.Transaction, local bool, sync bool) []error
 		p.discoverFeed.Send(core.NewTxsEvent{Txs: adds})
 		p.insertFeed.Send(core.NewTxsEvent{Txs: adds})
 	}
+	p.flushTxEvents()
 	return errs
 }
 
@@ -1382,6 +1432,8 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.lookup.untrack(prev)
 		p.lookup.track(meta)
 		p.stored += uint64(meta.size) - uint64(prev.size)
+
+		p.txEvents = append(p.txEvents, txpool.TxEvent{Hash: prev.hash, Kind: txpool.TxReplaced, ReplacedBy: meta.hash})
 	} else {
 		// Transaction extends previously scheduled ones
 		p.index[from] = append(p.index[from], meta)
@@ -1393,6 +1445,8 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.lookup.track(meta)
 		p.stored += uint64(meta.size)
 	}
+	p.emitTxEvent(meta.hash, txpool.TxPromoted, "")
+
 	// Recompute the rolling eviction fields. In case of a replacement, this will
 	// recompute all subsequent fields. In case of an append, this will only do
 	// the fresh calculation.
@@ -1480,6 +1534,7 @@ func (p *BlobPool) drop() {
 	}
 	p.stored -= uint64(drop.size)
 	p.lookup.untrack(drop)
+	p.emitTxEvent(drop.hash, txpool.TxEvicted, txpool.TxReasonCapacity)
 
 	// Remove the transaction from the pool's eviction heap:
 	//   - If the entire account was dropped, pop off the address
@@ -1655,6 +1710,12 @@ func (p *BlobPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool
 	}
 }
 
+// SubscribeTxEvents registers a subscription for the lifecycle transitions of
+// the pooled transactions.
+func (p *BlobPool) SubscribeTxEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return p.txEventFeed.Subscribe(ch)
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (p *BlobPool) Nonce(addr common.Address) uint64 {
@@ -25,8 +25,10 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
@@ -1449,6 +1451,81 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+// Tests that the lifecycle transitions of the pooled transactions are reported
+// to the event subscribers.
+func TestTxEvents(t *testing.T) {
+	var (
+		key, _     = crypto.GenerateKey()
+		addr       = crypto.PubkeyToAddress(key.PublicKey)
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	)
+	statedb.AddBalance(addr, uint256.NewInt(1_000_000_000_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  params.MainnetChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: t.TempDir()}, chain)
+	if err := pool.Init(1, chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	events := make(chan []txpool.TxEvent, 32)
+	sub := pool.SubscribeTxEvents(events)
+	defer sub.Unsubscribe()
+
+	var (
+		tx0      = makeTx(0, 1, 1000, 100, key)
+		tx1      = makeTx(1, 1, 1000, 100, key)
+		replaced = makeTx(1, 2, 2000, 200, key)
+	)
+	for _, err := range pool.Add([]*types.Transaction{tx0, tx1, replaced}, false, true) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Include the first transaction in a block
+	header := &types.Header{
+		Number:  big.NewInt(int64(chain.CurrentBlock().Number.Uint64() + 1)),
+		BaseFee: chain.CurrentBlock().BaseFee,
+	}
+	chain.blocks = map[uint64]*types.Block{
+		header.Number.Uint64(): types.NewBlockWithHeader(header).WithBody(types.Body{
+			Transactions: []*types.Transaction{tx0},
+		}),
+	}
+	statedb.SetNonce(addr, 1)
+	pool.Reset(chain.CurrentBlock(), header)
+
+	// Transactions below the minimum tip are evicted
+	pool.SetGasTip(big.NewInt(3))
+
+	want := []txpool.TxEvent{
+		{Hash: tx0.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx1.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx1.Hash(), Kind: txpool.TxReplaced, ReplacedBy: replaced.Hash()},
+		{Hash: replaced.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx0.Hash(), Kind: txpool.TxIncluded, Block: header.Number.Uint64()},
+		{Hash: replaced.Hash(), Kind: txpool.TxEvicted, Reason: txpool.TxReasonUnderpriced},
+	}
+	var have []txpool.TxEvent
+	for len(have) < len(want) {
+		select {
+		case evs := <-events:
+			have = append(have, evs...)
+		case <-time.After(time.Second):
+			t.Fatalf("event timeout: have %d, want %d", len(have), len(want))
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
 // fakeBilly is a billy.Database implementation which just drops data on the floor.
 type fakeBilly struct {
 	billy.Database
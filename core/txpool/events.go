@@ -0,0 +1,70 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import "github.com/ethereum/go-ethereum/common"
+
+// TxEventKind is the lifecycle transition of a pooled transaction.
+type TxEventKind string
+
+const (
+	// TxQueued is emitted when a transaction is added to the non-executable
+	// queue, either when it is first seen or when it is demoted from pending.
+	TxQueued TxEventKind = "queued"
+
+	// TxPromoted is emitted when a transaction becomes executable.
+	TxPromoted TxEventKind = "promoted"
+
+	// TxReplaced is emitted when a transaction is replaced by another one with
+	// the same nonce from the same sender.
+	TxReplaced TxEventKind = "replaced"
+
+	// TxEvicted is emitted when a still valid transaction is removed from the
+	// pool to make room for others.
+	TxEvicted TxEventKind = "evicted"
+
+	// TxDropped is emitted when a transaction is removed from the pool because
+	// it became invalid.
+	TxDropped TxEventKind = "dropped"
+
+	// TxIncluded is emitted when a transaction is removed from the pool because
+	// it was included in a block.
+	TxIncluded TxEventKind = "included"
+)
+
+// TxEventReason is the cause of the eviction or drop of a pooled transaction.
+type TxEventReason string
+
+const (
+	TxReasonUnderpriced  TxEventReason = "underpriced"   // Price below the pool minimum or cheaper than the incoming transactions
+	TxReasonCapacity     TxEventReason = "capacity"      // Pool or account limits exceeded
+	TxReasonNonceTooLow  TxEventReason = "nonce too low" // Nonce used by another transaction included in the chain
+	TxReasonUnexecutable TxEventReason = "unexecutable"  // Insufficient funds or gas above the block limit
+	TxReasonGapped       TxEventReason = "gapped"        // Nonce gap left by a preceding transaction
+	TxReasonExpired      TxEventReason = "expired"       // Queued for longer than the pool lifetime
+	TxReasonInvalid      TxEventReason = "invalid"       // Failed the pool validation rules
+	TxReasonRemoved      TxEventReason = "removed"       // Removed explicitly from the pool
+)
+
+// TxEvent is a lifecycle transition of a pooled transaction.
+type TxEvent struct {
+	Hash       common.Hash   // Hash of the transaction
+	Kind       TxEventKind   // Lifecycle transition of the transaction
+	Reason     TxEventReason // Cause of the eviction or drop, empty otherwise
+	ReplacedBy common.Hash   // Hash of the replacing transaction, set for TxReplaced
+	Block      uint64        // Number of the including block, set for TxIncluded
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -121,12 +122,20 @@ type BlockChain interface {
 	StateAt(root common.Hash) (*state.StateDB, error)
 }
 
+// txLookupChain is implemented by the chains indexing their transactions. It's
+// used to tell the included transactions apart from the dropped ones when the
+// pool didn't walk the chain segment including them.
+type txLookupChain interface {
+	GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLookupEntry, *types.Transaction, error)
+}
+
 // Config are the configuration parameters of the transaction pool.
 type Config struct {
 	Locals    []common.Address // Addresses that should be treated by default as local
 	NoLocals  bool             // Whether local transaction handling should be disabled
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
+	Snapshot  string           // Snapshot of the pooled transactions written on shutdown, disabled if empty
 
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
@@ -207,6 +216,7 @@ type LegacyPool struct {
 	chain       BlockChain
 	gasTip      atomic.Pointer[uint256.Int]
 	txFeed      event.Feed
+	txEventFeed event.Feed
 	signer      types.Signer
 	mu          sync.RWMutex
 
@@ -233,6 +243,8 @@ type LegacyPool struct {
 	initDoneCh      chan struct{}  // is closed once the pool is initialized (for tests)
 
 	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
+
+	txEvents []txpool.TxEvent // Lifecycle events recorded under the lock, waiting to be sent
 }
 
 type txpoolResetRequest struct {
@@ -325,6 +337,12 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If snapshotting is enabled, reload the transactions of the previous run
+	if pool.config.Snapshot != "" {
+		if err := loadSnapshot(pool.config.Snapshot, pool.addRemotesSync); err != nil {
+			log.Warn("Failed to load transaction pool snapshot", "err", err)
+		}
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 	return nil
@@ -382,10 +400,12 @@ func (pool *LegacyPool) loop() {
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true, true)
 					}
+					pool.emitTxEvents(txpool.TxDropped, txpool.TxReasonExpired, list)
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
 			pool.mu.Unlock()
+			pool.flushTxEvents()
 
 		// Handle local transaction journal rotation
 		case <-journal.C:
@@ -409,6 +429,15 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.config.Snapshot != "" {
+		pool.mu.RLock()
+		txs := pool.snapshotContent()
+		pool.mu.RUnlock()
+
+		if err := writeSnapshot(pool.config.Snapshot, txs); err != nil {
+			log.Warn("Failed to write transaction pool snapshot", "err", err)
+		}
+	}
 	log.Info("Transaction pool stopped")
 	return nil
 }
@@ -430,9 +459,69 @@ func (pool *LegacyPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs
 	return pool.txFeed.Subscribe(ch)
 }
 
+// SubscribeTxEvents registers a subscription for the lifecycle transitions of
+// the pooled transactions.
+func (pool *LegacyPool) SubscribeTxEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return pool.txEventFeed.Subscribe(ch)
+}
+
+// emitTxEvents records a lifecycle transition of the given transactions, to be
+// sent out when the pool lock is released.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) emitTxEvents(kind txpool.TxEventKind, reason txpool.TxEventReason, txs types.Transactions) {
+	for _, tx := range txs {
+		pool.txEvents = append(pool.txEvents, txpool.TxEvent{Hash: tx.Hash(), Kind: kind, Reason: reason})
+	}
+}
+
+// emitReplaced records the replacement of a pooled transaction by a new one.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) emitReplaced(old, tx *types.Transaction) {
+	pool.txEvents = append(pool.txEvents, txpool.TxEvent{Hash: old.Hash(), Kind: txpool.TxReplaced, ReplacedBy: tx.Hash()})
+}
+
+// emitNonceTooLow records the removal of a transaction whose nonce was used in
+// the chain. It's reported as included if found in the inclusions, mapping the
+// transaction hashes to block numbers. If the inclusions are unknown (nil), the
+// transaction is looked up in the chain instead.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *LegacyPool) emitNonceTooLow(tx *types.Transaction, inclusions map[common.Hash]uint64) {
+	hash := tx.Hash()
+	if inclusions != nil {
+		if number, ok := inclusions[hash]; ok {
+			pool.txEvents = append(pool.txEvents, txpool.TxEvent{Hash: hash, Kind: txpool.TxIncluded, Block: number})
+			return
+		}
+	} else if chain, ok := pool.chain.(txLookupChain); ok {
+		if lookup, _, _ := chain.GetTransactionLookup(hash); lookup != nil {
+			pool.txEvents = append(pool.txEvents, txpool.TxEvent{Hash: hash, Kind: txpool.TxIncluded, Block: lookup.BlockIndex})
+			return
+		}
+	}
+	pool.emitTxEvents(txpool.TxDropped, txpool.TxReasonNonceTooLow, types.Transactions{tx})
+}
+
+// flushTxEvents sends out the lifecycle events recorded since the last flush.
+// The pool lock must not be held, since subscribers may call back into the pool.
+func (pool *LegacyPool) flushTxEvents() {
+	pool.mu.Lock()
+	events := pool.txEvents
+	pool.txEvents = nil
+	pool.mu.Unlock()
+
+	if len(events) > 0 {
+		pool.txEventFeed.Send(events)
+	}
+}
+
 // SetGasTip updates the minimum gas tip required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *LegacyPool) SetGasTip(tip *big.Int) {
+	defer pool.flushTxEvents()
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -448,6 +537,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false, true)
 		}
+		pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonUnderpriced, drop)
 		pool.priced.Removed(len(drop))
 	}
 	log.Info("Legacy pool tip threshold updated", "tip", newTip)
@@ -772,6 +862,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 
 			pool.changesSinceReorg += dropped
 		}
+		pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonUnderpriced, drop)
 	}
 
 	// Try to replace an existing transaction in the pending pool
@@ -787,11 +878,13 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.emitReplaced(old, tx)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
+		pool.emitTxEvents(txpool.TxPromoted, "", types.Transactions{tx})
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
@@ -862,10 +955,13 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.emitReplaced(old, tx)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
 	}
+	pool.emitTxEvents(txpool.TxQueued, "", types.Transactions{tx})
+
 	// If the transaction isn't in lookup set but it's expected to be there,
 	// show the error log.
 	if pool.all.Get(hash) == nil && !addAll {
@@ -911,6 +1007,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonUnderpriced, types.Transactions{tx})
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -918,10 +1015,12 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.emitReplaced(old, tx)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
 	}
+	pool.emitTxEvents(txpool.TxPromoted, "", types.Transactions{tx})
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
 
@@ -1011,6 +1110,7 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	pool.mu.Lock()
 	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
 	pool.mu.Unlock()
+	pool.flushTxEvents()
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -1090,6 +1190,23 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 	return pool.all.Get(hash) != nil
 }
 
+// Remove drops a single transaction from the pool, moving all subsequent
+// transactions of the account back to the future queue.
+func (pool *LegacyPool) Remove(hash common.Hash) bool {
+	defer pool.flushTxEvents()
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all.Get(hash)
+	if tx == nil {
+		return false
+	}
+	pool.removeTx(hash, true, true)
+	pool.emitTxEvents(txpool.TxDropped, txpool.TxReasonRemoved, types.Transactions{tx})
+	return true
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 //
@@ -1278,10 +1395,12 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		// the flatten operation can be avoided.
 		promoteAddrs = dirtyAccounts.flatten()
 	}
+	var inclusions map[common.Hash]uint64
+
 	pool.mu.Lock()
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
-		pool.reset(reset.oldHead, reset.newHead)
+		inclusions = pool.reset(reset.oldHead, reset.newHead)
 
 		// Nonces were reset, discard any events that became stale
 		for addr := range events {
@@ -1297,13 +1416,13 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		}
 	}
 	// Check for pending transactions for every account that sent new ones
-	promoted := pool.promoteExecutables(promoteAddrs)
+	promoted := pool.promoteExecutables(promoteAddrs, inclusions)
 
 	// If a new block appeared, validate the pool of pending transactions. This will
 	// remove any transaction that has been included in the block or was invalidated
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
-		pool.demoteUnexecutables()
+		pool.demoteUnexecutables(inclusions)
 		if reset.newHead != nil {
 			if pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
 				pendingBaseFee := eip1559.CalcBaseFee(pool.chainconfig, reset.newHead)
@@ -1327,6 +1446,7 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	dropBetweenReorgHistogram.Update(int64(pool.changesSinceReorg))
 	pool.changesSinceReorg = 0 // Reset change counter
 	pool.mu.Unlock()
+	pool.flushTxEvents()
 
 	// Notify subsystems for newly added transactions
 	for _, tx := range promoted {
@@ -1346,11 +1466,20 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
-// of the transaction pool is valid with regard to the chain state.
-func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
+// of the transaction pool is valid with regard to the chain state. It returns the
+// transactions included by the new chain segment, mapped to their block numbers,
+// or nil if the segment wasn't walked.
+func (pool *LegacyPool) reset(oldHead, newHead *types.Header) map[common.Hash]uint64 {
 	// If we're reorging an old state, reinject all dropped transactions
-	var reinject types.Transactions
-
+	var (
+		reinject   types.Transactions
+		inclusions = make(map[common.Hash]uint64)
+	)
+	include := func(block *types.Block) {
+		for _, tx := range block.Transactions() {
+			inclusions[tx.Hash()] = block.NumberU64()
+		}
+	}
 	if oldHead != nil && oldHead.Hash() != newHead.ParentHash {
 		// If the reorg is too deep, avoid doing it (will happen during fast sync)
 		oldNum := oldHead.Number.Uint64()
@@ -1358,6 +1487,7 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 
 		if depth := uint64(math.Abs(float64(oldNum) - float64(newNum))); depth > 64 {
 			log.Debug("Skipping deep transaction reorg", "depth", depth)
+			inclusions = nil
 		} else {
 			// Reorg seems shallow enough to pull in all transactions into memory
 			var (
@@ -1373,7 +1503,7 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 					// If we reorged to a same or higher number, then it's not a case of setHead
 					log.Warn("Transaction pool reset with missing old head",
 						"old", oldHead.Hash(), "oldnum", oldNum, "new", newHead.Hash(), "newnum", newNum)
-					return
+					return nil
 				}
 				// If the reorg ended up on a lower number, it's indicative of setHead being the cause
 				log.Debug("Skipping transaction reset caused by setHead",
@@ -1386,33 +1516,35 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 					// reorg caused by sync-reversion or explicit sethead back to an
 					// earlier block.
 					log.Warn("Transaction pool reset with missing new head", "number", newHead.Number, "hash", newHead.Hash())
-					return
+					return nil
 				}
 				var discarded, included types.Transactions
 				for rem.NumberU64() > add.NumberU64() {
 					discarded = append(discarded, rem.Transactions()...)
 					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
 						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-						return
+						return nil
 					}
 				}
 				for add.NumberU64() > rem.NumberU64() {
 					included = append(included, add.Transactions()...)
+					include(add)
 					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
 						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-						return
+						return nil
 					}
 				}
 				for rem.Hash() != add.Hash() {
 					discarded = append(discarded, rem.Transactions()...)
 					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
 						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-						return
+						return nil
 					}
 					included = append(included, add.Transactions()...)
+					include(add)
 					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
 						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-						return
+						return nil
 					}
 				}
 				lost := make([]*types.Transaction, 0, len(discarded))
@@ -1424,6 +1556,13 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 				reinject = lost
 			}
 		}
+	} else if newHead != nil {
+		// The new head extends the old one, only its own transactions got included
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			include(block)
+		} else {
+			inclusions = nil
+		}
 	}
 	// Initialize the internal state to the current head
 	if newHead == nil {
@@ -1432,7 +1571,7 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	statedb, err := pool.chain.StateAt(newHead.Root)
 	if err != nil {
 		log.Error("Failed to reset txpool state", "err", err)
-		return
+		return nil
 	}
 	pool.currentHead.Store(newHead)
 	pool.currentState = statedb
@@ -1442,12 +1581,15 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	core.SenderCacher.Recover(pool.signer, reinject)
 	pool.addTxsLocked(reinject, false)
+	return inclusions
 }
 
 // promoteExecutables moves transactions that have become processable from the
 // future queue to the set of pending transactions. During this process, all
-// invalidated transactions (low nonce, low balance) are deleted.
-func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.Transaction {
+// invalidated transactions (low nonce, low balance) are deleted. The ones with
+// a low nonce are reported as included if found in the given inclusions, see
+// emitNonceTooLow.
+func (pool *LegacyPool) promoteExecutables(accounts []common.Address, inclusions map[common.Hash]uint64) []*types.Transaction {
 	// Track the promoted transactions to broadcast them at once
 	var promoted []*types.Transaction
 
@@ -1463,6 +1605,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range forwards {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.emitNonceTooLow(tx, inclusions)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -1471,6 +1614,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.emitTxEvents(txpool.TxDropped, txpool.TxReasonUnexecutable, drops)
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1494,6 +1638,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonCapacity, caps)
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1562,6 +1707,7 @@ func (pool *LegacyPool) truncatePending() {
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
+					pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonCapacity, caps)
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
@@ -1589,6 +1735,7 @@ func (pool *LegacyPool) truncatePending() {
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
+				pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonCapacity, caps)
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
@@ -1629,9 +1776,11 @@ func (pool *LegacyPool) truncateQueue() {
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
-			for _, tx := range list.Flatten() {
+			txs := list.Flatten()
+			for _, tx := range txs {
 				pool.removeTx(tx.Hash(), true, true)
 			}
+			pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonCapacity, txs)
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
 			continue
@@ -1640,6 +1789,7 @@ func (pool *LegacyPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true, true)
+			pool.emitTxEvents(txpool.TxEvicted, txpool.TxReasonCapacity, txs[i:i+1])
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1650,10 +1800,13 @@ func (pool *LegacyPool) truncateQueue() {
 // executable/pending queue and any subsequent transactions that become unexecutable
 // are moved back into the future queue.
 //
+// The transactions dropped because of their nonce are reported as included if they
+// are contained in the given inclusions, see emitNonceTooLow.
+//
 // Note: transactions are not marked as removed in the priced list because re-heaping
 // is always explicitly triggered by SetBaseFee and it would be unnecessary and wasteful
 // to trigger a re-heap is this function
-func (pool *LegacyPool) demoteUnexecutables() {
+func (pool *LegacyPool) demoteUnexecutables(inclusions map[common.Hash]uint64) {
 	// Iterate over all accounts and demote any non-executable transactions
 	gasLimit := pool.currentHead.Load().GasLimit
 	for addr, list := range pool.pending {
@@ -1665,6 +1818,8 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
+
+			pool.emitNonceTooLow(tx, inclusions)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), gasLimit)
@@ -1673,6 +1828,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.emitTxEvents(txpool.TxDropped, txpool.TxReasonUnexecutable, drops)
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
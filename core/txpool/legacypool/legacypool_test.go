@@ -24,6 +24,8 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -31,6 +33,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/txpool"
@@ -403,7 +406,7 @@ func TestQueue2(t *testing.T) {
 	pool.enqueueTx(tx2.Hash(), tx2, false, true)
 	pool.enqueueTx(tx3.Hash(), tx3, false, true)
 
-	pool.promoteExecutables([]common.Address{from})
+	pool.promoteExecutables([]common.Address{from}, nil)
 	if len(pool.pending) != 1 {
 		t.Error("expected pending length to be 1, got", len(pool.pending))
 	}
@@ -2551,7 +2554,7 @@ func benchmarkPendingDemotion(b *testing.B, size int) {
 	// Benchmark the speed of pool validation
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		pool.demoteUnexecutables()
+		pool.demoteUnexecutables(nil)
 	}
 }
 
@@ -2576,7 +2579,7 @@ func benchmarkFuturePromotion(b *testing.B, size int) {
 	// Benchmark the speed of pool validation
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		pool.promoteExecutables(nil)
+		pool.promoteExecutables(nil, nil)
 	}
 }
 
@@ -2670,3 +2673,263 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that private transactions are hidden until they expire, after which they
+// are either dropped from the pool or published.
+func TestPrivateTransactions(t *testing.T) {
+	t.Parallel()
+
+	// The test chain has no base fee, use pre-London rules for the resets
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := newTestBlockChain(params.NonActivatedConfig, 10000000, statedb, new(event.Feed))
+
+	legacy := New(testTxPoolConfig, blockchain)
+	pool, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{legacy})
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	dropKey, _ := crypto.GenerateKey()
+	publishKey, _ := crypto.GenerateKey()
+	testAddBalance(legacy, crypto.PubkeyToAddress(dropKey.PublicKey), big.NewInt(1000000))
+	testAddBalance(legacy, crypto.PubkeyToAddress(publishKey.PublicKey), big.NewInt(1000000))
+
+	var (
+		dropped   = transaction(0, 100000, dropKey)
+		published = transaction(0, 100000, publishKey)
+		blob      = types.NewTx(&types.BlobTx{})
+	)
+	// Transactions expiring at the current head are expired on the next reset
+	if errs := pool.AddPrivate([]*types.Transaction{dropped}, 0, false); errs[0] != nil {
+		t.Fatalf("failed to add private transaction: %v", errs[0])
+	}
+	if errs := pool.AddPrivate([]*types.Transaction{published, blob}, 0, true); errs[0] != nil || !errors.Is(errs[1], txpool.ErrPrivateBlobTx) {
+		t.Fatalf("private transaction errors mismatch: %v", errs)
+	}
+	if errs := pool.AddPrivate([]*types.Transaction{dropped}, 0, false); !errors.Is(errs[0], txpool.ErrAlreadyKnown) {
+		t.Fatalf("duplicate private transaction error mismatch: have %v, want %v", errs[0], txpool.ErrAlreadyKnown)
+	}
+	for _, tx := range []*types.Transaction{dropped, published} {
+		if !pool.Has(tx.Hash()) || !pool.IsPrivate(tx.Hash()) {
+			t.Fatalf("transaction %x not pooled privately", tx.Hash())
+		}
+	}
+	if err := pool.Sync(); err != nil {
+		t.Fatalf("failed to sync pool: %v", err)
+	}
+	if pool.Has(dropped.Hash()) || pool.IsPrivate(dropped.Hash()) {
+		t.Errorf("expired private transaction not dropped")
+	}
+	if !pool.Has(published.Hash()) || pool.IsPrivate(published.Hash()) {
+		t.Errorf("expired private transaction not published")
+	}
+}
+
+// Tests that the pooled transactions are saved into the snapshot on shutdown,
+// and reloaded and revalidated on startup.
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	snapshot := filepath.Join(t.TempDir(), "snapshot.rlp")
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	config := testTxPoolConfig
+	config.Snapshot = snapshot
+
+	pool := New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key1.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(key2.PublicKey), big.NewInt(1000000000))
+
+	// Add two pending and a queued transactions of the first account, and a
+	// pending one of the second
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), key1),
+		pricedTransaction(1, 100000, big.NewInt(1), key1),
+		pricedTransaction(3, 100000, big.NewInt(1), key1),
+		pricedTransaction(0, 100000, big.NewInt(1), key2),
+	}
+	for i, err := range pool.addRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 3/1", pending, queued)
+	}
+	// Restart the pool after the first transaction of the second account is
+	// included, it must not be reloaded
+	pool.Close()
+	statedb.SetNonce(crypto.PubkeyToAddress(key2.PublicKey), 1)
+	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+
+	pool = New(config, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	defer pool.Close()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("reloaded pool stats mismatch: have %d/%d, want 2/1", pending, queued)
+	}
+	for _, tx := range txs[:3] {
+		if !pool.Has(tx.Hash()) {
+			t.Errorf("transaction %x not reloaded", tx.Hash())
+		}
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// The snapshot is consumed when loaded
+	if _, err := os.Stat(snapshot); !os.IsNotExist(err) {
+		t.Errorf("snapshot not removed after loading: %v", err)
+	}
+}
+
+// Tests that the lifecycle transitions of the pooled transactions are reported
+// to the event subscribers.
+func TestTxEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	events := make(chan []txpool.TxEvent, 32)
+	sub := pool.SubscribeTxEvents(events)
+	defer sub.Unsubscribe()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	var (
+		tx0      = pricedTransaction(0, 100000, big.NewInt(1), key)
+		tx1      = pricedTransaction(1, 100000, big.NewInt(1), key)
+		replaced = pricedTransaction(1, 100000, big.NewInt(2), key)
+	)
+	// Gapped transaction is queued, promoted when the gap is filled
+	if err := pool.addRemoteSync(tx1); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(tx0); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Replacement of a pending transaction is promoted right away
+	if err := pool.addRemoteSync(replaced); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	// Transactions with a used up nonce are included or dropped
+	testSetNonce(pool, crypto.PubkeyToAddress(key.PublicKey), 1)
+	pool.mu.Lock()
+	pool.demoteUnexecutables(map[common.Hash]uint64{tx0.Hash(): 1})
+	pool.mu.Unlock()
+	pool.flushTxEvents()
+
+	// Transactions below the minimum tip are evicted
+	pool.SetGasTip(big.NewInt(3))
+
+	want := []txpool.TxEvent{
+		{Hash: tx1.Hash(), Kind: txpool.TxQueued},
+		{Hash: tx0.Hash(), Kind: txpool.TxQueued},
+		{Hash: tx0.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx1.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx1.Hash(), Kind: txpool.TxReplaced, ReplacedBy: replaced.Hash()},
+		{Hash: replaced.Hash(), Kind: txpool.TxPromoted},
+		{Hash: tx0.Hash(), Kind: txpool.TxIncluded, Block: 1},
+		{Hash: replaced.Hash(), Kind: txpool.TxEvicted, Reason: txpool.TxReasonUnderpriced},
+	}
+	var have []txpool.TxEvent
+	for len(have) < len(want) {
+		select {
+		case evs := <-events:
+			have = append(have, evs...)
+		case <-time.After(time.Second):
+			t.Fatalf("event timeout: have %d, want %d", len(have), len(want))
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
+// lookupBlockChain is a test chain indexing the inclusion of the transactions.
+type lookupBlockChain struct {
+	*testBlockChain
+	included map[common.Hash]uint64
+}
+
+func (bc *lookupBlockChain) GetTransactionLookup(hash common.Hash) (*rawdb.LegacyTxLookupEntry, *types.Transaction, error) {
+	if number, ok := bc.included[hash]; ok {
+		return &rawdb.LegacyTxLookupEntry{BlockIndex: number}, nil, nil
+	}
+	return nil, nil, nil
+}
+
+// Tests that the pending and queued transactions removed due to their nonce are
+// reported as included if the chain contains them, even if the pool didn't walk
+// the chain segment including them.
+func TestTxEventsInclusionLookup(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	var (
+		tx0 = transaction(0, 100000, key)
+		tx1 = transaction(1, 100000, key)
+		tx3 = transaction(3, 100000, key)
+		tx4 = transaction(4, 100000, key)
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	blockchain := &lookupBlockChain{
+		testBlockChain: newTestBlockChain(params.TestChainConfig, 10000000, statedb, new(event.Feed)),
+		included:       map[common.Hash]uint64{tx0.Hash(): 1, tx3.Hash(): 2},
+	}
+	pool := New(testTxPoolConfig, blockchain)
+	if err := pool.Init(testTxPoolConfig.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	events := make(chan []txpool.TxEvent, 32)
+	sub := pool.SubscribeTxEvents(events)
+	defer sub.Unsubscribe()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, from, big.NewInt(1000000000))
+	for _, err := range pool.addRemotesSync([]*types.Transaction{tx0, tx1, tx3, tx4}) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// All nonces are used up by the chain, the inclusions are unknown to the pool
+	testSetNonce(pool, from, 5)
+	pool.mu.Lock()
+	pool.promoteExecutables([]common.Address{from}, nil)
+	pool.demoteUnexecutables(nil)
+	pool.mu.Unlock()
+	pool.flushTxEvents()
+
+	want := []txpool.TxEvent{
+		{Hash: tx3.Hash(), Kind: txpool.TxIncluded, Block: 2},
+		{Hash: tx4.Hash(), Kind: txpool.TxDropped, Reason: txpool.TxReasonNonceTooLow},
+		{Hash: tx0.Hash(), Kind: txpool.TxIncluded, Block: 1},
+		{Hash: tx1.Hash(), Kind: txpool.TxDropped, Reason: txpool.TxReasonNonceTooLow},
+	}
+	var have []txpool.TxEvent
+	for len(have) < len(want) {
+		select {
+		case evs := <-events:
+			for _, ev := range evs {
+				if ev.Kind == txpool.TxIncluded || ev.Kind == txpool.TxDropped {
+					have = append(have, ev)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event timeout: have %d, want %d", len(have), len(want))
+		}
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
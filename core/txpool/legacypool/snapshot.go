@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// snapshotBatch is the number of transactions added to the pool at once when
+// loading the snapshot.
+const snapshotBatch = 1024
+
+// writeSnapshot dumps the given transactions into the snapshot file at path,
+// replacing any previous one.
+func writeSnapshot(path string, all map[common.Address]types.Transactions) error {
+	output, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	var (
+		buf   = bufio.NewWriter(output)
+		count int
+	)
+	for _, txs := range all {
+		for _, tx := range txs {
+			if err = rlp.Encode(buf, tx); err != nil {
+				output.Close()
+				return err
+			}
+		}
+		count += len(txs)
+	}
+	if err = buf.Flush(); err != nil {
+		output.Close()
+		return err
+	}
+	if err = output.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(path+".new", path); err != nil {
+		return err
+	}
+	log.Info("Wrote transaction pool snapshot", "transactions", count, "accounts", len(all))
+	return nil
+}
+
+// loadSnapshot parses the snapshot file at path, adding its transactions to the
+// pool through the given method, which revalidates them. The snapshot is deleted
+// once loaded, so that its stale content is not loaded again after a crash.
+func loadSnapshot(path string, add func([]*types.Transaction) []error) error {
+	input, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	defer input.Close()
+
+	var (
+		stream         = rlp.NewStream(bufio.NewReader(input), 0)
+		batch          types.Transactions
+		total, dropped int
+		failure        error
+	)
+	loadBatch := func() {
+		for _, err := range add(batch) {
+			if err != nil {
+				log.Debug("Failed to add snapshot transaction", "err", err)
+				dropped++
+			}
+		}
+		batch = batch[:0]
+	}
+	for {
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		total++
+		if batch = append(batch, tx); len(batch) >= snapshotBatch {
+			loadBatch()
+		}
+	}
+	if len(batch) > 0 {
+		loadBatch()
+	}
+	log.Info("Loaded transaction pool snapshot", "transactions", total, "dropped", dropped)
+	return failure
+}
+
+// snapshotContent retrieves the transactions to be saved in the snapshot: all of
+// them, except for the local ones if they are already journaled.
+func (pool *LegacyPool) snapshotContent() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		txs[addr] = append(txs[addr], list.Flatten()...)
+	}
+	for addr, list := range pool.queue {
+		txs[addr] = append(txs[addr], list.Flatten()...)
+	}
+	if pool.journal != nil {
+		for addr := range pool.locals.accounts {
+			delete(txs, addr)
+		}
+	}
+	return txs
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxPrivateTxs is the maximum number of private transactions tracked by the pool.
+const maxPrivateTxs = 4096
+
+var (
+	// ErrPrivateBlobTx is returned if a blob transaction is submitted privately.
+	ErrPrivateBlobTx = errors.New("blob transactions cannot be private")
+
+	// ErrPrivateLimitExceeded is returned if too many private transactions are
+	// tracked by the pool.
+	ErrPrivateLimitExceeded = errors.New("private transaction limit exceeded")
+)
+
+// privateTx is a transaction only available to the local block builder.
+type privateTx struct {
+	tx       *types.Transaction
+	expiry   uint64 // Block number at which the transaction stops being private
+	fallback bool   // Whether to publish the transaction on expiry instead of dropping it
+}
+
+// remover is implemented by the subpools able to drop individual transactions.
+type remover interface {
+	// Remove drops the transaction from the pool, returning whether it was found.
+	Remove(hash common.Hash) bool
+}
+
+// AddPrivate enqueues a batch of transactions into the pool which are only
+// available to the local block builder: they are not announced to the network.
+// If a transaction is still pending after lifetime blocks, it's published if
+// fallback is set, or dropped otherwise.
+//
+// Private transactions are added as remote ones, so that they are not persisted
+// in the local transaction journal.
+func (p *TxPool) AddPrivate(txs []*types.Transaction, lifetime uint64, fallback bool) []error {
+	var (
+		errs   = make([]error, len(txs))
+		added  []*types.Transaction
+		expiry = p.chain.CurrentBlock().Number.Uint64() + lifetime
+	)
+	// Mark the transactions private before adding them, so that they are never
+	// visible as public ones.
+	p.privateLock.Lock()
+	for i, tx := range txs {
+		switch {
+		case tx.Type() == types.BlobTxType:
+			errs[i] = ErrPrivateBlobTx
+		case len(p.private) >= maxPrivateTxs:
+			errs[i] = ErrPrivateLimitExceeded
+		case p.private[tx.Hash()] != nil || p.Has(tx.Hash()):
+			errs[i] = ErrAlreadyKnown
+		default:
+			p.private[tx.Hash()] = &privateTx{tx: tx, expiry: expiry, fallback: fallback}
+			added = append(added, tx)
+		}
+	}
+	p.privateLock.Unlock()
+
+	addErrs := p.Add(added, false, false)
+
+	p.privateLock.Lock()
+	defer p.privateLock.Unlock()
+	for i, j := 0, 0; i < len(txs); i++ {
+		if errs[i] != nil {
+			continue
+		}
+		if errs[i] = addErrs[j]; errs[i] != nil {
+			delete(p.private, txs[i].Hash())
+		}
+		j++
+	}
+	return errs
+}
+
+// IsPrivate returns whether the transaction with the given hash is private and
+// must not be announced to the network.
+func (p *TxPool) IsPrivate(hash common.Hash) bool {
+	p.privateLock.RLock()
+	defer p.privateLock.RUnlock()
+
+	return p.private[hash] != nil
+}
+
+// expirePrivate forgets the private transactions no longer in the pool, and
+// publishes or drops the ones expiring at the given head.
+func (p *TxPool) expirePrivate(head *types.Header) {
+	var published []*types.Transaction
+
+	p.privateLock.Lock()
+	for hash, ptx := range p.private {
+		if !p.Has(hash) {
+			delete(p.private, hash)
+			continue
+		}
+		if head.Number.Uint64() < ptx.expiry {
+			continue
+		}
+		delete(p.private, hash)
+		if ptx.fallback {
+			published = append(published, ptx.tx)
+			continue
+		}
+		p.remove(hash)
+		log.Debug("Dropped expired private transaction", "hash", hash)
+	}
+	p.privateLock.Unlock()
+
+	if len(published) > 0 {
+		log.Debug("Published expired private transactions", "count", len(published))
+		p.privateFeed.Send(core.NewTxsEvent{Txs: published})
+	}
+}
+
+// dropPrivate removes all private transactions from the pool, so that they are
+// not persisted by the subpools on shutdown.
+func (p *TxPool) dropPrivate() {
+	p.privateLock.Lock()
+	defer p.privateLock.Unlock()
+
+	for hash := range p.private {
+		p.remove(hash)
+		delete(p.private, hash)
+	}
+}
+
+// remove drops the transaction from the subpool holding it.
+func (p *TxPool) remove(hash common.Hash) {
+	for _, subpool := range p.subpools {
+		if r, ok := subpool.(remover); ok && r.Remove(hash) {
+			return
+		}
+	}
+}
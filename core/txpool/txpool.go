@@ -66,11 +66,16 @@ type BlockChain interface {
 // They exit the pool when they are included in the blockchain or evicted due to
 // resource constraints.
 type TxPool struct {
-	subpools []SubPool // List of subpools for specialized transaction handling
+	subpools []SubPool  // List of subpools for specialized transaction handling
+	chain    BlockChain // Chain to track the head of
 
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
+	private     map[common.Hash]*privateTx // Transactions only available to the local block builder
+	privateLock sync.RWMutex               // Lock protecting the private transactions
+	privateFeed event.Feed                 // Feed of the private transactions published on expiry
+
 	subs event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
 	term chan struct{}           // Termination channel to detect a closed pool
@@ -88,7 +93,9 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 
 	pool := &TxPool{
 		subpools:     subpools,
+		chain:        chain,
 		reservations: make(map[common.Address]SubPool),
+		private:      make(map[common.Hash]*privateTx),
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
@@ -161,6 +168,9 @@ func (p *TxPool) Close() error {
 	if err := <-errc; err != nil {
 		errs = append(errs, err)
 	}
+	// Drop the private transactions before the subpools persist their content
+	p.dropPrivate()
+
 	// Terminate each subpool
 	for _, subpool := range p.subpools {
 		if err := subpool.Close(); err != nil {
@@ -224,6 +234,7 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 					for _, subpool := range p.subpools {
 						subpool.Reset(oldHead, newHead)
 					}
+					p.expirePrivate(newHead)
 					resetDone <- newHead
 				}(oldHead, newHead)
 
@@ -385,11 +396,26 @@ func (p *TxPool) Pending(filter PendingFilter) map[common.Address][]*LazyTransac
 
 // SubscribeTransactions registers a subscription for new transaction events,
 // supporting feeding only newly seen or also resurrected transactions.
+//
+// Private transactions are fed when they are added, and again when they are
+// published on expiry. The subscribers exposing the transactions outside of the
+// node must skip them while IsPrivate reports them as private.
 func (p *TxPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
-	subs := make([]event.Subscription, len(p.subpools))
+	subs := make([]event.Subscription, len(p.subpools), len(p.subpools)+1)
 	for i, subpool := range p.subpools {
 		subs[i] = subpool.SubscribeTransactions(ch, reorgs)
 	}
+	subs = append(subs, p.privateFeed.Subscribe(ch))
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
+// SubscribeTxEvents registers a subscription for the lifecycle transitions of
+// the transactions in all subpools.
+func (p *TxPool) SubscribeTxEvents(ch chan<- []TxEvent) event.Subscription {
+	subs := make([]event.Subscription, len(p.subpools))
+	for i, subpool := range p.subpools {
+		subs[i] = subpool.SubscribeTxEvents(ch)
+	}
 	return p.subs.Track(event.JoinSubscriptions(subs...))
 }
 
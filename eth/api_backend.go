@@ -205,7 +205,12 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	}
 	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
 	if err != nil {
-		return nil, nil, err
+		// The state is no longer available in the live trie database,
+		// try to resolve it from the state histories in path mode.
+		var herr error
+		if stateDb, herr = b.eth.BlockChain().HistoricState(header.Root); herr != nil {
+			return nil, nil, err
+		}
 	}
 	return stateDb, header, nil
 }
@@ -227,7 +232,12 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		}
 		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
 		if err != nil {
-			return nil, nil, err
+			// The state is no longer available in the live trie database,
+			// try to resolve it from the state histories in path mode.
+			var herr error
+			if stateDb, herr = b.eth.BlockChain().HistoricState(header.Root); herr != nil {
+				return nil, nil, err
+			}
 		}
 		return stateDb, header, nil
 	}
@@ -345,6 +355,14 @@ func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.txPool.SubscribeTransactions(ch, true)
 }
 
+func (b *EthAPIBackend) SubscribeTxPoolEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return b.eth.txPool.SubscribeTxEvents(ch)
+}
+
+func (b *EthAPIBackend) IsPrivateTx(hash common.Hash) bool {
+	return b.eth.txPool.IsPrivate(hash)
+}
+
 func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
 	prog := b.eth.Downloader().Progress()
 	if txProg, err := b.eth.blockchain.TxIndexProgress(); err == nil {
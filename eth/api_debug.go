@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 )
 
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
@@ -420,6 +421,321 @@ func (api *DebugAPI) GetAccessibleState(from, to rpc.BlockNumber) (uint64, error
 	return 0, errors.New("no state found")
 }
 
+// HistoryMaxResults is the maximum number of state changes to be returned per
+// call of the state history inspection.
+const HistoryMaxResults = 1024
+
+// HistoricAccount represents the content of an account at a specific block.
+type HistoricAccount struct {
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Balance     *hexutil.Big   `json:"balance"`
+	CodeHash    common.Hash    `json:"codeHash"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+}
+
+// AccountChange represents a mutation of an account within a block. The previous
+// or current value is nil if the account doesn't exist before or after the block.
+type AccountChange struct {
+	Block    hexutil.Uint64   `json:"block"`
+	Previous *HistoricAccount `json:"previous"`
+	Current  *HistoricAccount `json:"current"`
+}
+
+// AccountHistoryResult is the result of a debug_getAccountHistory API call.
+type AccountHistoryResult struct {
+	Changes []AccountChange `json:"changes"`
+	Next    *hexutil.Uint64 `json:"next"` // nil if Changes includes the last change in the range.
+	To      hexutil.Uint64  `json:"to"`   // the last block covered, clamped to the persisted state histories.
+}
+
+// StorageChange represents a mutation of a storage slot within a block.
+type StorageChange struct {
+	Block    hexutil.Uint64 `json:"block"`
+	Previous common.Hash    `json:"previous"`
+	Current  common.Hash    `json:"current"`
+}
+
+// StorageHistoryResult is the result of a debug_getStorageHistory API call.
+type StorageHistoryResult struct {
+	Changes []StorageChange `json:"changes"`
+	Next    *hexutil.Uint64 `json:"next"` // nil if Changes includes the last change in the range.
+	To      hexutil.Uint64  `json:"to"`   // the last block covered, clamped to the persisted state histories.
+}
+
+// GetAccountHistory returns the list of changes of the specified account within
+// the given block range (both included). At most maxResults changes are returned,
+// the returned next block can be used as the start of the subsequent query.
+//
+// The changes are resolved from the state histories, it's only supported in the
+// path-based scheme. The end of the range is clamped to the most recent state
+// history persisted, the last block covered is returned as well.
+func (api *DebugAPI) GetAccountHistory(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber, maxResults int) (AccountHistoryResult, error) {
+	from, to, start, end, err := api.historyRange(ctx, fromBlock, toBlock)
+	if err != nil {
+		return AccountHistoryResult{}, err
+	}
+	if from > to || start > end {
+		// state is not mutated in range
+		return AccountHistoryResult{Changes: []AccountChange{}, To: hexutil.Uint64(to)}, nil
+	}
+	stats, err := api.eth.blockchain.TrieDB().AccountHistory(address, start, end)
+	if err != nil {
+		return AccountHistoryResult{}, err
+	}
+	result, err := accountHistory(stats, from, to, maxResults, func(number uint64) (*HistoricAccount, error) {
+		statedb, _, err := api.eth.APIBackend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		return historicAccountAt(statedb, address), nil
+	})
+	if err != nil {
+		return AccountHistoryResult{}, err
+	}
+	result.To = hexutil.Uint64(to)
+	return result, nil
+}
+
+// GetStorageHistory returns the list of changes of the specified storage slot
+// within the given block range (both included). At most maxResults changes are
+// returned, the returned next block can be used as the start of the subsequent
+// query.
+//
+// The changes are resolved from the state histories, it's only supported in the
+// path-based scheme. The end of the range is clamped to the most recent state
+// history persisted, the last block covered is returned as well.
+func (api *DebugAPI) GetStorageHistory(ctx context.Context, address common.Address, key common.Hash, fromBlock, toBlock rpc.BlockNumber, maxResults int) (StorageHistoryResult, error) {
+	from, to, start, end, err := api.historyRange(ctx, fromBlock, toBlock)
+	if err != nil {
+		return StorageHistoryResult{}, err
+	}
+	if from > to || start > end {
+		// state is not mutated in range
+		return StorageHistoryResult{Changes: []StorageChange{}, To: hexutil.Uint64(to)}, nil
+	}
+	// The hash of storage slot key is utilized in the history
+	// rather than the raw slot key, make the conversion.
+	stats, err := api.eth.blockchain.TrieDB().StorageHistory(address, crypto.Keccak256Hash(key.Bytes()), start, end)
+	if err != nil {
+		return StorageHistoryResult{}, err
+	}
+	result, err := storageHistory(stats, from, to, maxResults, func(number uint64) (common.Hash, error) {
+		statedb, _, err := api.eth.APIBackend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return statedb.GetState(address, key), nil
+	})
+	if err != nil {
+		return StorageHistoryResult{}, err
+	}
+	result.To = hexutil.Uint64(to)
+	return result, nil
+}
+
+// historyRange resolves the given block range into the block numbers and the
+// range of state history ids to query. The returned start id is zero if it is
+// not resolvable, leaving the lower bound to the available state histories.
+//
+// The blocks whose state is still held in memory have no state history yet, the
+// end of the range is clamped to the most recent persisted history in that case.
+// The returned end block might therefore be lower than requested, or even lower
+// than the start block if no part of the range is covered.
+func (api *DebugAPI) historyRange(ctx context.Context, fromBlock, toBlock rpc.BlockNumber) (uint64, uint64, uint64, uint64, error) {
+	if api.eth.blockchain.TrieDB().Scheme() != rawdb.PathScheme {
+		return 0, 0, 0, 0, errors.New("state history is only available in path-based scheme")
+	}
+	fromHeader, err := api.eth.APIBackend.HeaderByNumber(ctx, fromBlock)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if fromHeader == nil {
+		return 0, 0, 0, 0, fmt.Errorf("block %v not found", fromBlock)
+	}
+	toHeader, err := api.eth.APIBackend.HeaderByNumber(ctx, toBlock)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if toHeader == nil {
+		return 0, 0, 0, 0, fmt.Errorf("block %v not found", toBlock)
+	}
+	from, to := fromHeader.Number.Uint64(), toHeader.Number.Uint64()
+	if from > to {
+		return 0, 0, 0, 0, fmt.Errorf("start block height (%d) must not be greater than end block height (%d)", from, to)
+	}
+	_, last, err := api.eth.blockchain.TrieDB().HistoryRange()
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("state history is not available: %v", err)
+	}
+	if to > last {
+		to = last
+		if toHeader = api.eth.blockchain.GetHeaderByNumber(to); toHeader == nil {
+			return 0, 0, 0, 0, fmt.Errorf("block %d not found", to)
+		}
+		if from > to {
+			return from, to, 0, 0, nil
+		}
+	}
+	// The state history with id N contains the state mutations from state N-1
+	// to state N, so the first history to query is the one after the parent
+	// state of the start block.
+	var (
+		db         = api.eth.ChainDb()
+		start, end uint64
+	)
+	if from > 0 {
+		if parent := api.eth.blockchain.GetHeaderByNumber(from - 1); parent != nil {
+			if id := rawdb.ReadStateID(db, parent.Root); id != nil {
+				start = *id + 1
+			}
+		}
+	}
+	id := rawdb.ReadStateID(db, toHeader.Root)
+	if id == nil {
+		return 0, 0, 0, 0, fmt.Errorf("state history of block %d is not available yet", to)
+	}
+	end = *id
+	return from, to, start, end, nil
+}
+
+// filterHistory returns the mutations within the block range [from, to].
+func filterHistory(stats *pathdb.HistoryStats, from, to uint64) ([]uint64, [][]byte) {
+	var (
+		blocks  []uint64
+		origins [][]byte
+	)
+	for i, number := range stats.Blocks {
+		if number < from || number > to {
+			continue
+		}
+		blocks = append(blocks, number)
+		origins = append(origins, stats.Origins[i])
+	}
+	return blocks, origins
+}
+
+// accountHistory assembles the account changes from the history statistics.
+// The value after the last mutation is resolved by the given callback, as it's
+// not recorded in the state histories within the range.
+func accountHistory(stats *pathdb.HistoryStats, from, to uint64, maxResults int, current func(number uint64) (*HistoricAccount, error)) (AccountHistoryResult, error) {
+	if maxResults > HistoryMaxResults || maxResults <= 0 {
+		maxResults = HistoryMaxResults
+	}
+	blocks, origins := filterHistory(stats, from, to)
+
+	result := AccountHistoryResult{Changes: []AccountChange{}}
+	for i := 0; i < len(blocks) && i < maxResults; i++ {
+		prev, err := decodeHistoricAccount(origins[i])
+		if err != nil {
+			return AccountHistoryResult{}, err
+		}
+		var cur *HistoricAccount
+		if i+1 < len(blocks) {
+			cur, err = decodeHistoricAccount(origins[i+1])
+		} else {
+			cur, err = current(blocks[i])
+		}
+		if err != nil {
+			return AccountHistoryResult{}, err
+		}
+		result.Changes = append(result.Changes, AccountChange{
+			Block:    hexutil.Uint64(blocks[i]),
+			Previous: prev,
+			Current:  cur,
+		})
+	}
+	// Add the 'next block' so clients can continue querying.
+	if len(blocks) > maxResults {
+		next := hexutil.Uint64(blocks[maxResults])
+		result.Next = &next
+	}
+	return result, nil
+}
+
+// storageHistory assembles the storage changes from the history statistics.
+// The value after the last mutation is resolved by the given callback, as it's
+// not recorded in the state histories within the range.
+func storageHistory(stats *pathdb.HistoryStats, from, to uint64, maxResults int, current func(number uint64) (common.Hash, error)) (StorageHistoryResult, error) {
+	if maxResults > HistoryMaxResults || maxResults <= 0 {
+		maxResults = HistoryMaxResults
+	}
+	blocks, origins := filterHistory(stats, from, to)
+
+	result := StorageHistoryResult{Changes: []StorageChange{}}
+	for i := 0; i < len(blocks) && i < maxResults; i++ {
+		prev, err := decodeHistoricSlot(origins[i])
+		if err != nil {
+			return StorageHistoryResult{}, err
+		}
+		var cur common.Hash
+		if i+1 < len(blocks) {
+			cur, err = decodeHistoricSlot(origins[i+1])
+		} else {
+			cur, err = current(blocks[i])
+		}
+		if err != nil {
+			return StorageHistoryResult{}, err
+		}
+		result.Changes = append(result.Changes, StorageChange{
+			Block:    hexutil.Uint64(blocks[i]),
+			Previous: prev,
+			Current:  cur,
+		})
+	}
+	// Add the 'next block' so clients can continue querying.
+	if len(blocks) > maxResults {
+		next := hexutil.Uint64(blocks[maxResults])
+		result.Next = &next
+	}
+	return result, nil
+}
+
+// decodeHistoricAccount decodes the account recorded in the state history,
+// which is encoded in the slim format. Nil is returned if the account was not
+// existent.
+func decodeHistoricAccount(blob []byte) (*HistoricAccount, error) {
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	account, err := types.FullAccount(blob)
+	if err != nil {
+		return nil, err
+	}
+	return &HistoricAccount{
+		Nonce:       hexutil.Uint64(account.Nonce),
+		Balance:     (*hexutil.Big)(account.Balance.ToBig()),
+		CodeHash:    common.BytesToHash(account.CodeHash),
+		StorageRoot: account.Root,
+	}, nil
+}
+
+// decodeHistoricSlot decodes the storage slot recorded in the state history,
+// which is RLP-encoded with the leading zeros trimmed.
+func decodeHistoricSlot(blob []byte) (common.Hash, error) {
+	if len(blob) == 0 {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
+
+// historicAccountAt returns the content of the specified account in the given
+// state, or nil if it's not existent.
+func historicAccountAt(statedb *state.StateDB, address common.Address) *HistoricAccount {
+	if !statedb.Exist(address) {
+		return nil
+	}
+	return &HistoricAccount{
+		Nonce:       hexutil.Uint64(statedb.GetNonce(address)),
+		Balance:     (*hexutil.Big)(statedb.GetBalance(address).ToBig()),
+		CodeHash:    statedb.GetCodeHash(address),
+		StorageRoot: statedb.GetStorageRoot(address),
+	}
+}
+
 // SetTrieFlushInterval configures how often in-memory tries are persisted
 // to disk. The value is in terms of block processing time, not wall clock.
 // If the value is shorter than the block generation time, or even 0 or negative,
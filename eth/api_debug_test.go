@@ -26,12 +26,14 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 	"github.com/holiman/uint256"
 )
 
@@ -157,6 +159,148 @@ func TestEmptyAccountRange(t *testing.T) {
 	}
 }
 
+func TestStateHistory(t *testing.T) {
+	t.Parallel()
+
+	disk, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	var (
+		tdb   = triedb.NewDatabase(disk, &triedb.Config{PathDB: pathdb.Defaults})
+		db    = state.NewDatabase(tdb, nil)
+		hdb   = state.NewHistoricDatabase(tdb)
+		addr  = common.Address{0x01}
+		key   = common.Hash{0x02}
+		roots []common.Hash
+	)
+	defer tdb.Close()
+
+	// Mutate the account balance in block 1, 2, 4 and the storage slot in block
+	// 1, 3, the account is left unchanged in block 5 besides an unrelated one.
+	root := types.EmptyRootHash
+	for number := uint64(1); number <= 5; number++ {
+		sdb, _ := state.New(root, db)
+		if number != 3 && number != 5 {
+			sdb.SetBalance(addr, uint256.NewInt(number), tracing.BalanceChangeUnspecified)
+		}
+		if number == 1 || number == 3 {
+			sdb.SetState(addr, key, common.Hash{byte(number)})
+		}
+		sdb.SetNonce(common.Address{byte(number + 0x10)}, 1)
+
+		root, err = sdb.Commit(number, false)
+		if err != nil {
+			t.Fatalf("Failed to commit state: %v", err)
+		}
+		if err := tdb.Commit(root, false); err != nil {
+			t.Fatalf("Failed to flush state: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	stateAt := func(number uint64) *state.StateDB {
+		if sdb, err := state.New(roots[number-1], db); err == nil {
+			return sdb
+		}
+		sdb, err := state.New(roots[number-1], hdb)
+		if err != nil {
+			t.Fatalf("Failed to open state %d: %v", number, err)
+		}
+		return sdb
+	}
+	account := func(number uint64) *HistoricAccount {
+		return historicAccountAt(stateAt(number), addr)
+	}
+	next := func(n uint64) *hexutil.Uint64 {
+		number := hexutil.Uint64(n)
+		return &number
+	}
+	accountStats, err := tdb.AccountHistory(addr, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to inspect account history: %v", err)
+	}
+	accountTests := []struct {
+		from, to uint64
+		limit    int
+		want     AccountHistoryResult
+	}{
+		{
+			from: 1, to: 5, limit: 0,
+			want: AccountHistoryResult{Changes: []AccountChange{
+				{Block: 1, Previous: nil, Current: account(1)},
+				{Block: 2, Previous: account(1), Current: account(2)},
+				{Block: 3, Previous: account(2), Current: account(3)}, // storage root change
+				{Block: 4, Previous: account(3), Current: account(4)},
+			}},
+		},
+		{
+			from: 1, to: 5, limit: 2,
+			want: AccountHistoryResult{Changes: []AccountChange{
+				{Block: 1, Previous: nil, Current: account(1)},
+				{Block: 2, Previous: account(1), Current: account(2)},
+			}, Next: next(3)},
+		},
+		{
+			from: 2, to: 3, limit: 10,
+			want: AccountHistoryResult{Changes: []AccountChange{
+				{Block: 2, Previous: account(1), Current: account(2)},
+				{Block: 3, Previous: account(2), Current: account(3)},
+			}},
+		},
+		{
+			from: 5, to: 5, limit: 10,
+			want: AccountHistoryResult{Changes: []AccountChange{}},
+		},
+	}
+	for _, test := range accountTests {
+		result, err := accountHistory(accountStats, test.from, test.to, test.limit, func(number uint64) (*HistoricAccount, error) {
+			return historicAccountAt(stateAt(number), addr), nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to assemble account history: %v", err)
+		}
+		if !reflect.DeepEqual(result, test.want) {
+			t.Fatalf("wrong account history for range [%d, %d], limit %d:\ngot %s\nwant %s",
+				test.from, test.to, test.limit, dumper.Sdump(result), dumper.Sdump(test.want))
+		}
+	}
+	storageStats, err := tdb.StorageHistory(addr, crypto.Keccak256Hash(key.Bytes()), 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to inspect storage history: %v", err)
+	}
+	storageTests := []struct {
+		from, to uint64
+		limit    int
+		want     StorageHistoryResult
+	}{
+		{
+			from: 1, to: 5, limit: 0,
+			want: StorageHistoryResult{Changes: []StorageChange{
+				{Block: 1, Previous: common.Hash{}, Current: common.Hash{0x01}},
+				{Block: 3, Previous: common.Hash{0x01}, Current: common.Hash{0x03}},
+			}},
+		},
+		{
+			from: 1, to: 5, limit: 1,
+			want: StorageHistoryResult{Changes: []StorageChange{
+				{Block: 1, Previous: common.Hash{}, Current: common.Hash{0x01}},
+			}, Next: next(3)},
+		},
+	}
+	for _, test := range storageTests {
+		result, err := storageHistory(storageStats, test.from, test.to, test.limit, func(number uint64) (common.Hash, error) {
+			return stateAt(number).GetState(addr, key), nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to assemble storage history: %v", err)
+		}
+		if !reflect.DeepEqual(result, test.want) {
+			t.Fatalf("wrong storage history for range [%d, %d], limit %d:\ngot %s\nwant %s",
+				test.from, test.to, test.limit, dumper.Sdump(result), dumper.Sdump(test.want))
+		}
+	}
+}
+
 func TestStorageRangeAt(t *testing.T) {
 	t.Parallel()
 
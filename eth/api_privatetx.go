@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// defaultPrivateTxLifetime is the number of blocks a private transaction stays
+// private if not specified otherwise.
+const defaultPrivateTxLifetime = 25
+
+// PrivateTxAPI provides an API to submit transactions which are not propagated
+// to the network.
+type PrivateTxAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateTxAPI creates a new PrivateTxAPI instance.
+func NewPrivateTxAPI(e *Ethereum) *PrivateTxAPI {
+	return &PrivateTxAPI{e}
+}
+
+// PrivateTxArgs represents the options of eth_sendPrivateRawTransaction.
+type PrivateTxArgs struct {
+	MaxBlocks *hexutil.Uint64 `json:"maxBlocks"` // Number of blocks the transaction stays private
+	Fallback  bool            `json:"fallback"`  // Whether to publish the transaction after, instead of dropping it
+}
+
+// SendPrivateRawTransaction adds the signed transaction to the pool without
+// announcing it to the network, so that only the local block builder can include
+// it. If it's not included within maxBlocks blocks, it's published if fallback is
+// set, or dropped otherwise. While private, the transaction is not returned by
+// the transaction lookups and the txpool content of the RPC APIs.
+func (api *PrivateTxAPI) SendPrivateRawTransaction(input hexutil.Bytes, args *PrivateTxArgs) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if !api.e.APIBackend.UnprotectedAllowed() && !tx.Protected() {
+		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
+		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	var (
+		lifetime = uint64(defaultPrivateTxLifetime)
+		fallback bool
+	)
+	if args != nil {
+		if args.MaxBlocks != nil {
+			lifetime = uint64(*args.MaxBlocks)
+		}
+		fallback = args.Fallback
+	}
+	if err := api.e.txPool.AddPrivate([]*types.Transaction{tx}, lifetime, fallback)[0]; err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Submitted private transaction", "hash", tx.Hash(), "lifetime", lifetime, "fallback", fallback)
+	return tx.Hash(), nil
+}
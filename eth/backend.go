@@ -75,7 +75,8 @@ type Ethereum struct {
 	discmix *enode.FairMix
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb   ethdb.Database      // Block chain database
+	callIndex ethdb.KeyValueStore // Call address index maintained by the live tracer, nil if not maintained
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -203,11 +204,12 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		if config.VMTraceJsonConfig != "" {
 			traceConfig = json.RawMessage(config.VMTraceJsonConfig)
 		}
-		t, err := tracers.LiveDirectory.New(config.VMTrace, traceConfig)
+		t, index, err := tracers.LiveDirectory.NewWithIndex(config.VMTrace, traceConfig)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create tracer %s: %v", config.VMTrace, err)
 		}
 		vmConfig.Tracer = t
+		eth.callIndex = index
 	}
 	// Override the chain config with provided settings.
 	var overrides core.ChainOverrides
@@ -231,6 +233,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = stack.ResolvePath(config.TxPool.Snapshot)
+	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain)
 
 	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool, blobPool})
@@ -301,11 +306,19 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Accept the transaction bundles only if enabled for the miner
+	if s.config.Miner.Bundles {
+		apis = append(apis, rpc.API{Namespace: "eth", Service: NewBundleAPI(s)})
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
+		}, {
+			Namespace: "eth",
+			Service:   NewPrivateTxAPI(s),
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.blockchain, s.eventMux),
@@ -341,6 +354,10 @@ func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedF
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
 
+// CallAddressIndex returns the call address index maintained by the live tracer,
+// nil if the configured live tracer doesn't maintain one.
+func (s *Ethereum) CallAddressIndex() ethdb.KeyValueStore { return s.callIndex }
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -190,6 +191,62 @@ func (api *FilterAPI) NewPendingTransactions(ctx context.Context, fullTx *bool)
 	return rpcSub, nil
 }
 
+// rpcTxPoolEvent is the lifecycle transition of a pooled transaction as sent to
+// the txpoolEvents subscribers.
+type rpcTxPoolEvent struct {
+	Hash        common.Hash          `json:"hash"`
+	Kind        txpool.TxEventKind   `json:"kind"`
+	Reason      txpool.TxEventReason `json:"reason,omitempty"`
+	ReplacedBy  *common.Hash         `json:"replacedBy,omitempty"`
+	BlockNumber *hexutil.Uint64      `json:"blockNumber,omitempty"`
+}
+
+func newRPCTxPoolEvent(ev txpool.TxEvent) *rpcTxPoolEvent {
+	result := &rpcTxPoolEvent{
+		Hash:   ev.Hash,
+		Kind:   ev.Kind,
+		Reason: ev.Reason,
+	}
+	switch ev.Kind {
+	case txpool.TxReplaced:
+		result.ReplacedBy = &ev.ReplacedBy
+	case txpool.TxIncluded:
+		result.BlockNumber = (*hexutil.Uint64)(&ev.Block)
+	}
+	return result
+}
+
+// TxpoolEvents creates a subscription that is triggered each time a transaction
+// in the transaction pool is queued, promoted, replaced, evicted, dropped or
+// included in a block, allowing clients to track why a transaction left the pool.
+func (api *FilterAPI) TxpoolEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan []txpool.TxEvent, txEventChanSize)
+		eventsSub := api.events.SubscribeTxPoolEvents(events)
+		defer eventsSub.Unsubscribe()
+
+		for {
+			select {
+			case evs := <-events:
+				for _, ev := range evs {
+					notifier.Notify(rpcSub.ID, newRPCTxPoolEvent(ev))
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 func (api *FilterAPI) NewBlockFilter() rpc.ID {
@@ -251,6 +308,11 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+//
+// If fromBlock is set to a block number, the matching logs of the canonical chain
+// since that block are replayed before the new logs. The logs are delivered in
+// chain order, reorgs are reflected by the logs with the removed flag set. At most
+// 10000 blocks are replayed, larger ranges are rejected.
 func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -266,6 +328,15 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	if err != nil {
 		return nil, err
 	}
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		head := api.replayHead(crit)
+		if from := crit.FromBlock.Uint64(); from <= head && head-from >= logReplayMaxBlocks {
+			logsSub.Unsubscribe()
+			return nil, errLogReplayTooLarge
+		}
+		go api.replayLogs(notifier, rpcSub, logsSub, matchedLogs, crit, head)
+		return rpcSub, nil
+	}
 
 	go func() {
 		defer logsSub.Unsubscribe()
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -65,6 +66,8 @@ type Backend interface {
 	CurrentHeader() *types.Header
 	ChainConfig() *params.ChainConfig
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+	SubscribeTxPoolEvents(chan<- []txpool.TxEvent) event.Subscription
+	IsPrivateTx(hash common.Hash) bool
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
@@ -154,6 +157,9 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// TxPoolEventsSubscription queries for the lifecycle transitions of the
+	// transactions in the transaction pool
+	TxPoolEventsSubscription
 	// LastIndexSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -168,6 +174,9 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// txEventChanSize is the size of channel listening to transaction pool
+	// lifecycle events.
+	txEventChanSize = 128
 )
 
 type subscription struct {
@@ -178,6 +187,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	txs       chan []*types.Transaction
 	headers   chan *types.Header
+	txEvents  chan []txpool.TxEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -193,6 +203,7 @@ type EventSystem struct {
 	logsSub   event.Subscription // Subscription for new log event
 	rmLogsSub event.Subscription // Subscription for removed log event
 	chainSub  event.Subscription // Subscription for new chain event
+	txEvSub   event.Subscription // Subscription for transaction pool lifecycle event
 
 	// Channels
 	install   chan *subscription         // install filter for event notification
@@ -201,6 +212,7 @@ type EventSystem struct {
 	logsCh    chan []*types.Log          // Channel to receive new log event
 	rmLogsCh  chan core.RemovedLogsEvent // Channel to receive removed log event
 	chainCh   chan core.ChainEvent       // Channel to receive new chain event
+	txEvCh    chan []txpool.TxEvent      // Channel to receive transaction pool lifecycle event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -219,6 +231,7 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 		logsCh:    make(chan []*types.Log, logsChanSize),
 		rmLogsCh:  make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		chainCh:   make(chan core.ChainEvent, chainEvChanSize),
+		txEvCh:    make(chan []txpool.TxEvent, txEventChanSize),
 	}
 
 	// Subscribe events
@@ -226,9 +239,10 @@ func NewEventSystem(sys *FilterSystem) *EventSystem {
 	m.logsSub = m.backend.SubscribeLogsEvent(m.logsCh)
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
+	m.txEvSub = m.backend.SubscribeTxPoolEvents(m.txEvCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.txEvSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -264,6 +278,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.txs:
 			case <-sub.f.headers:
+			case <-sub.f.txEvents:
 			}
 		}
 
@@ -331,6 +346,7 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		txs:       make(chan []*types.Transaction),
 		headers:   make(chan *types.Header),
+		txEvents:  make(chan []txpool.TxEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -347,6 +363,7 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		txs:       make(chan []*types.Transaction),
 		headers:   headers,
+		txEvents:  make(chan []txpool.TxEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -363,6 +380,24 @@ func (es *EventSystem) SubscribePendingTxs(txs chan []*types.Transaction) *Subsc
 		logs:      make(chan []*types.Log),
 		txs:       txs,
 		headers:   make(chan *types.Header),
+		txEvents:  make(chan []txpool.TxEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeTxPoolEvents creates a subscription that writes the lifecycle
+// transitions of the transactions in the transaction pool.
+func (es *EventSystem) SubscribeTxPoolEvents(events chan []txpool.TxEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       TxPoolEventsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		txs:       make(chan []*types.Transaction),
+		headers:   make(chan *types.Header),
+		txEvents:  events,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -383,9 +418,23 @@ func (es *EventSystem) handleLogs(filters filterIndex, ev []*types.Log) {
 	}
 }
 
+// handleTxsEvent delivers the new pending transactions, except for the private
+// ones which are only reported once they are published.
 func (es *EventSystem) handleTxsEvent(filters filterIndex, ev core.NewTxsEvent) {
+	if len(filters[PendingTransactionsSubscription]) == 0 {
+		return
+	}
+	txs := make([]*types.Transaction, 0, len(ev.Txs))
+	for _, tx := range ev.Txs {
+		if !es.backend.IsPrivateTx(tx.Hash()) {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
 	for _, f := range filters[PendingTransactionsSubscription] {
-		f.txs <- ev.Txs
+		f.txs <- txs
 	}
 }
 
@@ -395,6 +444,26 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	}
 }
 
+// handleTxPoolEvents delivers the lifecycle events of the pooled transactions,
+// except for the private ones.
+func (es *EventSystem) handleTxPoolEvents(filters filterIndex, ev []txpool.TxEvent) {
+	if len(filters[TxPoolEventsSubscription]) == 0 {
+		return
+	}
+	events := make([]txpool.TxEvent, 0, len(ev))
+	for _, txEvent := range ev {
+		if !es.backend.IsPrivateTx(txEvent.Hash) {
+			events = append(events, txEvent)
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+	for _, f := range filters[TxPoolEventsSubscription] {
+		f.txEvents <- events
+	}
+}
+
 // eventLoop (un)installs filters and processes mux events.
 func (es *EventSystem) eventLoop() {
 	// Ensure all subscriptions get cleaned up
@@ -403,6 +472,7 @@ func (es *EventSystem) eventLoop() {
 		es.logsSub.Unsubscribe()
 		es.rmLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.txEvSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -420,6 +490,8 @@ func (es *EventSystem) eventLoop() {
 			es.handleLogs(index, ev.Logs)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.txEvCh:
+			es.handleTxPoolEvents(index, ev)
 
 		case f := <-es.install:
 			index[f.typ][f.id] = f
@@ -438,6 +510,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-es.chainSub.Err():
 			return
+		case <-es.txEvSub.Err():
+			return
 		}
 	}
 }
@@ -27,16 +27,19 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/triedb"
 )
 
 type testBackend struct {
@@ -46,6 +49,8 @@ type testBackend struct {
 	logsFeed        event.Feed
 	rmLogsFeed      event.Feed
 	chainFeed       event.Feed
+	txEventFeed     event.Feed
+	private         map[common.Hash]bool
 	pendingBlock    *types.Block
 	pendingReceipts types.Receipts
 }
@@ -125,6 +130,14 @@ func (b *testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Sub
 	return b.txFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeTxPoolEvents(ch chan<- []txpool.TxEvent) event.Subscription {
+	return b.txEventFeed.Subscribe(ch)
+}
+
+func (b *testBackend) IsPrivateTx(hash common.Hash) bool {
+	return b.private[hash]
+}
+
 func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.rmLogsFeed.Subscribe(ch)
 }
@@ -351,6 +364,86 @@ func TestPendingTxFilterFullTx(t *testing.T) {
 	}
 }
 
+// TestTxPoolEventsSubscription tests whether the transaction pool lifecycle
+// events are delivered to the subscribers.
+func TestTxPoolEventsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+
+		events = []txpool.TxEvent{
+			{Hash: common.HexToHash("0x01"), Kind: txpool.TxQueued},
+			{Hash: common.HexToHash("0x01"), Kind: txpool.TxReplaced, ReplacedBy: common.HexToHash("0x02")},
+			{Hash: common.HexToHash("0x02"), Kind: txpool.TxEvicted, Reason: txpool.TxReasonUnderpriced},
+			{Hash: common.HexToHash("0x03"), Kind: txpool.TxIncluded, Block: 1},
+		}
+	)
+	ch := make(chan []txpool.TxEvent)
+	sub := api.events.SubscribeTxPoolEvents(ch)
+	defer sub.Unsubscribe()
+
+	time.Sleep(1 * time.Second)
+	backend.txEventFeed.Send(events)
+
+	select {
+	case have := <-ch:
+		if !reflect.DeepEqual(have, events) {
+			t.Fatalf("events mismatch: have %v, want %v", have, events)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for transaction pool events")
+	}
+}
+
+// TestPendingTxPrivate tests that the private transactions are not delivered to
+// the pending transaction and the transaction pool event subscriptions.
+func TestPendingTxPrivate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+
+		to      = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		private = types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), nil)
+		public  = types.NewTransaction(1, to, new(big.Int), 0, new(big.Int), nil)
+	)
+	backend.private = map[common.Hash]bool{private.Hash(): true}
+
+	txs := make(chan []*types.Transaction, 2)
+	txSub := api.events.SubscribePendingTxs(txs)
+	defer txSub.Unsubscribe()
+	events := make(chan []txpool.TxEvent, 2)
+	evSub := api.events.SubscribeTxPoolEvents(events)
+	defer evSub.Unsubscribe()
+
+	backend.txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{private}})
+	backend.txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{private, public}})
+	backend.txEventFeed.Send([]txpool.TxEvent{{Hash: private.Hash(), Kind: txpool.TxPromoted}})
+	backend.txEventFeed.Send([]txpool.TxEvent{{Hash: private.Hash(), Kind: txpool.TxPromoted}, {Hash: public.Hash(), Kind: txpool.TxPromoted}})
+
+	select {
+	case have := <-txs:
+		if len(have) != 1 || have[0].Hash() != public.Hash() {
+			t.Fatalf("wrong pending transactions delivered: %v", have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for pending transactions")
+	}
+	select {
+	case have := <-events:
+		if len(have) != 1 || have[0].Hash != public.Hash() {
+			t.Fatalf("wrong transaction pool events delivered: %v", have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for transaction pool events")
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
@@ -630,3 +723,283 @@ func TestPendingTxFilterDeadlock(t *testing.T) {
 		}
 	}
 }
+
+// TestLogSubscriptionReplay tests that a logs subscription starting in the past
+// replays the historical logs, then delivers the live logs without duplicates.
+func TestLogSubscriptionReplay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+		addr         = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		gspec        = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 5, func(i int, gen *core.BlockGen) {
+		// Blocks with several logs, the removal of a block must deliver
+		// the removal of all its logs.
+		if i%2 == 0 {
+			for j := 0; j < 2; j++ {
+				gen.AddUncheckedReceipt(makeReceipt(addr))
+				gen.AddUncheckedTx(types.NewTransaction(uint64(2*i+j), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+			}
+		}
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type logResult struct {
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+		BlockHash   common.Hash    `json:"blockHash"`
+		Removed     bool           `json:"removed"`
+	}
+	logs := make(chan logResult)
+	sub, err := client.EthSubscribe(context.Background(), logs, "logs", map[string]interface{}{"fromBlock": "0x2", "address": addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	// The live logs of the replayed blocks are dropped, but not their removal.
+	var (
+		replayed = []*types.Log{
+			{Address: addr, BlockNumber: 5, BlockHash: chain[4].Hash()},
+			{Address: addr, BlockNumber: 5, BlockHash: chain[4].Hash(), Index: 1},
+		}
+		removed = []*types.Log{
+			{Address: addr, BlockNumber: 3, BlockHash: chain[2].Hash(), Removed: true},
+			{Address: addr, BlockNumber: 3, BlockHash: chain[2].Hash(), Index: 1, Removed: true},
+			{Address: addr, BlockNumber: 4, BlockHash: common.Hash{0x4}, Removed: true},
+		}
+		live = []*types.Log{
+			{Address: addr, BlockNumber: 3, BlockHash: chain[2].Hash()},
+			{Address: addr, BlockNumber: 3, BlockHash: chain[2].Hash(), Index: 1},
+			{Address: addr, BlockNumber: 6, BlockHash: common.Hash{0x6}},
+		}
+	)
+	backend.logsFeed.Send(replayed)
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: removed})
+
+	want := []struct {
+		number  uint64
+		hash    common.Hash
+		removed bool
+	}{
+		{3, chain[2].Hash(), false},
+		{3, chain[2].Hash(), false},
+		{5, chain[4].Hash(), false},
+		{5, chain[4].Hash(), false},
+		{3, chain[2].Hash(), true},
+		{3, chain[2].Hash(), true},
+		{3, chain[2].Hash(), false},
+		{3, chain[2].Hash(), false},
+		{6, common.Hash{0x6}, false},
+	}
+	for i, w := range want {
+		// The event system doesn't order the events of the different feeds,
+		// only send the new logs once the removal is delivered. The removed
+		// block is added back to the chain.
+		if i == 6 {
+			backend.logsFeed.Send(live)
+		}
+		select {
+		case log := <-logs:
+			if uint64(log.BlockNumber) != w.number || log.BlockHash != w.hash || log.Removed != w.removed {
+				t.Fatalf("log %d mismatch: have %d %x removed %v, want %d %x removed %v", i, log.BlockNumber, log.BlockHash, log.Removed, w.number, w.hash, w.removed)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("log %d not delivered", i)
+		}
+	}
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log delivered: %d %x", log.BlockNumber, log.BlockHash)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// The replayed blocks are no longer tracked beyond the reorg depth, their
+	// removal isn't delivered anymore.
+	backend.logsFeed.Send([]*types.Log{{Address: addr, BlockNumber: 6 + logReplayReorgDepth, BlockHash: common.Hash{0x7}}})
+	select {
+	case log := <-logs:
+		if uint64(log.BlockNumber) != 6+logReplayReorgDepth {
+			t.Fatalf("unexpected log delivered: %d %x", log.BlockNumber, log.BlockHash)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("live log not delivered")
+	}
+	backend.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: []*types.Log{{Address: addr, BlockNumber: 5, BlockHash: chain[4].Hash(), Removed: true}}})
+	select {
+	case log := <-logs:
+		t.Fatalf("unexpected log delivered: %d %x removed %v", log.BlockNumber, log.BlockHash, log.Removed)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestLogSubscriptionReplayLimit tests that the logs subscriptions replaying too
+// many blocks are rejected.
+func TestLogSubscriptionReplayLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{})
+		api    = NewFilterAPI(sys)
+		head   = &types.Header{Number: big.NewInt(2 * logReplayMaxBlocks)}
+	)
+	rawdb.WriteHeader(db, head)
+	rawdb.WriteCanonicalHash(db, head.Hash(), head.Number.Uint64())
+	rawdb.WriteHeadBlockHash(db, head.Hash())
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	tests := []struct {
+		crit map[string]interface{}
+		ok   bool
+	}{
+		{map[string]interface{}{"fromBlock": hexutil.Uint64(0)}, false},
+		{map[string]interface{}{"fromBlock": hexutil.Uint64(logReplayMaxBlocks)}, false},
+		{map[string]interface{}{"fromBlock": hexutil.Uint64(logReplayMaxBlocks + 1)}, true},
+		{map[string]interface{}{"fromBlock": hexutil.Uint64(0), "toBlock": hexutil.Uint64(logReplayMaxBlocks - 1)}, true},
+	}
+	for i, test := range tests {
+		sub, err := client.EthSubscribe(context.Background(), make(chan types.Log), "logs", test.crit)
+		if test.ok != (err == nil) {
+			t.Errorf("test %d: unexpected result, ok %v, err %v", i, test.ok, err)
+		}
+		if err == nil {
+			sub.Unsubscribe()
+		}
+	}
+}
+
+// TestChainUpdates tests that the chain updates subscription reverts the blocks
+// of the old chain and applies the ones of the new chain on reorgs.
+func TestChainUpdates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+		addr         = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		gspec        = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		withLog = func(i int, gen *core.BlockGen) {
+			gen.AddUncheckedReceipt(makeReceipt(addr))
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	)
+	genDb, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, withLog)
+	fork, forkReceipts := core.GenerateChain(gspec.Config, chain[0], ethash.NewFaker(), genDb, 3, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x1})
+		withLog(i, gen)
+	})
+	gspec.MustCommit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+
+	write := func(blocks []*types.Block, receipts []types.Receipts) {
+		for i, block := range blocks {
+			rawdb.WriteBlock(db, block)
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+			rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		}
+	}
+	write(chain, receipts)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type updateResult struct {
+		Type  string `json:"type"`
+		Block struct {
+			Number hexutil.Uint64 `json:"number"`
+			Hash   common.Hash    `json:"hash"`
+		} `json:"block"`
+		Receipts []struct {
+			BlockHash common.Hash `json:"blockHash"`
+		} `json:"receipts"`
+	}
+	updates := make(chan updateResult)
+	sub, err := client.EthSubscribe(context.Background(), updates, "chainUpdates", map[string]interface{}{"receipts": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	write(fork, forkReceipts)
+	backend.chainFeed.Send(core.ChainEvent{Header: fork[2].Header()})
+
+	want := []struct {
+		typ   string
+		block *types.Block
+	}{
+		{ChainUpdateRevert, chain[2]},
+		{ChainUpdateRevert, chain[1]},
+		{ChainUpdateApply, fork[0]},
+		{ChainUpdateApply, fork[1]},
+		{ChainUpdateApply, fork[2]},
+	}
+	for i, w := range want {
+		select {
+		case update := <-updates:
+			if update.Type != w.typ || uint64(update.Block.Number) != w.block.NumberU64() || update.Block.Hash != w.block.Hash() {
+				t.Fatalf("update %d mismatch: have %s %d %x, want %s %d %x", i, update.Type, update.Block.Number, update.Block.Hash, w.typ, w.block.NumberU64(), w.block.Hash())
+			}
+			if len(update.Receipts) != 1 || update.Receipts[0].BlockHash != w.block.Hash() {
+				t.Fatalf("update %d has wrong receipts: %v", i, update.Receipts)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("update %d not delivered", i)
+		}
+	}
+	// A new head whose ancestry is unavailable is delivered as a reset.
+	orphan := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), ParentHash: common.Hash{0xff}, Difficulty: common.Big1})
+	rawdb.WriteBlock(db, orphan)
+	rawdb.WriteCanonicalHash(db, orphan.Hash(), orphan.NumberU64())
+	rawdb.WriteHeadBlockHash(db, orphan.Hash())
+	backend.chainFeed.Send(core.ChainEvent{Header: orphan.Header()})
+
+	select {
+	case update := <-updates:
+		if update.Type != ChainUpdateReset || uint64(update.Block.Number) != orphan.NumberU64() || update.Block.Hash != orphan.Hash() {
+			t.Fatalf("reset mismatch: have %s %d %x, want %s %d %x", update.Type, update.Block.Number, update.Block.Hash, ChainUpdateReset, orphan.NumberU64(), orphan.Hash())
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reset not delivered")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chainUpdateMaxDepth is the maximum number of blocks reverted and applied by
+// a single head change. Deeper changes, typically happening during the sync, are
+// delivered as a reset.
+const chainUpdateMaxDepth = 1024
+
+// Types of the chain updates.
+const (
+	ChainUpdateApply  = "apply"  // Block added to the canonical chain
+	ChainUpdateRevert = "revert" // Block removed from the canonical chain
+	ChainUpdateReset  = "reset"  // Head changed without the reverts and applications
+)
+
+var errChainUpdateTooDeep = errors.New("chain update too deep")
+
+// ChainUpdatesConfig are the options of the chain updates subscription.
+type ChainUpdatesConfig struct {
+	Receipts bool `json:"receipts"` // Include the receipts of the blocks
+}
+
+// ChainUpdate is a change of the canonical chain delivered by the chain updates
+// subscription.
+type ChainUpdate struct {
+	Type     string                   `json:"type"`
+	Block    map[string]interface{}   `json:"block"`
+	Receipts []map[string]interface{} `json:"receipts,omitempty"`
+}
+
+// ChainUpdates creates a subscription that fires an ordered stream of block
+// applications and reverts, derived from the changes of the canonical head.
+//
+// When the head changes, the blocks of the old chain are reverted first down to
+// the common ancestor, starting at the old head. Then the blocks of the new chain
+// are applied, ending at the new head. Replaying the stream in order keeps any
+// derived state consistent with the canonical chain without reorg detection.
+//
+// If the changes can't be derived, e.g. because the head moved too far during
+// the sync, a reset update carrying the new head is delivered instead. The state
+// derived from the previous updates is stale at that point and needs to be
+// rebuilt from the new head.
+func (api *FilterAPI) ChainUpdates(ctx context.Context, config *ChainUpdatesConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if config == nil {
+		config = new(ChainUpdatesConfig)
+	}
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		headers    = make(chan *types.Header)
+		headersSub = api.events.SubscribeNewHeads(headers)
+		last       = api.sys.backend.CurrentHeader()
+	)
+	go func() {
+		defer headersSub.Unsubscribe()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		for {
+			select {
+			case <-headers:
+				// The chain events may be stale, the current head is diffed
+				// against the last delivered one instead.
+				head := api.sys.backend.CurrentHeader()
+				updates, err := api.chainUpdates(ctx, last, head, config.Receipts)
+				if err != nil {
+					log.Debug("Resetting chain updates", "from", last.Number, "to", head.Number, "err", err)
+					updates = []*ChainUpdate{{Type: ChainUpdateReset, Block: ethapi.RPCMarshalHeader(head)}}
+				}
+				for _, update := range updates {
+					notifier.Notify(rpcSub.ID, update)
+				}
+				last = head
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// chainUpdates returns the reverts and applications turning the old chain into
+// the new one.
+func (api *FilterAPI) chainUpdates(ctx context.Context, oldHead, newHead *types.Header, receipts bool) ([]*ChainUpdate, error) {
+	var (
+		reverted []*types.Header
+		applied  []*types.Header
+		err      error
+	)
+	for oldHead.Hash() != newHead.Hash() {
+		if len(reverted)+len(applied) > chainUpdateMaxDepth {
+			return nil, errChainUpdateTooDeep
+		}
+		oldNumber, newNumber := oldHead.Number.Uint64(), newHead.Number.Uint64()
+		if oldNumber >= newNumber {
+			reverted = append(reverted, oldHead)
+			if oldHead, err = api.parentHeader(ctx, oldHead); err != nil {
+				return nil, err
+			}
+		}
+		if newNumber >= oldNumber {
+			applied = append(applied, newHead)
+			if newHead, err = api.parentHeader(ctx, newHead); err != nil {
+				return nil, err
+			}
+		}
+	}
+	slices.Reverse(applied)
+
+	updates := make([]*ChainUpdate, 0, len(reverted)+len(applied))
+	for _, header := range reverted {
+		update, err := api.chainUpdate(ctx, ChainUpdateRevert, header, receipts)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	for _, header := range applied {
+		update, err := api.chainUpdate(ctx, ChainUpdateApply, header, receipts)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// parentHeader retrieves the parent of the given header.
+func (api *FilterAPI) parentHeader(ctx context.Context, header *types.Header) (*types.Header, error) {
+	parent, err := api.sys.backend.HeaderByHash(ctx, header.ParentHash)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("missing parent of block %d", header.Number)
+	}
+	return parent, nil
+}
+
+// chainUpdate assembles the update of the given block, with the receipts if
+// requested.
+func (api *FilterAPI) chainUpdate(ctx context.Context, typ string, header *types.Header, receipts bool) (*ChainUpdate, error) {
+	update := &ChainUpdate{
+		Type:  typ,
+		Block: ethapi.RPCMarshalHeader(header),
+	}
+	if !receipts {
+		return update, nil
+	}
+	body, err := api.sys.backend.GetBody(ctx, header.Hash(), rpc.BlockNumber(header.Number.Int64()))
+	if err != nil {
+		return nil, err
+	}
+	list, err := api.sys.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	block := types.NewBlockWithHeader(header).WithBody(*body)
+	if update.Receipts, err = ethapi.RPCMarshalReceipts(block, list, api.sys.backend.ChainConfig()); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// logReplayBatchSize is the number of blocks whose historical logs are
+	// retrieved and delivered at once.
+	logReplayBatchSize = 1000
+
+	// logReplayMaxPending is the maximum number of live logs buffered while the
+	// replay is in progress.
+	logReplayMaxPending = 100000
+
+	// logReplayMaxBlocks is the maximum number of blocks replayed by a single
+	// subscription.
+	logReplayMaxBlocks = 10000
+
+	// logReplayReorgDepth is the depth beyond which the replayed blocks are not
+	// expected to be reorged, their delivery isn't tracked afterwards.
+	logReplayReorgDepth = 1024
+)
+
+var (
+	errLogReplayOverflow = errors.New("too many new logs during the replay, resubscribe from a later block")
+	errLogReplayTooLarge = fmt.Errorf("log replay range exceeds %d blocks", logReplayMaxBlocks)
+)
+
+// replayHead returns the last block replayed for the criteria, which is the
+// current head unless an earlier end block is specified.
+func (api *FilterAPI) replayHead(crit FilterCriteria) uint64 {
+	head := api.sys.backend.CurrentHeader().Number.Uint64()
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < head {
+		head = crit.ToBlock.Uint64()
+	}
+	return head
+}
+
+// logReplay is the result of a replayed batch of blocks.
+type logReplay struct {
+	logs []*types.Log
+	err  error
+}
+
+// replayLogs serves a logs subscription which starts in the past. The historical
+// logs of the canonical chain are delivered first, then the subscription switches
+// to the live logs.
+//
+// The live logs are buffered while the replay is in progress. The live logs of the
+// replayed blocks are duplicates and dropped, unless they are removed by a reorg
+// in which case the removal is delivered. The live logs of the blocks which weren't
+// replayed are delivered as is, this covers the reorgs happening during the replay.
+//
+// The subscription is closed with an error if the replay fails, or if too many live
+// logs are buffered before it finishes.
+//
+// The live subscription must be installed before the head is resolved, every block
+// after the head is delivered by it.
+func (api *FilterAPI) replayLogs(notifier *rpc.Notifier, rpcSub *rpc.Subscription, logsSub *Subscription, matchedLogs chan []*types.Log, crit FilterCriteria, head uint64) {
+	defer logsSub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		replayed = make(chan logReplay)
+		pending  []*types.Log
+	)
+	go api.replayRange(ctx, crit, crit.FromBlock.Uint64(), head, replayed)
+
+	// delivered tracks the blocks up to the head whose logs were delivered, keyed
+	// by hash. The flag is set while the replayed logs of the block are still on
+	// the client side, in which case the live logs of the block are duplicates.
+	// Once the block is removed or delivered live, all its logs are delivered.
+	//
+	// The blocks are dropped once they are deeper than the reorg depth of the
+	// latest live block, no further logs are expected for them.
+	type deliveredBlock struct {
+		number   uint64
+		replayed bool
+	}
+	var (
+		delivered = make(map[common.Hash]deliveredBlock)
+		latest    uint64
+	)
+	deliver := func(logs []*types.Log) {
+		for _, log := range logs {
+			if log.BlockNumber <= head {
+				block, seen := delivered[log.BlockHash]
+				if log.Removed && !seen {
+					continue // removal of a block never delivered
+				}
+				if !log.Removed && block.replayed {
+					continue // duplicate of a replayed log
+				}
+				delivered[log.BlockHash] = deliveredBlock{number: log.BlockNumber}
+			}
+			notifier.Notify(rpcSub.ID, &log)
+
+			if log.BlockNumber > latest {
+				latest = log.BlockNumber
+				for hash, block := range delivered {
+					if block.number+logReplayReorgDepth < latest {
+						delete(delivered, hash)
+					}
+				}
+			}
+		}
+	}
+	for replayed != nil {
+		select {
+		case res, ok := <-replayed:
+			if !ok {
+				replayed = nil
+				deliver(pending)
+				pending = nil
+				break
+			}
+			if res.err != nil {
+				log.Debug("Failed to replay logs", "from", crit.FromBlock, "to", head, "err", res.err)
+				notifier.Close(rpcSub.ID, fmt.Errorf("failed to replay logs: %w", res.err))
+				return
+			}
+			for _, log := range res.logs {
+				delivered[log.BlockHash] = deliveredBlock{number: log.BlockNumber, replayed: true}
+				notifier.Notify(rpcSub.ID, &log)
+			}
+		case logs := <-matchedLogs:
+			if len(pending)+len(logs) > logReplayMaxPending {
+				notifier.Close(rpcSub.ID, errLogReplayOverflow)
+				return
+			}
+			pending = append(pending, logs...)
+		case <-rpcSub.Err(): // client send an unsubscribe request
+			return
+		}
+	}
+	for {
+		select {
+		case logs := <-matchedLogs:
+			deliver(logs)
+		case <-rpcSub.Err(): // client send an unsubscribe request
+			return
+		}
+	}
+}
+
+// replayRange retrieves the historical logs of the given block range in batches,
+// and sends them in order. The channel is closed when the range is finished.
+func (api *FilterAPI) replayRange(ctx context.Context, crit FilterCriteria, from, to uint64, results chan<- logReplay) {
+	defer close(results)
+
+	for begin := from; begin <= to; begin += logReplayBatchSize {
+		end := min(begin+logReplayBatchSize-1, to)
+		logs, err := api.sys.NewRangeFilter(int64(begin), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+		select {
+		case results <- logReplay{logs: logs, err: err}:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -303,6 +303,16 @@ func testSendTransactions(t *testing.T, protocol uint) {
 	go handler.txpool.Add(insert, false, false) // Need goroutine to not block on feed
 	time.Sleep(250 * time.Millisecond)          // Wait until tx events get out of the system (can't use events, tx broadcaster races with peer join)
 
+	// Add some private transactions which must not be sent
+	private := make([]*types.Transaction, 10)
+	for i := range private {
+		tx := types.NewTransaction(uint64(len(insert)+i), common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil)
+		tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+		private[i] = tx
+	}
+	go handler.txpool.addPrivate(private)
+	time.Sleep(250 * time.Millisecond)
+
 	// Create a source handler to send messages through and a sink peer to receive them
 	p2pSrc, p2pSink := p2p.MsgPipe()
 	defer p2pSrc.Close()
@@ -350,6 +360,9 @@ func testSendTransactions(t *testing.T, protocol uint) {
 					if _, ok := seen[hash]; ok {
 						t.Errorf("duplicate transaction announced: %x", hash)
 					}
+					if handler.txpool.IsPrivate(hash) {
+						t.Errorf("private transaction announced: %x", hash)
+					}
 					seen[hash] = struct{}{}
 				}
 			case <-bcasts:
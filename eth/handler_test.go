@@ -48,7 +48,8 @@ var (
 // Its goal is to get around setting up a valid statedb for the balance and nonce
 // checks.
 type testTxPool struct {
-	pool map[common.Hash]*types.Transaction // Hash map of collected transactions
+	pool    map[common.Hash]*types.Transaction // Hash map of collected transactions
+	private map[common.Hash]bool               // Hashes of the private transactions
 
 	txFeed event.Feed   // Notification feed to allow waiting for inclusion
 	lock   sync.RWMutex // Protects the transaction pool
@@ -57,7 +58,8 @@ type testTxPool struct {
 // newTestTxPool creates a mock transaction pool.
 func newTestTxPool() *testTxPool {
 	return &testTxPool{
-		pool: make(map[common.Hash]*types.Transaction),
+		pool:    make(map[common.Hash]*types.Transaction),
+		private: make(map[common.Hash]bool),
 	}
 }
 
@@ -78,6 +80,23 @@ func (p *testTxPool) Get(hash common.Hash) *types.Transaction {
 	return p.pool[hash]
 }
 
+// IsPrivate returns whether the transaction with the given hash is private.
+func (p *testTxPool) IsPrivate(hash common.Hash) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.private[hash]
+}
+
+// addPrivate appends a batch of private transactions to the pool.
+func (p *testTxPool) addPrivate(txs []*types.Transaction) {
+	p.lock.Lock()
+	for _, tx := range txs {
+		p.private[tx.Hash()] = true
+	}
+	p.lock.Unlock()
+	p.Add(txs, false, false)
+}
+
 // Add appends a batch of transactions to the pool, and notifies any
 // listeners if the addition channel is non nil
 func (p *testTxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
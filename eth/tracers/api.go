@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -90,14 +92,43 @@ type Backend interface {
 	StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*types.Transaction, vm.BlockContext, *state.StateDB, StateReleaseFunc, error)
 }
 
+// APIConfig contains the node-level settings of the tracing APIs.
+type APIConfig struct {
+	ChainTraceDir string              // Base directory of the chain segments traced into files
+	CallIndex     ethdb.KeyValueStore // Call address index maintained by the live tracer, nil if not maintained
+}
+
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
 type API struct {
 	backend Backend
+
+	callIndex ethdb.KeyValueStore       // Call address index for trace_filter, nil if not maintained
+	chainDir  string                    // Base directory of the chain segments traced into files
+	chainJobs map[string]*chainTraceJob // Chain segments being traced into files, keyed by directory
+	chainStop bool                      // Flag whether the chain tracing is terminated at shutdown
+	chainLock sync.Mutex                // Lock for protecting the chain tracing jobs
 }
 
 // NewAPI creates a new API definition for the tracing methods of the Ethereum service.
 func NewAPI(backend Backend) *API {
-	return &API{backend: backend}
+	return newAPI(backend, nil)
+}
+
+// newAPI creates a new API definition with the given node-level settings.
+func newAPI(backend Backend, config *APIConfig) *API {
+	if config == nil {
+		config = &APIConfig{}
+	}
+	dir := config.ChainTraceDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), defaultChainTraceDir)
+	}
+	return &API{
+		backend:   backend,
+		callIndex: config.CallIndex,
+		chainDir:  dir,
+		chainJobs: make(map[string]*chainTraceJob),
+	}
 }
 
 // chainContext constructs the context reader which is used by the evm for reading
@@ -914,6 +945,49 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // the trace will be conducted on the state after executing the specified transaction
 // within the specified block.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	if config != nil {
+		config.BlockOverrides.Apply(&vmctx)
+		rules := api.backend.ChainConfig().Rules(vmctx.BlockNumber, vmctx.Random != nil, vmctx.Time)
+
+		precompiles := vm.ActivePrecompiledContracts(rules)
+		if err := config.StateOverrides.Apply(statedb, precompiles); err != nil {
+			return nil, err
+		}
+	}
+	// Execute the trace
+	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+		return nil, err
+	}
+	var (
+		msg         = args.ToMessage(vmctx.BaseFee, true, true)
+		tx          = args.ToTransaction(types.LegacyTxType)
+		traceConfig *TraceConfig
+	)
+	// Lower the basefee to 0 to avoid breaking EVM
+	// invariants (basefee < feecap).
+	if msg.GasPrice.Sign() == 0 {
+		vmctx.BaseFee = new(big.Int)
+	}
+	if msg.BlobGasFeeCap != nil && msg.BlobGasFeeCap.BitLen() == 0 {
+		vmctx.BlobBaseFee = new(big.Int)
+	}
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig)
+}
+
+// callState retrieves the block and the state the calls of TraceCall and
+// TraceCallMany are executed on.
+func (api *API) callState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (*types.Block, *state.StateDB, StateReleaseFunc, error) {
 	// Try to retrieve the specified block
 	var (
 		err     error
@@ -930,14 +1004,14 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// more flexibility and stability than trying to trace on 'pending', since
 			// the contents of 'pending' is unstable and probably not a true representation
 			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
+			return nil, nil, nil, errors.New("tracing on top of pending is not supported")
 		}
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
@@ -951,42 +1025,45 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	defer release()
-
-	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-	// Apply the customization rules if required.
-	if config != nil {
-		config.BlockOverrides.Apply(&vmctx)
-		rules := api.backend.ChainConfig().Rules(vmctx.BlockNumber, vmctx.Random != nil, vmctx.Time)
+	return block, statedb, release, nil
+}
 
-		precompiles := vm.ActivePrecompiledContracts(rules)
-		if err := config.StateOverrides.Apply(statedb, precompiles); err != nil {
-			return nil, err
-		}
-	}
-	// Execute the trace
-	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+// TraceCallMany lets you trace a sequence of eth_calls executed on top of the
+// provided block, each call seeing the state changes of the preceding ones. The
+// calls are traced with the tracer of the config, every result carrying the trace
+// of its call. The state overrides of the config are applied before the first call,
+// the ones of the individual calls right before them.
+func (api *API) TraceCallMany(ctx context.Context, calls []ethapi.CallManyArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([]*ethapi.CallManyResult, error) {
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
 		return nil, err
 	}
+	defer release()
+
 	var (
-		msg         = args.ToMessage(vmctx.BaseFee, true, true)
-		tx          = args.ToTransaction(types.LegacyTxType)
-		traceConfig *TraceConfig
+		traceConfig    TraceConfig
+		overrides      *ethapi.StateOverride
+		blockOverrides *ethapi.BlockOverrides
+		timeout        = defaultTraceTimeout
 	)
-	// Lower the basefee to 0 to avoid breaking EVM
-	// invariants (basefee < feecap).
-	if msg.GasPrice.Sign() == 0 {
-		vmctx.BaseFee = new(big.Int)
+	if config != nil {
+		traceConfig, overrides, blockOverrides = config.TraceConfig, config.StateOverrides, config.BlockOverrides
 	}
-	if msg.BlobGasFeeCap != nil && msg.BlobGasFeeCap.BitLen() == 0 {
-		vmctx.BlobBaseFee = new(big.Int)
+	if traceConfig.Timeout != nil {
+		if timeout, err = time.ParseDuration(*traceConfig.Timeout); err != nil {
+			return nil, err
+		}
 	}
-	if config != nil {
-		traceConfig = &config.TraceConfig
+	newTracer := func(index int, tx *types.Transaction) (*ethapi.CallTracer, error) {
+		tracer, err := api.newTracer(&traceConfig, &Context{TxIndex: index, TxHash: tx.Hash()})
+		if err != nil {
+			return nil, err
+		}
+		return &ethapi.CallTracer{Hooks: tracer.Hooks, GetResult: tracer.GetResult, Stop: tracer.Stop}, nil
 	}
-	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig)
+	return ethapi.DoCallMany(ctx, api.chainContext(ctx), api.backend.ChainConfig(), calls, statedb, block.Header(), overrides, blockOverrides, timeout, api.backend.RPCGasCap(), newTracer)
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
@@ -1002,19 +1079,8 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 	if config == nil {
 		config = &TraceConfig{}
 	}
-	// Default tracer is the struct logger
-	if config.Tracer == nil {
-		logger := logger.NewStructLogger(config.Config)
-		tracer = &Tracer{
-			Hooks:     logger.Hooks(),
-			GetResult: logger.GetResult,
-			Stop:      logger.Stop,
-		}
-	} else {
-		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig, api.backend.ChainConfig())
-		if err != nil {
-			return nil, err
-		}
+	if tracer, err = api.newTracer(config, txctx); err != nil {
+		return nil, err
 	}
 	// The actual TxContext will be created as part of ApplyTransactionWithEVM.
 	vmenv := vm.NewEVM(vmctx, vm.TxContext{GasPrice: message.GasPrice, BlobFeeCap: message.BlobGasFeeCap}, statedb, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
@@ -1045,13 +1111,43 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 	return tracer.GetResult()
 }
 
+// newTracer creates the tracer of the config, defaulting to the struct logger.
+func (api *API) newTracer(config *TraceConfig, txctx *Context) (*Tracer, error) {
+	if config.Tracer == nil {
+		logger := logger.NewStructLogger(config.Config)
+		return &Tracer{
+			Hooks:     logger.Hooks(),
+			GetResult: logger.GetResult,
+			Stop:      logger.Stop,
+		}, nil
+	}
+	return DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig, api.backend.ChainConfig())
+}
+
+// Register adds the tracing APIs to the node, along with the lifecycle which
+// terminates the chain segments traced into files at shutdown.
+func Register(stack *node.Node, backend Backend, config *APIConfig) {
+	api := newAPI(backend, config)
+	stack.RegisterAPIs(apis(api))
+	stack.RegisterLifecycle(&chainTraceService{api: api})
+}
+
 // APIs return the collection of RPC services the tracer package offers.
-func APIs(backend Backend) []rpc.API {
+func APIs(backend Backend, config *APIConfig) []rpc.API {
+	return apis(newAPI(backend, config))
+}
+
+// apis returns the collection of RPC services backed by the given API.
+func apis(api *API) []rpc.API {
 	// Append all the local APIs and return
 	return []rpc.API{
 		{
 			Namespace: "debug",
-			Service:   NewAPI(backend),
+			Service:   api,
+		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(api),
 		},
 	}
 }
@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"reflect"
 	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -404,6 +405,50 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	var (
+		value    = (*hexutil.Big)(big.NewInt(600))
+		transfer = ethapi.CallManyArgs{TransactionArgs: ethapi.TransactionArgs{From: &accounts[1].addr, To: &accounts[0].addr, Value: value}}
+		config   = &TraceCallConfig{
+			StateOverrides: &ethapi.StateOverride{
+				accounts[1].addr: ethapi.OverrideAccount{Balance: newRPCBalance(big.NewInt(1000))},
+			},
+		}
+	)
+	results, err := api.TraceCallMany(context.Background(), []ethapi.CallManyArgs{transfer}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace logger.ExecutionResult
+	if err := json.Unmarshal(results[0].Trace, &trace); err != nil {
+		t.Fatal(err)
+	}
+	if trace.Failed || trace.Gas != params.TxGas {
+		t.Fatalf("wrong trace %+v", trace)
+	}
+	// The second transfer sees the balance spent by the first one.
+	results, err = api.TraceCallMany(context.Background(), []ethapi.CallManyArgs{transfer, transfer}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error != nil || results[1].Error == nil || !strings.Contains(results[1].Error.Message, "insufficient funds") {
+		t.Fatalf("wrong errors %v, %v", results[0].Error, results[1].Error)
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
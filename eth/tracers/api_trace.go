@@ -0,0 +1,529 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// Names of the tracers the trace namespace is built on.
+	flatCallTracerName = "flatCallTracer"
+	prestateTracerName = "prestateTracer"
+	vmTracerName       = "vmTracer"
+
+	// Trace types supported by the replay methods.
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVmTrace   = "vmTrace"
+
+	// traceFilterMaxBlocks is the maximum number of blocks re-executed for
+	// serving a single trace_filter request.
+	traceFilterMaxBlocks = 10000
+
+	// traceFilterMaxResults is the maximum number of traces returned by a single
+	// trace_filter request.
+	traceFilterMaxResults = 10000
+)
+
+var (
+	errTraceFilterTooManyBlocks  = fmt.Errorf("trace filter exceeds the limit of %d re-executed blocks, narrow the block range", traceFilterMaxBlocks)
+	errTraceFilterTooManyResults = fmt.Errorf("trace filter exceeds the limit of %d results, use after and count to paginate", traceFilterMaxResults)
+)
+
+// TraceAPI is the collection of Parity-compatible tracing APIs exposed over
+// the trace namespace. It's built on the tracers offered by the debug API.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the Parity-compatible tracing
+// methods of the Ethereum service.
+func NewTraceAPI(api *API) *TraceAPI {
+	return &TraceAPI{api: api}
+}
+
+// flatTraceConfig returns the trace config for producing the Parity-style
+// flat call traces.
+func flatTraceConfig() *TraceConfig {
+	tracer := flatCallTracerName
+	return &TraceConfig{
+		Tracer:       &tracer,
+		TracerConfig: json.RawMessage(`{"convertParityErrors":true}`),
+	}
+}
+
+// replayTraceConfig returns the trace config for producing the requested types
+// of traces in one go. The flat call tracer is always enabled, as the output of
+// the execution is extracted from it.
+func replayTraceConfig(traceTypes []string) (*TraceConfig, error) {
+	if len(traceTypes) == 0 {
+		return nil, errors.New("no trace type is specified")
+	}
+	config := map[string]json.RawMessage{
+		flatCallTracerName: json.RawMessage(`{"convertParityErrors":true}`),
+	}
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+		case traceTypeStateDiff:
+			config[prestateTracerName] = json.RawMessage(`{"diffMode":true}`)
+		case traceTypeVmTrace:
+			config[vmTracerName] = json.RawMessage(`{}`)
+		default:
+			return nil, fmt.Errorf("unsupported trace type %q", typ)
+		}
+	}
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	tracer := "muxTracer"
+	return &TraceConfig{Tracer: &tracer, TracerConfig: blob}, nil
+}
+
+// Block returns the Parity-style flat call traces of all the transactions in
+// the given block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	results, err := api.api.TraceBlockByNumber(ctx, number, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for _, result := range results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %#x: %s", result.TxHash, result.Error)
+		}
+		var frames []json.RawMessage
+		if err := json.Unmarshal(result.Result.(json.RawMessage), &frames); err != nil {
+			return nil, err
+		}
+		traces = append(traces, frames...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the Parity-style flat call traces of the given transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) (interface{}, error) {
+	return api.api.TraceTransaction(ctx, hash, flatTraceConfig())
+}
+
+// TraceFilterArgs represents the arguments for filtering the call traces.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// flatTraceAddresses is the subset of a flat call trace for address filtering.
+type flatTraceAddresses struct {
+	Type   string `json:"type"`
+	Action struct {
+		From          *common.Address `json:"from"`
+		To            *common.Address `json:"to"`
+		Address       *common.Address `json:"address"`
+		RefundAddress *common.Address `json:"refundAddress"`
+		Author        *common.Address `json:"author"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address"`
+	} `json:"result"`
+}
+
+// addresses returns the sender and the recipient of the traced call.
+func (t *flatTraceAddresses) addresses() (from *common.Address, to *common.Address) {
+	switch t.Type {
+	case "create":
+		if t.Result != nil {
+			to = t.Result.Address
+		}
+		return t.Action.From, to
+	case "suicide":
+		return t.Action.Address, t.Action.RefundAddress
+	case "reward":
+		return nil, t.Action.Author
+	default:
+		return t.Action.From, t.Action.To
+	}
+}
+
+// matchAddress returns whether the address is included in the filter list. An
+// empty list matches all addresses.
+func matchAddress(addr *common.Address, list []common.Address) bool {
+	if len(list) == 0 {
+		return true
+	}
+	return addr != nil && slices.Contains(list, *addr)
+}
+
+// Filter returns the Parity-style flat call traces within the block range that
+// match the sender and recipient filters. A trace matches if both the sender
+// and the recipient are included in the specified lists, the empty list matches
+// any address.
+//
+// If the call address index is maintained by the live tracer, only the blocks
+// involving the requested addresses are re-executed. At most traceFilterMaxBlocks
+// blocks are re-executed and traceFilterMaxResults traces are returned per call.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	fromBlock, toBlock := rpc.LatestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		fromBlock = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		toBlock = *args.ToBlock
+	}
+	from, err := api.api.blockByNumber(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.api.blockByNumber(ctx, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("start block (#%d) needs to come before end block (#%d)", from.NumberU64(), to.NumberU64())
+	}
+	if args.Count != nil && *args.Count > traceFilterMaxResults {
+		return nil, errTraceFilterTooManyResults
+	}
+	var (
+		skipped uint64
+		traced  int
+		traces  = []json.RawMessage{}
+		filter  = newBlockFilter(api.api.callIndex, from.NumberU64(), to.NumberU64(), args.FromAddress, args.ToAddress)
+	)
+	for number := from.NumberU64(); number <= to.NumberU64(); number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Skip the blocks which are known to not involve the addresses
+		if !filter.include(number) {
+			continue
+		}
+		if traced++; traced > traceFilterMaxBlocks {
+			return nil, errTraceFilterTooManyBlocks
+		}
+		frames, err := api.Block(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		for _, frame := range frames {
+			var trace flatTraceAddresses
+			if err := json.Unmarshal(frame, &trace); err != nil {
+				return nil, err
+			}
+			sender, recipient := trace.addresses()
+			if !matchAddress(sender, args.FromAddress) || !matchAddress(recipient, args.ToAddress) {
+				continue
+			}
+			if args.After != nil && skipped < *args.After {
+				skipped++
+				continue
+			}
+			if len(traces) >= traceFilterMaxResults {
+				return nil, errTraceFilterTooManyResults
+			}
+			traces = append(traces, frame)
+			if args.Count != nil && uint64(len(traces)) >= *args.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// TraceResults is the result of replaying a transaction or a call with the
+// requested types of traces. The fields of the unrequested types are null.
+type TraceResults struct {
+	Output          hexutil.Bytes                 `json:"output"`
+	StateDiff       map[common.Address]*StateDiff `json:"stateDiff"`
+	Trace           []json.RawMessage             `json:"trace"`
+	VmTrace         json.RawMessage               `json:"vmTrace"`
+	TransactionHash *common.Hash                  `json:"transactionHash,omitempty"`
+}
+
+// Call executes the given call on top of the specified block and returns the
+// requested types of traces.
+func (api *TraceAPI) Call(ctx context.Context, args ethapi.TransactionArgs, traceTypes []string, blockNrOrHash *rpc.BlockNumberOrHash) (*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	res, err := api.api.TraceCall(ctx, args, *blockNrOrHash, &TraceCallConfig{TraceConfig: *config})
+	if err != nil {
+		return nil, err
+	}
+	return newTraceResults(res.(json.RawMessage), traceTypes)
+}
+
+// ReplayTransaction replays the given transaction and returns the requested
+// types of traces.
+func (api *TraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	res, err := api.api.TraceTransaction(ctx, hash, config)
+	if err != nil {
+		return nil, err
+	}
+	return newTraceResults(res.(json.RawMessage), traceTypes)
+}
+
+// ReplayBlockTransactions replays all the transactions in the given block and
+// returns the requested types of traces.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TraceResults, error) {
+	config, err := replayTraceConfig(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.api.TraceBlockByNumber(ctx, number, config)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, 0, len(results))
+	for _, result := range results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("failed to trace transaction %#x: %s", result.TxHash, result.Error)
+		}
+		replay, err := newTraceResults(result.Result.(json.RawMessage), traceTypes)
+		if err != nil {
+			return nil, err
+		}
+		hash := result.TxHash
+		replay.TransactionHash = &hash
+		replays = append(replays, replay)
+	}
+	return replays, nil
+}
+
+// newTraceResults assembles the replay result from the output of mux tracer.
+func newTraceResults(blob json.RawMessage, traceTypes []string) (*TraceResults, error) {
+	var res map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return nil, err
+	}
+	var frames []json.RawMessage
+	if err := json.Unmarshal(res[flatCallTracerName], &frames); err != nil {
+		return nil, err
+	}
+	results := &TraceResults{Output: hexutil.Bytes{}}
+
+	// The output of the execution is carried by the top-level call frame.
+	if len(frames) > 0 {
+		var top struct {
+			Result *struct {
+				Code   *hexutil.Bytes `json:"code"`
+				Output *hexutil.Bytes `json:"output"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(frames[0], &top); err != nil {
+			return nil, err
+		}
+		if top.Result != nil {
+			if top.Result.Output != nil {
+				results.Output = *top.Result.Output
+			} else if top.Result.Code != nil {
+				results.Output = *top.Result.Code
+			}
+		}
+	}
+	if slices.Contains(traceTypes, traceTypeTrace) {
+		results.Trace = frames
+	}
+	if slices.Contains(traceTypes, traceTypeStateDiff) {
+		diff, err := newStateDiff(res[prestateTracerName])
+		if err != nil {
+			return nil, err
+		}
+		results.StateDiff = diff
+	}
+	if slices.Contains(traceTypes, traceTypeVmTrace) {
+		results.VmTrace = res[vmTracerName]
+	}
+	return results, nil
+}
+
+// DiffValue is the Parity-style representation of a changed value, which is
+// one of "=" (unchanged), {"+": new} (created), {"-": old} (deleted) or
+// {"*": {"from": old, "to": new}} (modified).
+type DiffValue struct {
+	kind     string
+	from, to interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DiffValue) MarshalJSON() ([]byte, error) {
+	switch d.kind {
+	case "+":
+		return json.Marshal(map[string]interface{}{"+": d.to})
+	case "-":
+		return json.Marshal(map[string]interface{}{"-": d.from})
+	case "*":
+		return json.Marshal(map[string]interface{}{"*": map[string]interface{}{"from": d.from, "to": d.to}})
+	default:
+		return json.Marshal("=")
+	}
+}
+
+// newDiffValue constructs the diff of the value, nil refers to non-existence.
+func newDiffValue(from, to interface{}, equal bool) DiffValue {
+	switch {
+	case from == nil:
+		return DiffValue{kind: "+", to: to}
+	case to == nil:
+		return DiffValue{kind: "-", from: from}
+	case equal:
+		return DiffValue{kind: "="}
+	default:
+		return DiffValue{kind: "*", from: from, to: to}
+	}
+}
+
+// StateDiff is the Parity-style state changes of an account.
+type StateDiff struct {
+	Balance DiffValue                 `json:"balance"`
+	Code    DiffValue                 `json:"code"`
+	Nonce   DiffValue                 `json:"nonce"`
+	Storage map[common.Hash]DiffValue `json:"storage"`
+}
+
+// prestateAccount is the account representation of prestate tracer.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// newStateDiff converts the diff-mode output of prestate tracer into the
+// Parity-style state diff.
+//
+// In the diff mode, the prestate tracer reports the full pre-state of the
+// modified accounts (with the storage slots limited to the changed ones) and
+// only the changed fields in the post-state. The accounts that are created
+// are absent in the pre-state, while the destroyed ones are absent in the
+// post-state.
+func newStateDiff(blob json.RawMessage) (map[common.Address]*StateDiff, error) {
+	var res struct {
+		Pre  map[common.Address]*prestateAccount `json:"pre"`
+		Post map[common.Address]*prestateAccount `json:"post"`
+	}
+	if err := json.Unmarshal(blob, &res); err != nil {
+		return nil, err
+	}
+	diffs := make(map[common.Address]*StateDiff)
+	for addr, pre := range res.Pre {
+		post, ok := res.Post[addr]
+		if ok && pre.empty() {
+			// The account didn't exist before, report it as created.
+			continue
+		}
+		if !ok {
+			// The account is destroyed
+			diff := &StateDiff{
+				Balance: newDiffValue(balanceOf(pre), nil, false),
+				Code:    newDiffValue(pre.Code, nil, false),
+				Nonce:   newDiffValue(hexutil.Uint64(pre.Nonce), nil, false),
+				Storage: make(map[common.Hash]DiffValue),
+			}
+			for key, val := range pre.Storage {
+				if val != (common.Hash{}) {
+					diff.Storage[key] = newDiffValue(val, nil, false)
+				}
+			}
+			diffs[addr] = diff
+			continue
+		}
+		// The account is modified, the unchanged fields are absent in post.
+		diff := &StateDiff{
+			Balance: DiffValue{kind: "="},
+			Code:    DiffValue{kind: "="},
+			Nonce:   DiffValue{kind: "="},
+			Storage: make(map[common.Hash]DiffValue),
+		}
+		if post.Balance != nil {
+			diff.Balance = newDiffValue(balanceOf(pre), post.Balance, false)
+		}
+		if post.Code != nil && !bytes.Equal(post.Code, pre.Code) {
+			diff.Code = newDiffValue(pre.Code, post.Code, false)
+		}
+		if post.Nonce != 0 && post.Nonce != pre.Nonce {
+			diff.Nonce = newDiffValue(hexutil.Uint64(pre.Nonce), hexutil.Uint64(post.Nonce), false)
+		}
+		for key, val := range pre.Storage {
+			diff.Storage[key] = newDiffValue(val, post.Storage[key], false)
+		}
+		// The slots set from empty are absent in pre.
+		for key, val := range post.Storage {
+			if _, ok := pre.Storage[key]; !ok {
+				diff.Storage[key] = newDiffValue(common.Hash{}, val, false)
+			}
+		}
+		diffs[addr] = diff
+	}
+	for addr, post := range res.Post {
+		if pre, ok := res.Pre[addr]; ok && !pre.empty() {
+			continue
+		}
+		// The account is created
+		diff := &StateDiff{
+			Balance: newDiffValue(nil, balanceOf(post), false),
+			Code:    newDiffValue(nil, hexutil.Bytes(post.Code), false),
+			Nonce:   newDiffValue(nil, hexutil.Uint64(post.Nonce), false),
+			Storage: make(map[common.Hash]DiffValue),
+		}
+		if post.Code == nil {
+			diff.Code = newDiffValue(nil, hexutil.Bytes{}, false)
+		}
+		for key, val := range post.Storage {
+			diff.Storage[key] = newDiffValue(nil, val, false)
+		}
+		diffs[addr] = diff
+	}
+	return diffs, nil
+}
+
+// empty returns whether the account is non-existent before the execution.
+func (acct *prestateAccount) empty() bool {
+	return acct.Nonce == 0 && len(acct.Code) == 0 && len(acct.Storage) == 0 && balanceOf(acct).ToInt().Sign() == 0
+}
+
+// balanceOf returns the balance of the account, treating the absent one as zero.
+func balanceOf(acct *prestateAccount) *hexutil.Big {
+	if acct.Balance == nil {
+		return new(hexutil.Big)
+	}
+	return acct.Balance
+}
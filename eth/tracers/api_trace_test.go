@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestReplayTraceConfig(t *testing.T) {
+	if _, err := replayTraceConfig(nil); err == nil {
+		t.Fatal("Expected error for empty trace types")
+	}
+	if _, err := replayTraceConfig([]string{"trace", "unknown"}); err == nil {
+		t.Fatal("Expected error for unsupported trace type")
+	}
+	config, err := replayTraceConfig([]string{"trace", "stateDiff", "vmTrace"})
+	if err != nil {
+		t.Fatalf("Failed to create trace config: %v", err)
+	}
+	if *config.Tracer != "muxTracer" {
+		t.Fatalf("Unexpected tracer %s", *config.Tracer)
+	}
+	want := `{"flatCallTracer":{"convertParityErrors":true},"prestateTracer":{"diffMode":true},"vmTracer":{}}`
+	if string(config.TracerConfig) != want {
+		t.Fatalf("Unexpected tracer config\n have: %s\n want: %s", config.TracerConfig, want)
+	}
+}
+
+func TestTraceResults(t *testing.T) {
+	var (
+		flat     = `[{"action":{"callType":"call","from":"0x00000000000000000000000000000000000000aa","gas":"0x0","input":"0x","to":"0x00000000000000000000000000000000000000bb","value":"0x0"},"result":{"gasUsed":"0x0","output":"0x01"},"subtraces":0,"traceAddress":[],"type":"call"}]`
+		prestate = `{"pre":{` +
+			`"0x00000000000000000000000000000000000000aa":{"balance":"0x10","nonce":1},` +
+			`"0x00000000000000000000000000000000000000bb":{"balance":"0x0","code":"0x00","nonce":1,"storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000001"}},` +
+			`"0x00000000000000000000000000000000000000cc":{"balance":"0x0"},` +
+			`"0x00000000000000000000000000000000000000dd":{"balance":"0x1","nonce":1}},` +
+			`"post":{` +
+			`"0x00000000000000000000000000000000000000aa":{"balance":"0x8","nonce":2},` +
+			`"0x00000000000000000000000000000000000000bb":{"storage":{"0x0000000000000000000000000000000000000000000000000000000000000002":"0x0000000000000000000000000000000000000000000000000000000000000003"}},` +
+			`"0x00000000000000000000000000000000000000cc":{"balance":"0x2"}}}`
+		blob = json.RawMessage(`{"flatCallTracer":` + flat + `,"prestateTracer":` + prestate + `}`)
+	)
+	res, err := newTraceResults(blob, []string{"stateDiff"})
+	if err != nil {
+		t.Fatalf("Failed to assemble trace results: %v", err)
+	}
+	if res.Trace != nil || res.VmTrace != nil {
+		t.Fatal("Unexpected traces of the unrequested types")
+	}
+	have, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("Failed to marshal trace results: %v", err)
+	}
+	want := `{"output":"0x01","stateDiff":{` +
+		`"0x00000000000000000000000000000000000000aa":{"balance":{"*":{"from":"0x10","to":"0x8"}},"code":"=","nonce":{"*":{"from":"0x1","to":"0x2"}},"storage":{}},` +
+		`"0x00000000000000000000000000000000000000bb":{"balance":"=","code":"=","nonce":"=","storage":{` +
+		`"0x0000000000000000000000000000000000000000000000000000000000000001":{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000000000000000000000000000000"}},` +
+		`"0x0000000000000000000000000000000000000000000000000000000000000002":{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000000000000000000000000000003"}}}},` +
+		`"0x00000000000000000000000000000000000000cc":{"balance":{"+":"0x2"},"code":{"+":"0x"},"nonce":{"+":"0x0"},"storage":{}},` +
+		`"0x00000000000000000000000000000000000000dd":{"balance":{"-":"0x1"},"code":{"-":"0x"},"nonce":{"-":"0x1"},"storage":{}}},` +
+		`"trace":null,"vmTrace":null}`
+	if string(have) != want {
+		t.Fatalf("Unexpected trace results\n have: %s\n want: %s", have, want)
+	}
+}
+
+func TestBlockFilter(t *testing.T) {
+	var (
+		db = rawdb.NewMemoryDatabase()
+		a  = common.HexToAddress("0xaa")
+		b  = common.HexToAddress("0xbb")
+		c  = common.HexToAddress("0xcc")
+	)
+	// Blocks [10, 20] are indexed, a and b are involved in 12, a and c are
+	// involved in 15.
+	rawdb.WriteCallAddressIndexRange(db, 10, 20)
+	rawdb.WriteCallAddressIndex(db, a, 12)
+	rawdb.WriteCallAddressIndex(db, b, 12)
+	rawdb.WriteCallAddressIndex(db, a, 15)
+	rawdb.WriteCallAddressIndex(db, c, 15)
+
+	for i, tt := range []struct {
+		senders    []common.Address
+		recipients []common.Address
+		want       []uint64
+	}{
+		{nil, nil, []uint64{8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22}},
+		{[]common.Address{a}, nil, []uint64{8, 9, 12, 15, 21, 22}},
+		{nil, []common.Address{b, c}, []uint64{8, 9, 12, 15, 21, 22}},
+		{[]common.Address{a}, []common.Address{c}, []uint64{8, 9, 15, 21, 22}},
+		{[]common.Address{b}, []common.Address{c}, []uint64{8, 9, 21, 22}},
+	} {
+		var (
+			filter = newBlockFilter(db, 8, 22, tt.senders, tt.recipients)
+			have   []uint64
+		)
+		for number := uint64(8); number <= 22; number++ {
+			if filter.include(number) {
+				have = append(have, number)
+			}
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: unexpected blocks, have %v want %v", i, have, tt.want)
+		}
+	}
+}
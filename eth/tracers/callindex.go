@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// blockFilter decides which blocks need to be traced for matching the address
+// filters of trace_filter.
+type blockFilter struct {
+	first, last uint64              // Range of blocks covered by the index
+	indexed     bool                // Flag whether the index is usable
+	candidates  map[uint64]struct{} // Indexed blocks which might have matching traces
+}
+
+// newBlockFilter constructs the block filter for the range [from, to] based on
+// the given call address index, nil if the index is not maintained. The blocks which are not covered by the index always
+// need to be traced.
+//
+// A call trace matches only if both its sender and recipient match, so the block
+// is a candidate if it involves any of the senders as well as any of the
+// recipients requested.
+func newBlockFilter(index ethdb.KeyValueStore, from uint64, to uint64, senders []common.Address, recipients []common.Address) *blockFilter {
+	if index == nil || (len(senders) == 0 && len(recipients) == 0) {
+		return &blockFilter{}
+	}
+	first, last, ok := rawdb.ReadCallAddressIndexRange(index)
+	if !ok || last < from || first > to {
+		return &blockFilter{}
+	}
+	from, to = max(from, first), min(to, last)
+
+	lookup := func(addrs []common.Address) map[uint64]struct{} {
+		if len(addrs) == 0 {
+			return nil
+		}
+		numbers := make(map[uint64]struct{})
+		for _, addr := range addrs {
+			for _, number := range rawdb.ReadCallAddressBlocks(index, addr, from, to) {
+				numbers[number] = struct{}{}
+			}
+		}
+		return numbers
+	}
+	candidates := lookup(senders)
+	if matches := lookup(recipients); candidates == nil {
+		candidates = matches
+	} else if matches != nil {
+		for number := range candidates {
+			if _, ok := matches[number]; !ok {
+				delete(candidates, number)
+			}
+		}
+	}
+	return &blockFilter{
+		first:      first,
+		last:       last,
+		indexed:    true,
+		candidates: candidates,
+	}
+}
+
+// include returns whether the block with the given number needs to be traced.
+func (f *blockFilter) include(number uint64) bool {
+	if !f.indexed || number < f.first || number > f.last {
+		return true
+	}
+	_, ok := f.candidates[number]
+	return ok
+}
@@ -0,0 +1,457 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultChainTraceBlocksPerFile is the number of blocks whose traces are
+	// stored in a single file by default when a chain is traced into files.
+	defaultChainTraceBlocksPerFile = uint64(10000)
+
+	// chainTraceCheckpointInterval is the time interval between two checkpoints
+	// of the chain tracing progress.
+	chainTraceCheckpointInterval = 5 * time.Second
+
+	// chainTraceCheckpointFile is the name of the file in the trace directory
+	// that tracks the progress of chain tracing.
+	chainTraceCheckpointFile = "checkpoint.json"
+
+	// defaultChainTraceDir is the name of the base directory of the chain traces
+	// in the system temporary directory, used if the node doesn't configure one.
+	defaultChainTraceDir = "geth-chaintrace"
+)
+
+var (
+	errChainTraceRunning    = errors.New("chain tracing is already running in the directory")
+	errChainTraceNotRunning = errors.New("chain tracing is not running in the directory")
+	errChainTraceStopped    = errors.New("chain tracing is terminated")
+)
+
+// ChainTraceFileConfig holds extra parameters to trace a chain segment into files.
+type ChainTraceFileConfig struct {
+	TraceConfig
+	Dir           string  // Directory for the trace files relative to the base directory, a temporary one is created if empty
+	BlocksPerFile *uint64 // Number of blocks whose traces are stored in a single file
+}
+
+// ChainTraceStatus is the progress report of a chain segment traced into files.
+type ChainTraceStatus struct {
+	Dir     string         `json:"dir"`             // Directory of the trace files relative to the base directory
+	Start   hexutil.Uint64 `json:"start"`           // Start block of the segment (excluded)
+	End     hexutil.Uint64 `json:"end"`             // End block of the segment (included)
+	Traced  hexutil.Uint64 `json:"traced"`          // Last block whose traces are persisted
+	Running bool           `json:"running"`         // Whether the tracing is still in progress
+	Error   string         `json:"error,omitempty"` // Failure that terminated the tracing
+}
+
+// chainTraceCheckpoint is the progress marker of a chain segment traced into
+// files, persisted in the trace directory to allow resuming the tracing after a
+// disconnection or a node restart.
+type chainTraceCheckpoint struct {
+	Start         uint64       `json:"start"`           // Start block of the segment (excluded)
+	End           uint64       `json:"end"`             // End block of the segment (included)
+	BlocksPerFile uint64       `json:"blocksPerFile"`   // Number of blocks stored in a single file
+	Config        *TraceConfig `json:"config"`          // Trace config to use for the entire segment
+	Number        uint64       `json:"number"`          // Last block whose traces are persisted
+	File          string       `json:"file"`            // Trace file being written
+	Offset        int64        `json:"offset"`          // Size of the trace file at the checkpoint
+	Error         string       `json:"error,omitempty"` // Failure that terminated the tracing
+}
+
+// readChainTraceCheckpoint loads the tracing checkpoint from the given directory,
+// nil is returned if the directory doesn't have one.
+func readChainTraceCheckpoint(dir string) (*chainTraceCheckpoint, error) {
+	blob, err := os.ReadFile(filepath.Join(dir, chainTraceCheckpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp chainTraceCheckpoint
+	if err := json.Unmarshal(blob, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// writeChainTraceCheckpoint atomically stores the tracing checkpoint into the
+// given directory.
+func writeChainTraceCheckpoint(dir string, cp *chainTraceCheckpoint) error {
+	blob, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, chainTraceCheckpointFile)
+	if err := os.WriteFile(path+".tmp", blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// status converts the checkpoint into the progress report.
+func (cp *chainTraceCheckpoint) status(dir string, running bool) *ChainTraceStatus {
+	return &ChainTraceStatus{
+		Dir:     dir,
+		Start:   hexutil.Uint64(cp.Start),
+		End:     hexutil.Uint64(cp.End),
+		Traced:  hexutil.Uint64(cp.Number),
+		Running: running,
+		Error:   cp.Error,
+	}
+}
+
+// chainTraceJob is a chain segment being traced into files in the background.
+type chainTraceJob struct {
+	dir       string        // Absolute path of the trace directory
+	closed    chan error    // Channel to abort the tracing
+	closeOnce sync.Once     // Ensures the abort channel is only closed once
+	done      chan struct{} // Channel closed when the tracing terminates
+	lock      sync.Mutex
+	cp        chainTraceCheckpoint // The last persisted checkpoint
+}
+
+// TraceChainToFile traces the chain segment between two blocks (excluding start)
+// in the background and writes the results into a rotating set of files in the
+// configured directory, one JSON-encoded block trace result per line. The
+// directory is resolved within the base directory configured by the node.
+//
+// The tracing progress is checkpointed in the directory. If the directory holds
+// the checkpoint of an unfinished tracing of the same segment, e.g. because of a
+// node restart, the tracing is resumed from the last persisted block with the
+// original trace config.
+func (api *API) TraceChainToFile(ctx context.Context, start, end rpc.BlockNumber, config *ChainTraceFileConfig) (*ChainTraceStatus, error) {
+	from, err := api.blockByNumber(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.blockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if from.Number().Cmp(to.Number()) >= 0 {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", end, start)
+	}
+	if config == nil {
+		config = &ChainTraceFileConfig{}
+	}
+	name := config.Dir
+	if name == "" {
+		if err := os.MkdirAll(api.chainDir, 0755); err != nil {
+			return nil, err
+		}
+		dir, err := os.MkdirTemp(api.chainDir, "chaintrace-")
+		if err != nil {
+			return nil, err
+		}
+		name = filepath.Base(dir)
+	}
+	dir, err := api.chainTraceDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	api.chainLock.Lock()
+	defer api.chainLock.Unlock()
+
+	if api.chainStop {
+		return nil, errChainTraceStopped
+	}
+	if _, ok := api.chainJobs[dir]; ok {
+		return nil, errChainTraceRunning
+	}
+	cp, err := readChainTraceCheckpoint(dir)
+	if err != nil {
+		return nil, err
+	}
+	if cp != nil {
+		if cp.Start != from.NumberU64() || cp.End != to.NumberU64() {
+			return nil, fmt.Errorf("directory contains the traces of another segment [#%d, #%d]", cp.Start, cp.End)
+		}
+		if cp.Number >= cp.End {
+			return cp.status(name, false), nil
+		}
+		cp.Error = ""
+		log.Info("Resuming chain tracing", "dir", dir, "start", cp.Start, "end", cp.End, "traced", cp.Number)
+	} else {
+		cp = &chainTraceCheckpoint{
+			Start:         from.NumberU64(),
+			End:           to.NumberU64(),
+			BlocksPerFile: defaultChainTraceBlocksPerFile,
+			Config:        &config.TraceConfig,
+			Number:        from.NumberU64(),
+		}
+		if config.BlocksPerFile != nil && *config.BlocksPerFile > 0 {
+			cp.BlocksPerFile = *config.BlocksPerFile
+		}
+		if err := writeChainTraceCheckpoint(dir, cp); err != nil {
+			return nil, err
+		}
+	}
+	// Resume the tracing from the last persisted block, which is excluded
+	// from the tracing range.
+	if cp.Number != from.NumberU64() {
+		if from, err = api.blockByNumber(ctx, rpc.BlockNumber(cp.Number)); err != nil {
+			return nil, err
+		}
+	}
+	job := &chainTraceJob{
+		dir:    dir,
+		closed: make(chan error),
+		done:   make(chan struct{}),
+		cp:     *cp,
+	}
+	api.chainJobs[dir] = job
+
+	status := job.cp.status(name, true)
+	resCh := api.traceChain(from, to, cp.Config, job.closed)
+	go func() {
+		job.run(resCh)
+
+		api.chainLock.Lock()
+		delete(api.chainJobs, dir)
+		api.chainLock.Unlock()
+		close(job.done)
+	}()
+	return status, nil
+}
+
+// TraceChainStatus returns the progress of the chain segment traced into the
+// given directory.
+func (api *API) TraceChainStatus(name string) (*ChainTraceStatus, error) {
+	dir, err := api.chainTraceDir(name)
+	if err != nil {
+		return nil, err
+	}
+	api.chainLock.Lock()
+	job, ok := api.chainJobs[dir]
+	api.chainLock.Unlock()
+
+	if ok {
+		job.lock.Lock()
+		defer job.lock.Unlock()
+		return job.cp.status(name, true), nil
+	}
+	cp, err := readChainTraceCheckpoint(dir)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil {
+		return nil, fmt.Errorf("no chain tracing found in %s", name)
+	}
+	return cp.status(name, false), nil
+}
+
+// StopTraceChain aborts the chain segment being traced into the given directory.
+// The tracing can be resumed later by TraceChainToFile with the same segment.
+func (api *API) StopTraceChain(name string) (*ChainTraceStatus, error) {
+	dir, err := api.chainTraceDir(name)
+	if err != nil {
+		return nil, err
+	}
+	api.chainLock.Lock()
+	job, ok := api.chainJobs[dir]
+	api.chainLock.Unlock()
+	if !ok {
+		return nil, errChainTraceNotRunning
+	}
+	job.stop()
+
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	return job.cp.status(name, false), nil
+}
+
+// chainTraceDir resolves the trace directory of the given name within the base
+// directory. Absolute paths and paths escaping the base directory are rejected,
+// as the name is supplied by the remote caller.
+func (api *API) chainTraceDir(name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid trace directory %q, must be a relative path", name)
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(name), "/") {
+		if elem == ".." {
+			return "", fmt.Errorf("invalid trace directory %q, must not contain ..", name)
+		}
+	}
+	return filepath.Join(api.chainDir, name), nil
+}
+
+// chainTraceService is the node lifecycle which terminates the chain segments
+// traced into files at shutdown, before the chain they read is closed. The
+// tracing can be resumed after restart from the last checkpoint.
+type chainTraceService struct {
+	api *API
+}
+
+// Start implements node.Lifecycle, starting no background process.
+func (s *chainTraceService) Start() error {
+	return nil
+}
+
+// Stop implements node.Lifecycle, aborting all the running chain tracing and
+// waiting until they terminate. No further tracing is accepted afterwards.
+func (s *chainTraceService) Stop() error {
+	s.api.chainLock.Lock()
+	s.api.chainStop = true
+	jobs := make([]*chainTraceJob, 0, len(s.api.chainJobs))
+	for _, job := range s.api.chainJobs {
+		jobs = append(jobs, job)
+	}
+	s.api.chainLock.Unlock()
+
+	for _, job := range jobs {
+		job.stop()
+	}
+	return nil
+}
+
+// abort signals the tracer to stop tracing.
+func (job *chainTraceJob) abort() {
+	job.closeOnce.Do(func() { close(job.closed) })
+}
+
+// stop aborts the tracing and waits until it terminates.
+func (job *chainTraceJob) stop() {
+	job.abort()
+	<-job.done
+}
+
+// run writes the trace results into files until the result channel is closed.
+// The traces persisted beyond the last checkpoint (e.g. due to a crash) are
+// discarded, as they will be produced again after resuming.
+func (job *chainTraceJob) run(resCh chan *blockTraceResult) {
+	var (
+		file    *os.File
+		offset  int64
+		cp      = job.cp
+		updated = time.Now()
+		failed  error
+		aborted bool
+	)
+	// checkpoint flushes the trace file and persists the current progress.
+	checkpoint := func() error {
+		if file != nil {
+			if err := file.Sync(); err != nil {
+				return err
+			}
+		}
+		if err := writeChainTraceCheckpoint(job.dir, &cp); err != nil {
+			return err
+		}
+		job.lock.Lock()
+		job.cp = cp
+		job.lock.Unlock()
+
+		updated = time.Now()
+		return nil
+	}
+	// Truncate the trace file being written at the last checkpoint
+	if cp.File != "" {
+		var err error
+		file, err = os.OpenFile(filepath.Join(job.dir, cp.File), os.O_CREATE|os.O_WRONLY, 0644)
+		if err == nil {
+			err = file.Truncate(cp.Offset)
+		}
+		if err == nil {
+			_, err = file.Seek(cp.Offset, 0)
+		}
+		failed = err
+		offset = cp.Offset
+	}
+	for res := range resCh {
+		// Keep draining the result channel after the failure or abortion,
+		// the tracer will be blocked otherwise.
+		if failed != nil || aborted {
+			job.abort()
+			continue
+		}
+		select {
+		case <-job.closed:
+			aborted = true
+			continue
+		default:
+		}
+		// Rotate the trace file if the block belongs to the next one
+		number := uint64(res.Block)
+		if name := chainTraceFileName(number, cp.BlocksPerFile); name != cp.File {
+			if file != nil {
+				if failed = file.Sync(); failed != nil {
+					continue
+				}
+				file.Close()
+			}
+			if file, failed = os.Create(filepath.Join(job.dir, name)); failed != nil {
+				continue
+			}
+			cp.File, offset = name, 0
+		}
+		blob, err := json.Marshal(res)
+		if err != nil {
+			failed = err
+			continue
+		}
+		n, err := file.Write(append(blob, '\n'))
+		if err != nil {
+			failed = err
+			continue
+		}
+		offset += int64(n)
+		cp.Number, cp.Offset = number, offset
+
+		if time.Since(updated) > chainTraceCheckpointInterval {
+			failed = checkpoint()
+		}
+	}
+	if failed == nil && !aborted && cp.Number < cp.End {
+		failed = fmt.Errorf("chain tracing aborted at block #%d", cp.Number)
+	}
+	if failed != nil {
+		cp.Error = failed.Error()
+		log.Warn("Chain tracing into files failed", "dir", job.dir, "traced", cp.Number, "err", failed)
+	}
+	if err := checkpoint(); err != nil {
+		log.Error("Failed to persist chain tracing checkpoint", "dir", job.dir, "err", err)
+	}
+	if file != nil {
+		file.Close()
+	}
+}
+
+// chainTraceFileName returns the name of the trace file that stores the traces
+// of the specified block.
+func chainTraceFileName(number uint64, blocksPerFile uint64) string {
+	first := (number-1)/blocksPerFile*blocksPerFile + 1
+	return fmt.Sprintf("traces-%d-%d.jsonl", first, first+blocksPerFile-1)
+}
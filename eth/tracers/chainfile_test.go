@@ -0,0 +1,217 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// waitChainTrace waits until the chain tracing into the directory terminates.
+func waitChainTrace(api *API, dir string) {
+	api.chainLock.Lock()
+	job, ok := api.chainJobs[dir]
+	api.chainLock.Unlock()
+	if ok {
+		<-job.done
+	}
+}
+
+// readChainTraceFiles reads all the trace files in the directory.
+func readChainTraceFiles(t *testing.T, dir string) map[string][]byte {
+	files, err := filepath.Glob(filepath.Join(dir, "traces-*.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to list trace files: %v", err)
+	}
+	contents := make(map[string][]byte)
+	for _, file := range files {
+		blob, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read trace file: %v", err)
+		}
+		contents[filepath.Base(file)] = blob
+	}
+	return contents
+}
+
+func TestTraceChainToFile(t *testing.T) {
+	// Initialize test accounts
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	backend := newTestBackend(t, 50, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		nonce += 1
+	})
+	defer backend.teardown()
+	api := newAPI(backend, &APIConfig{ChainTraceDir: t.TempDir()})
+
+	var (
+		dir           = filepath.Join(api.chainDir, "segment")
+		blocksPerFile = uint64(10)
+		config        = &ChainTraceFileConfig{Dir: "segment", BlocksPerFile: &blocksPerFile}
+	)
+	// Directories outside of the base directory are rejected
+	for _, invalid := range []string{dir, "../segment", "segment/../../segment"} {
+		if _, err := api.TraceChainToFile(context.Background(), 5, 50, &ChainTraceFileConfig{Dir: invalid}); err == nil {
+			t.Fatalf("Expected error for trace directory %q", invalid)
+		}
+	}
+	if _, err := api.TraceChainToFile(context.Background(), 5, 50, config); err != nil {
+		t.Fatalf("Failed to trace chain: %v", err)
+	}
+	waitChainTrace(api, dir)
+
+	status, err := api.TraceChainStatus("segment")
+	if err != nil {
+		t.Fatalf("Failed to retrieve status: %v", err)
+	}
+	if status.Running || status.Error != "" || status.Traced != 50 {
+		t.Fatalf("Unexpected tracing status: %+v", status)
+	}
+	// Blocks [6, 50] are traced, split into files by every 10 blocks
+	files := readChainTraceFiles(t, dir)
+	if len(files) != 5 {
+		t.Fatalf("Unexpected trace file count, want 5, got %d", len(files))
+	}
+	next := uint64(6)
+	for _, name := range []string{"traces-1-10.jsonl", "traces-11-20.jsonl", "traces-21-30.jsonl", "traces-31-40.jsonl", "traces-41-50.jsonl"} {
+		for _, line := range bytes.Split(bytes.TrimSpace(files[name]), []byte("\n")) {
+			var result blockTraceResult
+			if err := json.Unmarshal(line, &result); err != nil {
+				t.Fatalf("Failed to decode trace result: %v", err)
+			}
+			if uint64(result.Block) != next {
+				t.Fatalf("Unexpected block in %s, want %d, got %d", name, next, result.Block)
+			}
+			if len(result.Traces) != 1 {
+				t.Fatalf("Unexpected trace count in block %d, want 1, got %d", next, len(result.Traces))
+			}
+			next++
+		}
+	}
+	if next != 51 {
+		t.Fatalf("Missing traced blocks, traced up to %d", next-1)
+	}
+	// Rewind the checkpoint to the middle of a file and append some junk to
+	// simulate a crash, the tracing should be resumed from the checkpoint.
+	cp, err := readChainTraceCheckpoint(dir)
+	if err != nil {
+		t.Fatalf("Failed to read checkpoint: %v", err)
+	}
+	content := files["traces-21-30.jsonl"]
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	cp.Number, cp.File, cp.Offset = 25, "traces-21-30.jsonl", int64(len(bytes.Join(lines[:5], nil)))
+	if err := writeChainTraceCheckpoint(dir, cp); err != nil {
+		t.Fatalf("Failed to write checkpoint: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, cp.File), append(content, []byte("junk")...), 0644); err != nil {
+		t.Fatalf("Failed to corrupt trace file: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "traces-41-50.jsonl")); err != nil {
+		t.Fatalf("Failed to remove trace file: %v", err)
+	}
+	if _, err := api.TraceChainToFile(context.Background(), 0, 50, config); err == nil {
+		t.Fatal("Expected error for mismatched segment")
+	}
+	if _, err := api.TraceChainToFile(context.Background(), 5, 50, config); err != nil {
+		t.Fatalf("Failed to resume tracing: %v", err)
+	}
+	waitChainTrace(api, dir)
+
+	resumed := readChainTraceFiles(t, dir)
+	if len(resumed) != len(files) {
+		t.Fatalf("Unexpected trace file count, want %d, got %d", len(files), len(resumed))
+	}
+	for name, content := range files {
+		if !bytes.Equal(resumed[name], content) {
+			t.Fatalf("Trace file %s mismatched after resuming", name)
+		}
+	}
+	if status, _ := api.TraceChainStatus("segment"); status.Traced != 50 || status.Error != "" {
+		t.Fatalf("Unexpected tracing status: %+v", status)
+	}
+}
+
+func TestTraceChainShutdown(t *testing.T) {
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	backend := newTestBackend(t, 50, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		nonce += 1
+	})
+	defer backend.teardown()
+	api := newAPI(backend, &APIConfig{ChainTraceDir: t.TempDir()})
+
+	for _, name := range []string{"first", "second"} {
+		if _, err := api.TraceChainToFile(context.Background(), 0, 50, &ChainTraceFileConfig{Dir: name}); err != nil {
+			t.Fatalf("Failed to trace chain: %v", err)
+		}
+	}
+	// Stopping the service must terminate all the tracing before returning,
+	// leaving resumable checkpoints behind.
+	service := &chainTraceService{api: api}
+	if err := service.Stop(); err != nil {
+		t.Fatalf("Failed to stop service: %v", err)
+	}
+	api.chainLock.Lock()
+	running := len(api.chainJobs)
+	api.chainLock.Unlock()
+	if running != 0 {
+		t.Fatalf("Unexpected running chain tracing: %d", running)
+	}
+	for _, name := range []string{"first", "second"} {
+		status, err := api.TraceChainStatus(name)
+		if err != nil {
+			t.Fatalf("Failed to retrieve status: %v", err)
+		}
+		if status.Running {
+			t.Fatalf("Chain tracing %s is still running", name)
+		}
+	}
+	if _, err := api.TraceChainToFile(context.Background(), 0, 50, &ChainTraceFileConfig{Dir: "first"}); err != errChainTraceStopped {
+		t.Fatalf("Unexpected error after shutdown, want %v, got %v", errChainTraceStopped, err)
+	}
+}
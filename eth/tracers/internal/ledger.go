@@ -0,0 +1,281 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package internal
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// transferTopic is the event signature of the ERC-20 and ERC-721 transfers.
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// transferSingleTopic is the event signature of the ERC-1155 single transfer.
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+
+	// transferBatchTopic is the event signature of the ERC-1155 batch transfer.
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// Kinds of the assets moved by the ledger entries.
+const (
+	LedgerKindEther   = "eth"
+	LedgerKindERC20   = "erc20"
+	LedgerKindERC721  = "erc721"
+	LedgerKindERC1155 = "erc1155"
+)
+
+// Reasons of the ether movements.
+const (
+	LedgerReasonCall         = "call"         // Value transferred by a call or creation
+	LedgerReasonSelfdestruct = "selfdestruct" // Balance sent to the beneficiary of a self-destruct
+	LedgerReasonWithdrawal   = "withdrawal"   // Ether withdrawn from the beacon chain
+	LedgerReasonReward       = "reward"       // Block or uncle reward of the proof-of-work era
+	LedgerReasonFee          = "fee"          // Priority fee paid to the block producer
+	LedgerReasonBurn         = "burn"         // Base and blob fees, or ether sent to a destructed account
+)
+
+// LedgerEntry is a single movement of ether or tokens. The zero address stands
+// for the source of the issued ether and the sink of the burnt ether.
+type LedgerEntry struct {
+	Kind    string          `json:"kind"`
+	Reason  string          `json:"reason,omitempty"` // Cause of the ether movement
+	Token   *common.Address `json:"token,omitempty"`  // Contract of the token
+	From    common.Address  `json:"from"`
+	To      common.Address  `json:"to"`
+	Value   *hexutil.Big    `json:"value,omitempty"`   // Amount of ether or fungible tokens
+	TokenID *hexutil.Big    `json:"tokenId,omitempty"` // Identifier of the non-fungible or multi tokens
+	TxHash  *common.Hash    `json:"txHash,omitempty"`  // Transaction causing the movement, nil for block-level ones
+}
+
+// Ledger collects the movements of ether and standard tokens. It's driven by the
+// tracing hooks, the movements of the reverted call frames are discarded.
+type Ledger struct {
+	Entries []*LedgerEntry
+
+	tx       *common.Hash   // Hash of the transaction being executed
+	txMark   int            // Number of entries before the transaction
+	marks    []int          // Number of entries before each active call frame
+	payer    common.Address // Account buying the gas of the transaction
+	paid     *big.Int       // Ether paid for the gas
+	refunded *big.Int       // Ether refunded for the unused gas
+	coinbase common.Address // Account receiving the priority fee
+	tip      *big.Int       // Priority fee paid to the coinbase
+}
+
+// NewLedger creates an empty ledger.
+func NewLedger() *Ledger {
+	return &Ledger{Entries: []*LedgerEntry{}}
+}
+
+func (l *Ledger) add(entry *LedgerEntry) {
+	entry.TxHash = l.tx
+	l.Entries = append(l.Entries, entry)
+}
+
+func (l *Ledger) addEther(reason string, from common.Address, to common.Address, value *big.Int) {
+	l.add(&LedgerEntry{
+		Kind:   LedgerKindEther,
+		Reason: reason,
+		From:   from,
+		To:     to,
+		Value:  (*hexutil.Big)(new(big.Int).Set(value)),
+	})
+}
+
+// OnTxStart resets the transaction-level tracking.
+func (l *Ledger) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	hash := tx.Hash()
+	l.tx = &hash
+	l.txMark = len(l.Entries)
+	l.marks = l.marks[:0]
+	l.payer = from
+	l.paid = new(big.Int)
+	l.refunded = new(big.Int)
+	l.coinbase = env.Coinbase
+	l.tip = new(big.Int)
+}
+
+// OnTxEnd records the fees of the transaction.
+func (l *Ledger) OnTxEnd(receipt *types.Receipt, err error) {
+	defer func() { l.tx = nil }()
+
+	// The invalid transaction has no effect at all
+	if err != nil {
+		l.Entries = l.Entries[:l.txMark]
+		return
+	}
+	if l.tip.Sign() > 0 {
+		l.addEther(LedgerReasonFee, l.payer, l.coinbase, l.tip)
+	}
+	burnt := new(big.Int).Sub(l.paid, l.refunded)
+	if burnt.Sub(burnt, l.tip).Sign() > 0 {
+		l.addEther(LedgerReasonBurn, l.payer, common.Address{}, burnt)
+	}
+}
+
+// OnEnter records the value transferred into the call frame.
+func (l *Ledger) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	l.marks = append(l.marks, len(l.Entries))
+
+	// Delegate and static calls carry no value, while the value of call-code
+	// is transferred to the caller itself.
+	if value == nil || value.Sign() == 0 || from == to {
+		return
+	}
+	switch vm.OpCode(typ) {
+	case vm.CALL, vm.CREATE, vm.CREATE2:
+		l.addEther(LedgerReasonCall, from, to, value)
+	case vm.SELFDESTRUCT:
+		l.addEther(LedgerReasonSelfdestruct, from, to, value)
+	}
+}
+
+// OnExit discards the movements of the reverted call frame.
+func (l *Ledger) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(l.marks) == 0 {
+		return
+	}
+	mark := l.marks[len(l.marks)-1]
+	l.marks = l.marks[:len(l.marks)-1]
+	if reverted {
+		l.Entries = l.Entries[:mark]
+	}
+}
+
+// OnBalanceChange records the ether movements not caused by the calls.
+func (l *Ledger) OnBalanceChange(addr common.Address, prevBalance, newBalance *big.Int, reason tracing.BalanceChangeReason) {
+	var (
+		increase = new(big.Int).Sub(newBalance, prevBalance)
+		decrease = new(big.Int).Neg(increase)
+	)
+	switch reason {
+	case tracing.BalanceDecreaseGasBuy:
+		if l.paid != nil {
+			l.paid.Add(l.paid, decrease)
+		}
+	case tracing.BalanceIncreaseGasReturn:
+		if l.refunded != nil {
+			l.refunded.Add(l.refunded, increase)
+		}
+	case tracing.BalanceIncreaseRewardTransactionFee:
+		if l.tip != nil {
+			l.coinbase = addr
+			l.tip.Add(l.tip, increase)
+		}
+	case tracing.BalanceIncreaseWithdrawal:
+		l.addEther(LedgerReasonWithdrawal, common.Address{}, addr, increase)
+	case tracing.BalanceIncreaseRewardMineBlock, tracing.BalanceIncreaseRewardMineUncle:
+		l.addEther(LedgerReasonReward, common.Address{}, addr, increase)
+	case tracing.BalanceDecreaseSelfdestructBurn:
+		l.addEther(LedgerReasonBurn, addr, common.Address{}, decrease)
+	}
+}
+
+// OnLog records the token transfers announced by the standard events.
+func (l *Ledger) OnLog(log *types.Log) {
+	if len(log.Topics) == 0 {
+		return
+	}
+	token := log.Address
+	switch {
+	case log.Topics[0] == transferTopic && len(log.Topics) == 3 && len(log.Data) == 32:
+		l.add(&LedgerEntry{
+			Kind:  LedgerKindERC20,
+			Token: &token,
+			From:  common.BytesToAddress(log.Topics[1][:]),
+			To:    common.BytesToAddress(log.Topics[2][:]),
+			Value: (*hexutil.Big)(new(big.Int).SetBytes(log.Data)),
+		})
+	case log.Topics[0] == transferTopic && len(log.Topics) == 4 && len(log.Data) == 0:
+		l.add(&LedgerEntry{
+			Kind:    LedgerKindERC721,
+			Token:   &token,
+			From:    common.BytesToAddress(log.Topics[1][:]),
+			To:      common.BytesToAddress(log.Topics[2][:]),
+			TokenID: (*hexutil.Big)(new(big.Int).SetBytes(log.Topics[3][:])),
+		})
+	case log.Topics[0] == transferSingleTopic && len(log.Topics) == 4 && len(log.Data) == 64:
+		l.add(&LedgerEntry{
+			Kind:    LedgerKindERC1155,
+			Token:   &token,
+			From:    common.BytesToAddress(log.Topics[2][:]),
+			To:      common.BytesToAddress(log.Topics[3][:]),
+			TokenID: (*hexutil.Big)(new(big.Int).SetBytes(log.Data[:32])),
+			Value:   (*hexutil.Big)(new(big.Int).SetBytes(log.Data[32:])),
+		})
+	case log.Topics[0] == transferBatchTopic && len(log.Topics) == 4:
+		ids, values, ok := decodeTransferBatch(log.Data)
+		if !ok {
+			return
+		}
+		for i := range ids {
+			l.add(&LedgerEntry{
+				Kind:    LedgerKindERC1155,
+				Token:   &token,
+				From:    common.BytesToAddress(log.Topics[2][:]),
+				To:      common.BytesToAddress(log.Topics[3][:]),
+				TokenID: (*hexutil.Big)(ids[i]),
+				Value:   (*hexutil.Big)(values[i]),
+			})
+		}
+	}
+}
+
+// decodeTransferBatch decodes the ids and values of the ERC-1155 batch transfer,
+// which are ABI-encoded as two dynamic uint256 arrays.
+func decodeTransferBatch(data []byte) ([]*big.Int, []*big.Int, bool) {
+	word := func(offset uint64) (*big.Int, bool) {
+		if offset+32 < offset || offset+32 > uint64(len(data)) {
+			return nil, false
+		}
+		return new(big.Int).SetBytes(data[offset : offset+32]), true
+	}
+	array := func(head uint64) ([]*big.Int, bool) {
+		offset, ok := word(head)
+		if !ok || !offset.IsUint64() {
+			return nil, false
+		}
+		size, ok := word(offset.Uint64())
+		if !ok || !size.IsUint64() || size.Uint64() > uint64(len(data))/32 {
+			return nil, false
+		}
+		items := make([]*big.Int, size.Uint64())
+		for i := range items {
+			if items[i], ok = word(offset.Uint64() + 32*uint64(i+1)); !ok {
+				return nil, false
+			}
+		}
+		return items, true
+	}
+	ids, ok := array(0)
+	if !ok {
+		return nil, nil, false
+	}
+	values, ok := array(32)
+	if !ok || len(ids) != len(values) {
+		return nil, nil, false
+	}
+	return ids, values, true
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCallIndexTracer(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		caller = common.HexToAddress("0xaaaa")
+		callee = common.HexToAddress("0xbbbb")
+		other  = common.HexToAddress("0xcccc")
+		config = *params.AllEthashProtocolChanges
+		engine = beacon.New(ethash.NewFaker())
+	)
+	// The caller contract calls into the callee with no value and no data
+	code := []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH2), 0xbb, 0xbb, byte(vm.GAS), byte(vm.CALL),
+	}
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			addr:   {Balance: big.NewInt(params.Ether)},
+			caller: {Code: code},
+		},
+	}
+	dir := filepath.ToSlash(t.TempDir())
+	tracer, err := tracers.LiveDirectory.New("callindex", json.RawMessage(fmt.Sprintf(`{"path":"%s"}`, dir)))
+	if err != nil {
+		t.Fatalf("Failed to create callindex tracer: %v", err)
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{Tracer: tracer}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create tester chain: %v", err)
+	}
+	// Call the caller contract in the odd blocks, transfer to the other
+	// account in the even blocks.
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, 4, func(i int, b *core.BlockGen) {
+		to := caller
+		if i%2 == 1 {
+			to = other
+		}
+		tx, _ := types.SignNewTx(key, types.LatestSigner(&config), &types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      100000,
+			GasPrice: b.BaseFee(),
+		})
+		b.AddTx(tx)
+	})
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("Block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	db, err := leveldb.New(dir, 16, 16, "", true)
+	if err != nil {
+		t.Fatalf("Failed to open index database: %v", err)
+	}
+	defer db.Close()
+
+	if first, last, ok := rawdb.ReadCallAddressIndexRange(db); !ok || first != 1 || last != 4 {
+		t.Fatalf("Unexpected indexed range: [%d, %d], ok: %v", first, last, ok)
+	}
+	for _, tt := range []struct {
+		addr common.Address
+		want []uint64
+	}{
+		{addr, []uint64{1, 2, 3, 4}},
+		{caller, []uint64{1, 3}},
+		{callee, []uint64{1, 3}},
+		{other, []uint64{2, 4}},
+	} {
+		if have := rawdb.ReadCallAddressBlocks(db, tt.addr, 0, 4); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("Unexpected indexed blocks of %x, have %v want %v", tt.addr, have, tt.want)
+		}
+	}
+	if have, want := rawdb.ReadCallAddressBlocks(db, caller, 2, 2), []uint64(nil); !reflect.DeepEqual(have, want) {
+		t.Errorf("Unexpected indexed blocks in range, have %v want %v", have, want)
+	}
+}
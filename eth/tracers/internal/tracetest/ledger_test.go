@@ -0,0 +1,149 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestLedgerTracer(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		token    = common.HexToAddress("0xaaaa")
+		receiver = common.HexToAddress("0xbbbb")
+		reverter = common.HexToAddress("0xcccc")
+		coinbase = common.Address{1}
+		config   = *params.MergedTestChainConfig
+		engine   = beacon.New(ethash.NewFaker())
+		topic    = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	)
+	// The token contract announces a transfer of 100 tokens from the caller to
+	// the receiver, sends 1 wei to the receiver and 2 wei to the reverter.
+	code := []byte{byte(vm.PUSH1), 0x64, byte(vm.PUSH1), 0x0, byte(vm.MSTORE)}
+	code = append(code, byte(vm.PUSH2), 0xbb, 0xbb, byte(vm.CALLER), byte(vm.PUSH32))
+	code = append(code, topic.Bytes()...)
+	code = append(code,
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.LOG3),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), 0x1, byte(vm.PUSH2), 0xbb, 0xbb, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), 0x2, byte(vm.PUSH2), 0xcc, 0xcc, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+	)
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			sender:   {Balance: big.NewInt(params.Ether)},
+			token:    {Code: code},
+			reverter: {Code: []byte{byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT)}},
+		},
+	}
+	dir := filepath.ToSlash(t.TempDir())
+	tracer, err := tracers.LiveDirectory.New("ledger", json.RawMessage(fmt.Sprintf(`{"path":"%s"}`, dir)))
+	if err != nil {
+		t.Fatalf("Failed to create ledger tracer: %v", err)
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{Tracer: tracer}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create tester chain: %v", err)
+	}
+	var baseFee *big.Int
+	_, blocks, receipts := core.GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *core.BlockGen) {
+		b.SetPoS()
+		b.SetCoinbase(coinbase)
+		baseFee = b.BaseFee()
+
+		tx, _ := types.SignNewTx(key, types.LatestSigner(&config), &types.LegacyTx{
+			Nonce:    0,
+			To:       &token,
+			Value:    big.NewInt(10),
+			Gas:      100000,
+			GasPrice: new(big.Int).Add(baseFee, big.NewInt(1)),
+		})
+		b.AddTx(tx)
+		b.AddWithdrawal(&types.Withdrawal{Validator: 42, Address: common.Address{0xee}, Amount: 1337})
+	})
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("Block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	blob, err := os.ReadFile(filepath.Join(dir, "ledger.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	var have struct {
+		Number  uint64            `json:"blockNumber"`
+		Hash    common.Hash       `json:"hash"`
+		Entries []json.RawMessage `json:"entries"`
+	}
+	if err := json.Unmarshal(blob, &have); err != nil {
+		t.Fatalf("Failed to unmarshal ledger: %v", err)
+	}
+	if have.Number != 1 || have.Hash != blocks[0].Hash() {
+		t.Fatalf("Unexpected block %d %x", have.Number, have.Hash)
+	}
+	var (
+		txHash  = blocks[0].Transactions()[0].Hash().Hex()
+		gasUsed = new(big.Int).SetUint64(receipts[0][0].GasUsed)
+		burnt   = new(big.Int).Mul(gasUsed, baseFee)
+		entry   = func(kind string, reason string, from, to common.Address, value *big.Int, hash string) string {
+			s := fmt.Sprintf(`{"kind":"%s",`, kind)
+			if reason != "" {
+				s += fmt.Sprintf(`"reason":"%s",`, reason)
+			} else {
+				s += fmt.Sprintf(`"token":"%s",`, strings.ToLower(token.Hex()))
+			}
+			s += fmt.Sprintf(`"from":"%s","to":"%s","value":"0x%x"`, strings.ToLower(from.Hex()), strings.ToLower(to.Hex()), value)
+			if hash != "" {
+				s += fmt.Sprintf(`,"txHash":"%s"`, hash)
+			}
+			return s + "}"
+		}
+		want = []string{
+			entry("eth", "call", sender, token, big.NewInt(10), txHash),
+			entry("erc20", "", sender, receiver, big.NewInt(100), txHash),
+			entry("eth", "call", token, receiver, big.NewInt(1), txHash),
+			entry("eth", "fee", sender, coinbase, gasUsed, txHash),
+			entry("eth", "burn", sender, common.Address{}, burnt, txHash),
+			entry("eth", "withdrawal", common.Address{}, common.Address{0xee}, big.NewInt(1337*params.GWei), ""),
+		}
+	)
+	if len(have.Entries) != len(want) {
+		t.Fatalf("Unexpected entry count, have %d want %d", len(have.Entries), len(want))
+	}
+	for i := range want {
+		if string(have.Entries[i]) != want[i] {
+			t.Errorf("Entry %d mismatch\n have: %s\n want: %s", i, have.Entries[i], want[i])
+		}
+	}
+}
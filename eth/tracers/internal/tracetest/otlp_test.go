@@ -0,0 +1,181 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue"`
+	IntValue    *string `json:"intValue"`
+}
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s *otlpSpan) attr(key string) string {
+	for _, attr := range s.Attributes {
+		if attr.Key != key {
+			continue
+		}
+		if attr.Value.StringValue != nil {
+			return *attr.Value.StringValue
+		}
+		if attr.Value.IntValue != nil {
+			return *attr.Value.IntValue
+		}
+	}
+	return ""
+}
+
+type otlpTraces struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []*otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func TestOTLPTracer(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		reverter = common.HexToAddress("0xbbbb")
+		config   = *params.AllEthashProtocolChanges
+		engine   = beacon.New(ethash.NewFaker())
+	)
+	// The contract reverts with the reason "boom", the revert data is
+	// appended to the code and copied into memory.
+	reason, _ := hex.DecodeString("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"626f6f6d00000000000000000000000000000000000000000000000000000000")
+	code := append([]byte{
+		byte(vm.PUSH1), byte(len(reason)), byte(vm.PUSH1), 0x0c, byte(vm.PUSH1), 0x00, byte(vm.CODECOPY),
+		byte(vm.PUSH1), byte(len(reason)), byte(vm.PUSH1), 0x00, byte(vm.REVERT),
+	}, reason...)
+
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: types.GenesisAlloc{
+			addr:     {Balance: big.NewInt(params.Ether)},
+			reverter: {Code: code},
+		},
+	}
+	dir := filepath.ToSlash(t.TempDir())
+	tracer, err := tracers.LiveDirectory.New("otlp", json.RawMessage(fmt.Sprintf(`{"path":"%s"}`, dir)))
+	if err != nil {
+		t.Fatalf("Failed to create otlp tracer: %v", err)
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{Tracer: tracer}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create tester chain: %v", err)
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, 1, func(i int, b *core.BlockGen) {
+		signer := types.LatestSigner(&config)
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    0,
+			To:       &reverter,
+			Gas:      100000,
+			GasPrice: b.BaseFee(),
+		})
+		b.AddTx(tx)
+	})
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("Block %d: failed to insert into chain: %v", n, err)
+	}
+	chain.Stop()
+
+	file, err := os.Open(filepath.Join(dir, "spans.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to open output file: %v", err)
+	}
+	defer file.Close()
+
+	var spans []*otlpSpan
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var traces otlpTraces
+		if err := json.Unmarshal(scanner.Bytes(), &traces); err != nil {
+			t.Fatalf("Failed to unmarshal spans: %v", err)
+		}
+		for _, rs := range traces.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	if len(spans) != 3 {
+		t.Fatalf("Unexpected span count, want 3, got %d", len(spans))
+	}
+	block, tx, call := spans[0], spans[1], spans[2]
+	if block.Name != "block" || tx.Name != "transaction" || call.Name != "CALL" {
+		t.Fatalf("Unexpected span names: %s, %s, %s", block.Name, tx.Name, call.Name)
+	}
+	if hash := blocks[0].Hash(); block.TraceID != hex.EncodeToString(hash[:16]) || tx.TraceID != block.TraceID || call.TraceID != block.TraceID {
+		t.Fatalf("Unexpected trace id: %s", block.TraceID)
+	}
+	if block.ParentSpanID != "" || tx.ParentSpanID != block.SpanID || call.ParentSpanID != tx.SpanID {
+		t.Fatal("Unexpected span hierarchy")
+	}
+	if have, want := block.attr("block.number"), "1"; have != want {
+		t.Fatalf("Unexpected block number, have %s want %s", have, want)
+	}
+	if have, want := tx.attr("tx.hash"), blocks[0].Transactions()[0].Hash().Hex(); have != want {
+		t.Fatalf("Unexpected transaction hash, have %s want %s", have, want)
+	}
+	if tx.Status.Code != 2 || call.Status.Code != 2 {
+		t.Fatalf("Expected failure status, tx %d call %d", tx.Status.Code, call.Status.Code)
+	}
+	if have, want := call.attr("call.revert_reason"), "boom"; have != want {
+		t.Fatalf("Unexpected revert reason, have %q want %q", have, want)
+	}
+	if have, want := call.attr("call.to"), reverter.Hex(); have != want {
+		t.Fatalf("Unexpected call target, have %s want %s", have, want)
+	}
+}
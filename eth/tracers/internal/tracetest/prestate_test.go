@@ -29,7 +29,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/tests"
 )
 
@@ -137,3 +139,126 @@ func testPrestateDiffTracer(tracerName string, dirPath string, t *testing.T) {
 		})
 	}
 }
+
+func TestPrestateWithDiffModeDetails(t *testing.T) {
+	var (
+		to       = common.HexToAddress("0xaaaa")
+		heir     = common.HexToAddress("0xbbbb")
+		reverter = common.HexToAddress("0xcccc")
+		child    = crypto.CreateAddress(to, 1)
+	)
+	// The contract isn't deleted by self-destruct since EIP-6780, only the
+	// balance is transferred. The transient lock is reported as well, while
+	// the write of the reverted call is dropped.
+	post, pre := tracePrestateDetails(t, `{"diffMode":true,"withTransient":true,"withLifecycle":true}`)
+	for addr, want := range map[common.Address]string{
+		to:    `{"balance":"0x0","nonce":2,"transientStorage":{"0x0000000000000000000000000000000000000000000000000000000000000001":["0x0000000000000000000000000000000000000000000000000000000000000001","0x0000000000000000000000000000000000000000000000000000000000000000"]}}`,
+		heir:  `{"balance":"0x64"}`,
+		child: `{"created":true,"destroyed":true}`,
+	} {
+		if have := string(post[addr]); have != want {
+			t.Errorf("post state mismatch of %x\n have: %s\n want: %s", addr, have, want)
+		}
+	}
+	if _, ok := post[reverter]; ok {
+		t.Error("unexpected post state of the reverted call")
+	}
+	if _, ok := pre[to]; !ok {
+		t.Error("missing pre state of the contract")
+	}
+	if _, ok := pre[child]; ok {
+		t.Error("unexpected pre state of the created contract")
+	}
+	// Without the options, the diff is left as it used to be, the contract
+	// self-destructed is considered as deleted.
+	post, _ = tracePrestateDetails(t, `{"diffMode":true}`)
+	for _, addr := range []common.Address{to, child, reverter} {
+		if have, ok := post[addr]; ok {
+			t.Errorf("unexpected post state of %x: %s", addr, have)
+		}
+	}
+	if have, want := string(post[heir]), `{"balance":"0x64"}`; have != want {
+		t.Errorf("post state mismatch of %x\n have: %s\n want: %s", heir, have, want)
+	}
+}
+
+// tracePrestateDetails runs the prestate tracer with the given config over a
+// transaction exercising the transient storage and the account lifecycle, and
+// returns the post and pre states of the diff.
+func tracePrestateDetails(t *testing.T, cfg string) (map[common.Address]json.RawMessage, map[common.Address]json.RawMessage) {
+	var (
+		config   = params.MergedTestChainConfig
+		signer   = types.LatestSigner(config)
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		origin   = crypto.PubkeyToAddress(key.PublicKey)
+		to       = common.HexToAddress("0xaaaa")
+		reverter = common.HexToAddress("0xcccc")
+		random   = common.Hash{}
+		context  = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: big.NewInt(1),
+			Time:        1,
+			Difficulty:  big.NewInt(0),
+			GasLimit:    uint64(6000000),
+			BaseFee:     big.NewInt(0),
+			BlobBaseFee: big.NewInt(0),
+			Random:      &random,
+		}
+	)
+	// The contract takes a transient lock and releases it, creates a child
+	// which destructs itself, calls a contract which writes the transient
+	// storage and reverts, then self-destructs into the heir.
+	code := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, byte(vm.TSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x1, byte(vm.TSTORE),
+		byte(vm.PUSH3), byte(vm.PUSH1), 0x0, byte(vm.SELFDESTRUCT), byte(vm.PUSH1), 0x0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x3, byte(vm.PUSH1), 29, byte(vm.PUSH1), 0x0, byte(vm.CREATE), byte(vm.POP),
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0,
+		byte(vm.PUSH2), 0xcc, 0xcc, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH2), 0xbb, 0xbb, byte(vm.SELFDESTRUCT),
+	}
+	revert := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x2, byte(vm.TSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.REVERT),
+	}
+	state := tests.MakePreState(rawdb.NewMemoryDatabase(), types.GenesisAlloc{
+		origin:   {Balance: big.NewInt(params.Ether)},
+		to:       {Balance: big.NewInt(100), Nonce: 1, Code: code},
+		reverter: {Nonce: 1, Code: revert},
+	}, false, rawdb.HashScheme)
+	defer state.Close()
+
+	tracer, err := tracers.DefaultDirectory.New("prestateTracer", new(tracers.Context), json.RawMessage(cfg), config)
+	if err != nil {
+		t.Fatalf("failed to create prestate tracer: %v", err)
+	}
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{To: &to, Gas: 200000, GasPrice: big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	msg, err := core.TransactionToMessage(tx, signer, context.BaseFee)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), state.StateDB, config, vm.Config{Tracer: tracer.Hooks})
+	tracer.OnTxStart(evm.GetVMContext(), tx, msg.From)
+	vmRet, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if err != nil || vmRet.Err != nil {
+		t.Fatalf("failed to execute transaction: %v %v", err, vmRet.Err)
+	}
+	tracer.OnTxEnd(&types.Receipt{GasUsed: vmRet.UsedGas}, nil)
+
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var diff struct {
+		Pre  map[common.Address]json.RawMessage `json:"pre"`
+		Post map[common.Address]json.RawMessage `json:"post"`
+	}
+	if err := json.Unmarshal(res, &diff); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	return diff.Post, diff.Pre
+}
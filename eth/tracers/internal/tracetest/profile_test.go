@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+type profileStat struct {
+	Count uint64 `json:"count"`
+	Gas   uint64 `json:"gas"`
+}
+
+type profileResult struct {
+	Opcodes   map[string]profileStat `json:"opcodes"`
+	Contracts []struct {
+		CodeHash common.Hash `json:"codeHash"`
+		Pc       uint64      `json:"pc"`
+		Op       string      `json:"op"`
+		Gas      uint64      `json:"gas"`
+	} `json:"contracts"`
+	Folded []string `json:"folded"`
+}
+
+func TestOpcodeProfiler(t *testing.T) {
+	var (
+		config  = params.MainnetChainConfig
+		signer  = types.LatestSigner(config)
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		origin  = crypto.PubkeyToAddress(key.PublicKey)
+		caller  = common.HexToAddress("0xaaaa")
+		callee  = common.HexToAddress("0xbbbb")
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+			BaseFee:     new(big.Int),
+		}
+		callerCode = []byte{
+			byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+			byte(vm.PUSH2), 0xbb, 0xbb, byte(vm.GAS), byte(vm.CALL),
+		}
+		calleeCode = []byte{
+			byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE),
+		}
+	)
+	state := tests.MakePreState(rawdb.NewMemoryDatabase(), types.GenesisAlloc{
+		origin: {Balance: big.NewInt(params.Ether)},
+		caller: {Code: callerCode},
+		callee: {Code: calleeCode},
+	}, false, rawdb.HashScheme)
+	defer state.Close()
+
+	tracer, err := tracers.DefaultDirectory.New("opcodeProfiler", new(tracers.Context), nil, config)
+	if err != nil {
+		t.Fatalf("failed to create opcode profiler: %v", err)
+	}
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{To: &caller, Gas: 100000, GasPrice: big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	msg, err := core.TransactionToMessage(tx, signer, context.BaseFee)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), state.StateDB, config, vm.Config{Tracer: tracer.Hooks})
+	tracer.OnTxStart(evm.GetVMContext(), tx, msg.From)
+	vmRet, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if err != nil || vmRet.Err != nil {
+		t.Fatalf("failed to execute transaction: %v %v", err, vmRet.Err)
+	}
+	tracer.OnTxEnd(&types.Receipt{GasUsed: vmRet.UsedGas}, nil)
+
+	blob, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var res profileResult
+	if err := json.Unmarshal(blob, &res); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	// The gas forwarded to the callee is excluded from the call
+	wantOpcodes := map[string]profileStat{
+		"PUSH1":  {Count: 3, Gas: 9},
+		"DUP1":   {Count: 4, Gas: 12},
+		"PUSH2":  {Count: 1, Gas: 3},
+		"GAS":    {Count: 1, Gas: 2},
+		"CALL":   {Count: 1, Gas: 700},
+		"SSTORE": {Count: 1, Gas: 20000},
+		"STOP":   {Count: 2, Gas: 0},
+	}
+	if !reflect.DeepEqual(res.Opcodes, wantOpcodes) {
+		t.Errorf("opcode stats mismatch\n have: %v\n want: %v", res.Opcodes, wantOpcodes)
+	}
+	if top := res.Contracts[0]; top.CodeHash != crypto.Keccak256Hash(calleeCode) || top.Pc != 4 || top.Op != "SSTORE" || top.Gas != 20000 {
+		t.Errorf("unexpected hottest instruction: %+v", top)
+	}
+	var (
+		a          = caller.Hex()
+		b          = a + ";" + callee.Hex()
+		wantFolded = []string{
+			b + ";PUSH1 6", b + ";SSTORE 20000", b + ";STOP 0",
+			a + ";CALL 700", a + ";DUP1 12", a + ";GAS 2", a + ";PUSH1 3", a + ";PUSH2 3", a + ";STOP 0",
+		}
+	)
+	if !reflect.DeepEqual(res.Folded, wantFolded) {
+		t.Errorf("folded stacks mismatch\n have: %v\n want: %v", res.Folded, wantFolded)
+	}
+}
@@ -21,30 +21,51 @@ import (
 	"errors"
 
 	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 type ctorFunc func(config json.RawMessage) (*tracing.Hooks, error)
 
+// indexCtorFunc constructs a live tracer which maintains the call address index,
+// returning the index for trace_filter along with the tracing hooks.
+type indexCtorFunc func(config json.RawMessage) (*tracing.Hooks, ethdb.KeyValueStore, error)
+
 // LiveDirectory is the collection of tracers which can be used
 // during normal block import operations.
-var LiveDirectory = liveDirectory{elems: make(map[string]ctorFunc)}
+var LiveDirectory = liveDirectory{elems: make(map[string]indexCtorFunc)}
 
 type liveDirectory struct {
-	elems map[string]ctorFunc
+	elems map[string]indexCtorFunc
 }
 
 // Register registers a tracer constructor by name.
 func (d *liveDirectory) Register(name string, f ctorFunc) {
+	d.elems[name] = func(config json.RawMessage) (*tracing.Hooks, ethdb.KeyValueStore, error) {
+		hooks, err := f(config)
+		return hooks, nil, err
+	}
+}
+
+// RegisterIndexer registers the constructor of a tracer maintaining the call
+// address index by name.
+func (d *liveDirectory) RegisterIndexer(name string, f indexCtorFunc) {
 	d.elems[name] = f
 }
 
 // New instantiates a tracer by name.
 func (d *liveDirectory) New(name string, config json.RawMessage) (*tracing.Hooks, error) {
+	hooks, _, err := d.NewWithIndex(name, config)
+	return hooks, err
+}
+
+// NewWithIndex instantiates a tracer by name, along with the call address index
+// it maintains. The returned index is nil if the tracer doesn't maintain one.
+func (d *liveDirectory) NewWithIndex(name string, config json.RawMessage) (*tracing.Hooks, ethdb.KeyValueStore, error) {
 	if len(config) == 0 {
 		config = json.RawMessage("{}")
 	}
 	if f, ok := d.elems[name]; ok {
 		return f(config)
 	}
-	return nil, errors.New("not found")
+	return nil, nil, errors.New("not found")
 }
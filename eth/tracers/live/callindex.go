@@ -0,0 +1,127 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func init() {
+	tracers.LiveDirectory.RegisterIndexer("callindex", newCallIndexTracer)
+}
+
+type callIndexTracerConfig struct {
+	Path    string `json:"path"`    // Path to the directory where the index database will be stored
+	Cache   int    `json:"cache"`   // Megabytes of memory allocated to the database, 16 by default
+	Handles int    `json:"handles"` // Number of files the database can open, 16 by default
+}
+
+// callIndexTracer maintains the index of the addresses involved in the call
+// frames of each block, for trace_filter to skip the irrelevant blocks.
+//
+// The index covers a contiguous range of blocks. Blocks of all the forks are
+// indexed as they are processed, so the index may report a block which doesn't
+// involve the address after a reorg, but never misses one within the range.
+type callIndexTracer struct {
+	db     ethdb.KeyValueStore
+	number uint64
+	addrs  map[common.Address]struct{} // Addresses involved in the current block
+}
+
+func newCallIndexTracer(cfg json.RawMessage) (*tracing.Hooks, ethdb.KeyValueStore, error) {
+	var config callIndexTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.Path == "" {
+		return nil, nil, errors.New("callindex tracer output path is required")
+	}
+	if config.Cache <= 0 {
+		config.Cache = 16
+	}
+	if config.Handles <= 0 {
+		config.Handles = 16
+	}
+	db, err := leveldb.New(config.Path, config.Cache, config.Handles, "", false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open index database: %v", err)
+	}
+	t := &callIndexTracer{db: db}
+	return &tracing.Hooks{
+		OnBlockStart: t.onBlockStart,
+		OnBlockEnd:   t.onBlockEnd,
+		OnEnter:      t.onEnter,
+		OnClose:      t.onClose,
+	}, db, nil
+}
+
+func (t *callIndexTracer) onBlockStart(ev tracing.BlockEvent) {
+	t.number = ev.Block.NumberU64()
+	t.addrs = make(map[common.Address]struct{})
+}
+
+func (t *callIndexTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.addrs == nil {
+		return
+	}
+	t.addrs[from] = struct{}{}
+	t.addrs[to] = struct{}{}
+}
+
+func (t *callIndexTracer) onBlockEnd(err error) {
+	addrs := t.addrs
+	t.addrs = nil
+
+	// The failed block is never accepted, leave it unindexed
+	if err != nil || addrs == nil {
+		return
+	}
+	batch := t.db.NewBatch()
+	for addr := range addrs {
+		rawdb.WriteCallAddressIndex(batch, addr, t.number)
+	}
+	// Extend the covered range, or restart it if there is a gap with the
+	// blocks indexed before.
+	first, last, ok := rawdb.ReadCallAddressIndexRange(t.db)
+	switch {
+	case !ok || t.number > last+1 || t.number+1 < first:
+		first, last = t.number, t.number
+	default:
+		first, last = min(first, t.number), max(last, t.number)
+	}
+	rawdb.WriteCallAddressIndexRange(batch, first, last)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write call address index", "number", t.number, "err", err)
+	}
+}
+
+func (t *callIndexTracer) onClose() {
+	if err := t.db.Close(); err != nil {
+		log.Warn("Failed to close call address index", "err", err)
+	}
+}
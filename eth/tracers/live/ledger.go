@@ -0,0 +1,118 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/internal"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("ledger", newLedgerTracer)
+}
+
+// ledgerBlock is the movements of ether and tokens in a block.
+type ledgerBlock struct {
+	Number     uint64                  `json:"blockNumber"`
+	Hash       common.Hash             `json:"hash"`
+	ParentHash common.Hash             `json:"parentHash"`
+	Entries    []*internal.LedgerEntry `json:"entries"`
+}
+
+type ledgerTracer struct {
+	block  *ledgerBlock
+	ledger *internal.Ledger
+	logger *lumberjack.Logger
+}
+
+type ledgerTracerConfig struct {
+	Path    string `json:"path"`    // Path to the directory where the tracer logs will be stored
+	MaxSize int    `json:"maxSize"` // MaxSize is the maximum size in megabytes of the tracer log file before it gets rotated. It defaults to 100 megabytes.
+}
+
+// newLedgerTracer returns a live tracer which writes the movements of ether
+// and standard tokens of each block into a rotating file.
+func newLedgerTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	var config ledgerTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.Path == "" {
+		return nil, errors.New("ledger tracer output path is required")
+	}
+	// Store traces in a rotating file
+	logger := &lumberjack.Logger{
+		Filename: filepath.Join(config.Path, "ledger.jsonl"),
+	}
+	if config.MaxSize > 0 {
+		logger.MaxSize = config.MaxSize
+	}
+	t := &ledgerTracer{
+		ledger: internal.NewLedger(),
+		logger: logger,
+	}
+	return &tracing.Hooks{
+		OnBlockStart:    t.onBlockStart,
+		OnBlockEnd:      t.onBlockEnd,
+		OnTxStart:       t.ledger.OnTxStart,
+		OnTxEnd:         t.ledger.OnTxEnd,
+		OnEnter:         t.ledger.OnEnter,
+		OnExit:          t.ledger.OnExit,
+		OnBalanceChange: t.ledger.OnBalanceChange,
+		OnLog:           t.ledger.OnLog,
+		OnClose:         t.onClose,
+	}, nil
+}
+
+func (t *ledgerTracer) onBlockStart(ev tracing.BlockEvent) {
+	t.ledger.Entries = t.ledger.Entries[:0]
+	t.block = &ledgerBlock{
+		Number:     ev.Block.NumberU64(),
+		Hash:       ev.Block.Hash(),
+		ParentHash: ev.Block.ParentHash(),
+	}
+}
+
+func (t *ledgerTracer) onBlockEnd(err error) {
+	block := t.block
+	t.block = nil
+
+	// The failed block is never accepted, nothing happened
+	if err != nil || block == nil {
+		return
+	}
+	block.Entries = t.ledger.Entries
+	out, _ := json.Marshal(block)
+	if _, err := t.logger.Write(append(out, '\n')); err != nil {
+		log.Warn("failed to write to ledger tracer log file", "error", err)
+	}
+}
+
+func (t *ledgerTracer) onClose() {
+	if err := t.logger.Close(); err != nil {
+		log.Warn("failed to close ledger tracer log file", "error", err)
+	}
+}
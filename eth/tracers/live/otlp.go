@@ -0,0 +1,447 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package live
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func init() {
+	tracers.LiveDirectory.Register("otlp", newOTLPTracer)
+}
+
+const (
+	// otlpQueueSize is the number of exported blocks allowed to wait for being
+	// sent to the collector endpoint, the newer ones are dropped if exceeded.
+	otlpQueueSize = 64
+
+	// otlpSendTimeout is the timeout of sending the spans of a single block to
+	// the collector endpoint.
+	otlpSendTimeout = 10 * time.Second
+
+	// otlpScopeName is the instrumentation scope name of the emitted spans.
+	otlpScopeName = "github.com/ethereum/go-ethereum/eth/tracers/live"
+)
+
+// Span kinds and status codes defined by the OTLP specification.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+)
+
+// otlpValue is the OTLP representation of an attribute value. Integers are
+// encoded as decimal strings as required by the OTLP/JSON encoding.
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+// otlpAttribute is the OTLP representation of a span attribute.
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpStatus is the OTLP representation of a span status.
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// otlpSpan is the OTLP representation of a span.
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+// otlpScope is the OTLP representation of an instrumentation scope.
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// otlpScopeSpans is the collection of spans produced by a scope.
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+// otlpResource is the OTLP representation of the entity producing the spans.
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+// otlpResourceSpans is the collection of spans produced by a resource.
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+// otlpTraces is the OTLP/JSON encoding of an ExportTraceServiceRequest, which
+// is accepted by the collectors over the OTLP/HTTP protocol.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func stringAttr(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value uint64) otlpAttribute {
+	s := strconv.FormatUint(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func boolAttr(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{BoolValue: &value}}
+}
+
+func bigAttr(key string, value *big.Int) otlpAttribute {
+	if value == nil {
+		value = new(big.Int)
+	}
+	return stringAttr(key, value.String())
+}
+
+func timestamp(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpTracer is a live tracer which converts the execution of blocks into
+// block -> transaction -> call frame spans, and exports them in the OTLP/JSON
+// format. The spans of each block are exported as a single trace, the trace
+// id is derived from the block hash.
+type otlpTracer struct {
+	service string
+	logger  *lumberjack.Logger // Rotating file the spans are written into, nil if not configured
+	client  *http.Client
+	url     string      // Collector endpoint the spans are sent to, empty if not configured
+	queue   chan []byte // Queue of the encoded spans waiting for being sent
+	wg      sync.WaitGroup
+
+	traceID string      // Trace id of the block being traced
+	spans   []*otlpSpan // All the spans created in the block being traced
+	stack   []*otlpSpan // Stack of the spans not yet ended
+	nonce   uint64      // Counter for producing the span ids
+}
+
+type otlpTracerConfig struct {
+	Path     string `json:"path"`     // Path to the directory where the spans will be stored
+	MaxSize  int    `json:"maxSize"`  // MaxSize is the maximum size in megabytes of the span file before it gets rotated. It defaults to 100 megabytes.
+	Endpoint string `json:"endpoint"` // OTLP/HTTP endpoint of the collector, e.g. http://localhost:4318/v1/traces
+	Service  string `json:"service"`  // Service name attached to the spans, defaults to geth
+}
+
+func newOTLPTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
+	var config otlpTracerConfig
+	if err := json.Unmarshal(cfg, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+	if config.Path == "" && config.Endpoint == "" {
+		return nil, errors.New("otlp tracer output path or endpoint is required")
+	}
+	t := &otlpTracer{
+		service: config.Service,
+		url:     config.Endpoint,
+	}
+	if t.service == "" {
+		t.service = "geth"
+	}
+	// Store spans in a rotating file
+	if config.Path != "" {
+		t.logger = &lumberjack.Logger{
+			Filename: filepath.Join(config.Path, "spans.jsonl"),
+		}
+		if config.MaxSize > 0 {
+			t.logger.MaxSize = config.MaxSize
+		}
+	}
+	// Send spans to the collector in the background, not blocking the
+	// block processing.
+	if config.Endpoint != "" {
+		t.client = &http.Client{Timeout: otlpSendTimeout}
+		t.queue = make(chan []byte, otlpQueueSize)
+		t.wg.Add(1)
+		go t.loop()
+	}
+	return &tracing.Hooks{
+		OnBlockStart:      t.onBlockStart,
+		OnBlockEnd:        t.onBlockEnd,
+		OnTxStart:         t.onTxStart,
+		OnTxEnd:           t.onTxEnd,
+		OnEnter:           t.onEnter,
+		OnExit:            t.onExit,
+		OnSystemCallStart: t.onSystemCallStart,
+		OnSystemCallEnd:   t.onSystemCallEnd,
+		OnClose:           t.onClose,
+	}, nil
+}
+
+// startSpan opens a new span as the child of the innermost span not yet ended.
+func (t *otlpTracer) startSpan(name string, attrs ...otlpAttribute) *otlpSpan {
+	t.nonce++
+
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], t.nonce)
+	span := &otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            hex.EncodeToString(id[:]),
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: timestamp(time.Now()),
+		Attributes:        attrs,
+	}
+	if len(t.stack) > 0 {
+		span.ParentSpanID = t.stack[len(t.stack)-1].SpanID
+	}
+	t.spans = append(t.spans, span)
+	t.stack = append(t.stack, span)
+	return span
+}
+
+// endSpan closes the innermost span not yet ended.
+func (t *otlpTracer) endSpan(attrs ...otlpAttribute) *otlpSpan {
+	span := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	span.EndTimeUnixNano = timestamp(time.Now())
+	span.Attributes = append(span.Attributes, attrs...)
+	return span
+}
+
+func (t *otlpTracer) onBlockStart(ev tracing.BlockEvent) {
+	var (
+		block = ev.Block
+		hash  = block.Hash()
+	)
+	t.traceID = hex.EncodeToString(hash[:16])
+	t.spans, t.stack, t.nonce = nil, nil, 0
+
+	t.startSpan("block",
+		intAttr("block.number", block.NumberU64()),
+		stringAttr("block.hash", hash.Hex()),
+		stringAttr("block.parent_hash", block.ParentHash().Hex()),
+		stringAttr("block.coinbase", block.Coinbase().Hex()),
+		intAttr("block.gas_limit", block.GasLimit()),
+		intAttr("block.gas_used", block.GasUsed()),
+		intAttr("block.transactions", uint64(len(block.Transactions()))),
+	)
+}
+
+func (t *otlpTracer) onBlockEnd(err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	// Close the spans left open by an aborted execution, the block span
+	// is always the outermost one.
+	for len(t.stack) > 1 {
+		t.endSpan()
+	}
+	span := t.endSpan()
+	if err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	} else {
+		span.Status = otlpStatus{Code: otlpStatusOk}
+	}
+	t.export()
+}
+
+func (t *otlpTracer) onTxStart(vm *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	if len(t.stack) == 0 {
+		return
+	}
+	to := "<create>"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	t.startSpan("transaction",
+		stringAttr("tx.hash", tx.Hash().Hex()),
+		intAttr("tx.type", uint64(tx.Type())),
+		intAttr("tx.nonce", tx.Nonce()),
+		stringAttr("tx.from", from.Hex()),
+		stringAttr("tx.to", to),
+		bigAttr("tx.value", tx.Value()),
+		intAttr("tx.gas_limit", tx.Gas()),
+	)
+}
+
+func (t *otlpTracer) onTxEnd(receipt *types.Receipt, err error) {
+	if len(t.stack) <= 1 {
+		return
+	}
+	// Close the call frames left open by an invalid transaction
+	for len(t.stack) > 2 {
+		t.endSpan()
+	}
+	if err != nil {
+		span := t.endSpan()
+		span.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+		return
+	}
+	span := t.endSpan(
+		intAttr("tx.index", uint64(receipt.TransactionIndex)),
+		intAttr("tx.gas_used", receipt.GasUsed),
+		intAttr("tx.status", receipt.Status),
+	)
+	if receipt.Status == types.ReceiptStatusFailed {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: "transaction failed"}
+	} else {
+		span.Status = otlpStatus{Code: otlpStatusOk}
+	}
+}
+
+func (t *otlpTracer) onEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if len(t.stack) == 0 {
+		return
+	}
+	t.startSpan(vm.OpCode(typ).String(),
+		intAttr("call.depth", uint64(depth)),
+		stringAttr("call.from", from.Hex()),
+		stringAttr("call.to", to.Hex()),
+		bigAttr("call.value", value),
+		intAttr("call.gas", gas),
+		intAttr("call.input_size", uint64(len(input))),
+	)
+}
+
+func (t *otlpTracer) onExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.stack) <= 1 {
+		return
+	}
+	span := t.endSpan(
+		intAttr("call.gas_used", gasUsed),
+		intAttr("call.output_size", uint64(len(output))),
+		boolAttr("call.reverted", reverted),
+	)
+	if err == nil {
+		span.Status = otlpStatus{Code: otlpStatusOk}
+		return
+	}
+	span.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		if reason, unpackErr := abi.UnpackRevert(output); unpackErr == nil {
+			span.Attributes = append(span.Attributes, stringAttr("call.revert_reason", reason))
+			span.Status.Message = fmt.Sprintf("%v: %s", err, reason)
+		}
+	}
+}
+
+func (t *otlpTracer) onSystemCallStart() {
+	if len(t.stack) == 0 {
+		return
+	}
+	t.startSpan("system call")
+}
+
+func (t *otlpTracer) onSystemCallEnd() {
+	if len(t.stack) <= 1 {
+		return
+	}
+	span := t.endSpan()
+	span.Status = otlpStatus{Code: otlpStatusOk}
+}
+
+// export encodes the spans of the traced block, and delivers them to the
+// configured destinations.
+func (t *otlpTracer) export() {
+	blob, err := json.Marshal(&otlpTraces{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{stringAttr("service.name", t.service)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: t.spans,
+			}},
+		}},
+	})
+	t.spans, t.stack = nil, nil
+	if err != nil {
+		log.Warn("Failed to encode otlp spans", "err", err)
+		return
+	}
+	if t.logger != nil {
+		if _, err := t.logger.Write(append(blob, '\n')); err != nil {
+			log.Warn("Failed to write to otlp tracer log file", "err", err)
+		}
+	}
+	if t.queue != nil {
+		select {
+		case t.queue <- blob:
+		default:
+			log.Warn("Dropped otlp spans, collector is lagging behind")
+		}
+	}
+}
+
+// loop sends the queued spans to the collector endpoint until the queue is
+// closed.
+func (t *otlpTracer) loop() {
+	defer t.wg.Done()
+
+	for blob := range t.queue {
+		res, err := t.client.Post(t.url, "application/json", bytes.NewReader(blob))
+		if err != nil {
+			log.Warn("Failed to send otlp spans", "endpoint", t.url, "err", err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode/100 != 2 {
+			log.Warn("Failed to send otlp spans", "endpoint", t.url, "status", res.Status)
+		}
+	}
+}
+
+func (t *otlpTracer) onClose() {
+	if t.queue != nil {
+		close(t.queue)
+		t.wg.Wait()
+	}
+	if t.logger != nil {
+		if err := t.logger.Close(); err != nil {
+			log.Warn("Failed to close otlp tracer log file", "err", err)
+		}
+	}
+}
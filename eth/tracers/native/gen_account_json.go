@@ -15,26 +15,35 @@ var _ = (*accountMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (a account) MarshalJSON() ([]byte, error) {
 	type account struct {
-		Balance *hexutil.Big                `json:"balance,omitempty"`
-		Code    hexutil.Bytes               `json:"code,omitempty"`
-		Nonce   uint64                      `json:"nonce,omitempty"`
-		Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+		Balance          *hexutil.Big                  `json:"balance,omitempty"`
+		Code             hexutil.Bytes                 `json:"code,omitempty"`
+		Nonce            uint64                        `json:"nonce,omitempty"`
+		Storage          map[common.Hash]common.Hash   `json:"storage,omitempty"`
+		TransientStorage map[common.Hash][]common.Hash `json:"transientStorage,omitempty"`
+		Created          bool                          `json:"created,omitempty"`
+		Destroyed        bool                          `json:"destroyed,omitempty"`
 	}
 	var enc account
 	enc.Balance = (*hexutil.Big)(a.Balance)
 	enc.Code = a.Code
 	enc.Nonce = a.Nonce
 	enc.Storage = a.Storage
+	enc.TransientStorage = a.TransientStorage
+	enc.Created = a.Created
+	enc.Destroyed = a.Destroyed
 	return json.Marshal(&enc)
 }
 
 // UnmarshalJSON unmarshals from JSON.
 func (a *account) UnmarshalJSON(input []byte) error {
 	type account struct {
-		Balance *hexutil.Big                `json:"balance,omitempty"`
-		Code    *hexutil.Bytes              `json:"code,omitempty"`
-		Nonce   *uint64                     `json:"nonce,omitempty"`
-		Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+		Balance          *hexutil.Big                  `json:"balance,omitempty"`
+		Code             *hexutil.Bytes                `json:"code,omitempty"`
+		Nonce            *uint64                       `json:"nonce,omitempty"`
+		Storage          map[common.Hash]common.Hash   `json:"storage,omitempty"`
+		TransientStorage map[common.Hash][]common.Hash `json:"transientStorage,omitempty"`
+		Created          *bool                         `json:"created,omitempty"`
+		Destroyed        *bool                         `json:"destroyed,omitempty"`
 	}
 	var dec account
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -52,5 +61,14 @@ func (a *account) UnmarshalJSON(input []byte) error {
 	if dec.Storage != nil {
 		a.Storage = dec.Storage
 	}
+	if dec.TransientStorage != nil {
+		a.TransientStorage = dec.TransientStorage
+	}
+	if dec.Created != nil {
+		a.Created = *dec.Created
+	}
+	if dec.Destroyed != nil {
+		a.Destroyed = *dec.Destroyed
+	}
 	return nil
 }
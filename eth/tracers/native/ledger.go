@@ -0,0 +1,121 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/internal"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("ledgerTracer", newLedgerTracer, false)
+}
+
+// ledgerTracer reports the movements of ether and standard tokens caused by
+// the transaction: the value transfers of the calls, self-destructs, the fees
+// paid and burnt, and the ERC-20/721/1155 transfer events. The movements of
+// the reverted call frames are excluded.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "ledgerTracer"})
+//	[
+//	  {kind: "eth", reason: "call", from: "0x...", to: "0x...", value: "0x1", txHash: "0x..."},
+//	  {kind: "erc20", token: "0x...", from: "0x...", to: "0x...", value: "0x64", txHash: "0x..."},
+//	  {kind: "eth", reason: "fee", from: "0x...", to: "0x...", value: "0x5208", txHash: "0x..."},
+//	  {kind: "eth", reason: "burn", from: "0x...", to: "0x0000000000000000000000000000000000000000", value: "0x5208", txHash: "0x..."}
+//	]
+type ledgerTracer struct {
+	ledger    *internal.Ledger
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newLedgerTracer returns a native go tracer which collects the movements of
+// ether and tokens.
+func newLedgerTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &ledgerTracer{ledger: internal.NewLedger()}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:       t.OnTxStart,
+			OnTxEnd:         t.OnTxEnd,
+			OnEnter:         t.OnEnter,
+			OnExit:          t.OnExit,
+			OnBalanceChange: t.OnBalanceChange,
+			OnLog:           t.OnLog,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *ledgerTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.ledger.OnTxStart(env, tx, from)
+}
+
+func (t *ledgerTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	t.ledger.OnTxEnd(receipt, err)
+}
+
+func (t *ledgerTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.ledger.OnEnter(depth, typ, from, to, input, gas, value)
+}
+
+func (t *ledgerTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.ledger.OnExit(depth, output, gasUsed, err, reverted)
+}
+
+func (t *ledgerTracer) OnBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	t.ledger.OnBalanceChange(addr, prev, new, reason)
+}
+
+func (t *ledgerTracer) OnLog(log *types.Log) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.ledger.OnLog(log)
+}
+
+// GetResult returns the json-encoded movements of the transaction, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *ledgerTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.ledger.Entries)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *ledgerTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
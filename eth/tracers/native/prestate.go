@@ -43,11 +43,14 @@ func init() {
 type stateMap = map[common.Address]*account
 
 type account struct {
-	Balance *big.Int                    `json:"balance,omitempty"`
-	Code    []byte                      `json:"code,omitempty"`
-	Nonce   uint64                      `json:"nonce,omitempty"`
-	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
-	empty   bool
+	Balance          *big.Int                      `json:"balance,omitempty"`
+	Code             []byte                        `json:"code,omitempty"`
+	Nonce            uint64                        `json:"nonce,omitempty"`
+	Storage          map[common.Hash]common.Hash   `json:"storage,omitempty"`
+	TransientStorage map[common.Hash][]common.Hash `json:"transientStorage,omitempty"`
+	Created          bool                          `json:"created,omitempty"`
+	Destroyed        bool                          `json:"destroyed,omitempty"`
+	empty            bool
 }
 
 func (a *account) exists() bool {
@@ -60,21 +63,33 @@ type accountMarshaling struct {
 }
 
 type prestateTracer struct {
-	env       *tracing.VMContext
-	pre       stateMap
-	post      stateMap
-	to        common.Address
-	config    prestateTracerConfig
-	interrupt atomic.Bool // Atomic flag to signal execution interruption
-	reason    error       // Textual reason for the interruption
-	created   map[common.Address]bool
-	deleted   map[common.Address]bool
+	env         *tracing.VMContext
+	chainConfig *params.ChainConfig
+	pre         stateMap
+	post        stateMap
+	to          common.Address
+	config      prestateTracerConfig
+	interrupt   atomic.Bool // Atomic flag to signal execution interruption
+	reason      error       // Textual reason for the interruption
+	created     map[common.Address]bool
+	deleted     map[common.Address]bool
+	transient   []transientWrite // Transient storage writes in order, dropped if the frame reverts
+	frames      []int            // Number of transient storage writes at the entry of each call frame
+}
+
+// transientWrite is a transient storage write made by the contract.
+type transientWrite struct {
+	addr  common.Address
+	slot  common.Hash
+	value common.Hash
 }
 
 type prestateTracerConfig struct {
 	DiffMode       bool `json:"diffMode"`       // If true, this tracer will return state modifications
 	DisableCode    bool `json:"disableCode"`    // If true, this tracer will not return the contract code
 	DisableStorage bool `json:"disableStorage"` // If true, this tracer will not return the contract storage
+	WithTransient  bool `json:"withTransient"`  // If true, this tracer will return the transient storage writes in diff mode
+	WithLifecycle  bool `json:"withLifecycle"`  // If true, this tracer will mark the created and destroyed accounts in diff mode
 }
 
 func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
@@ -83,16 +98,19 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *p
 		return nil, err
 	}
 	t := &prestateTracer{
-		pre:     stateMap{},
-		post:    stateMap{},
-		config:  config,
-		created: make(map[common.Address]bool),
-		deleted: make(map[common.Address]bool),
+		chainConfig: chainConfig,
+		pre:         stateMap{},
+		post:        stateMap{},
+		config:      config,
+		created:     make(map[common.Address]bool),
+		deleted:     make(map[common.Address]bool),
 	}
 	return &tracers.Tracer{
 		Hooks: &tracing.Hooks{
 			OnTxStart: t.OnTxStart,
 			OnTxEnd:   t.OnTxEnd,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
 			OnOpcode:  t.OnOpcode,
 		},
 		GetResult: t.GetResult,
@@ -117,10 +135,17 @@ func (t *prestateTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scop
 	case stackLen >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
 		slot := common.Hash(stackData[stackLen-1].Bytes32())
 		t.lookupStorage(caller, slot)
+	case stackLen >= 2 && op == vm.TSTORE && t.config.DiffMode && t.config.WithTransient:
+		t.lookupAccount(caller)
+		t.transient = append(t.transient, transientWrite{
+			addr:  caller,
+			slot:  common.Hash(stackData[stackLen-1].Bytes32()),
+			value: common.Hash(stackData[stackLen-2].Bytes32()),
+		})
 	case stackLen >= 1 && (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SELFDESTRUCT):
 		addr := common.Address(stackData[stackLen-1].Bytes20())
 		t.lookupAccount(addr)
-		if op == vm.SELFDESTRUCT {
+		if op == vm.SELFDESTRUCT && (!t.config.WithLifecycle || t.destructs(caller)) {
 			t.deleted[caller] = true
 		}
 	case stackLen >= 5 && (op == vm.DELEGATECALL || op == vm.CALL || op == vm.STATICCALL || op == vm.CALLCODE):
@@ -147,6 +172,24 @@ func (t *prestateTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scop
 	}
 }
 
+// OnEnter records the number of transient storage writes made before the call
+// frame, so that the writes made inside can be dropped if it reverts.
+func (t *prestateTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, len(t.transient))
+}
+
+// OnExit drops the transient storage writes made inside the reverted frame.
+func (t *prestateTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(t.frames) == 0 {
+		return
+	}
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if reverted {
+		t.transient = t.transient[:start]
+	}
+}
+
 func (t *prestateTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
 	t.env = env
 	if tx.To() == nil {
@@ -173,10 +216,48 @@ func (t *prestateTracer) OnTxEnd(receipt *types.Receipt, err error) {
 		// the created contract maybe exists in statedb before the creating tx
 		if s := t.pre[a]; s != nil && s.empty {
 			delete(t.pre, a)
+
+			if t.config.DiffMode && t.config.WithLifecycle {
+				t.markCreated(a)
+			}
+		}
+	}
+	if t.config.DiffMode && t.config.WithLifecycle {
+		for a := range t.deleted {
+			if t.post[a] == nil {
+				t.post[a] = &account{}
+			}
+			t.post[a].Destroyed = true
 		}
 	}
 }
 
+// destructs returns whether the self-destruct of the given contract deletes
+// the account. Since EIP-6780, only the contracts created in the same
+// transaction are deleted, the others just have their balance transferred.
+//
+// It's only taken into account with the lifecycle reporting enabled, leaving
+// the diff of the existing users untouched.
+func (t *prestateTracer) destructs(addr common.Address) bool {
+	if t.chainConfig == nil || !t.chainConfig.IsCancun(t.env.BlockNumber, t.env.Time) {
+		return true
+	}
+	return t.created[addr]
+}
+
+// markCreated marks the account as created by the transaction in the post
+// state. The account created by a failed creation doesn't exist afterwards
+// and is left unmarked.
+func (t *prestateTracer) markCreated(addr common.Address) {
+	if !t.deleted[addr] && !t.env.StateDB.Exist(addr) {
+		return
+	}
+	if t.post[addr] == nil {
+		t.post[addr] = &account{}
+	}
+	t.post[addr].Created = true
+}
+
 // GetResult returns the json-encoded nested list of call traces, and any
 // error arising from the encoding or forceful termination (via `Stop`).
 func (t *prestateTracer) GetResult() (json.RawMessage, error) {
@@ -203,6 +284,13 @@ func (t *prestateTracer) Stop(err error) {
 }
 
 func (t *prestateTracer) processDiffState() {
+	transient := make(map[common.Address]map[common.Hash][]common.Hash)
+	for _, w := range t.transient {
+		if transient[w.addr] == nil {
+			transient[w.addr] = make(map[common.Hash][]common.Hash)
+		}
+		transient[w.addr][w.slot] = append(transient[w.addr][w.slot], w.value)
+	}
 	for addr, state := range t.pre {
 		// The deleted account's state is pruned from `post` but kept in `pre`
 		if _, ok := t.deleted[addr]; ok {
@@ -249,6 +337,10 @@ func (t *prestateTracer) processDiffState() {
 			}
 		}
 
+		if slots := transient[addr]; len(slots) > 0 {
+			modified = true
+			postAccount.TransientStorage = slots
+		}
 		if modified {
 			t.post[addr] = postAccount
 		} else {
@@ -0,0 +1,349 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("opcodeProfiler", newOpcodeProfiler, false)
+}
+
+// profileStat is the aggregated cost of the executed instructions.
+type profileStat struct {
+	Count uint64 `json:"count"`
+	Gas   uint64 `json:"gas"`
+	Time  uint64 `json:"time"` // Wall-clock execution time in nanoseconds
+}
+
+func (s *profileStat) add(gas uint64, elapsed time.Duration) {
+	s.Count++
+	s.Gas += gas
+	s.Time += uint64(elapsed)
+}
+
+// profileSite identifies an instruction in the contract code.
+type profileSite struct {
+	CodeHash common.Hash `json:"codeHash"`
+	Pc       uint64      `json:"pc"`
+}
+
+// profileSiteStat is the aggregated cost of an instruction in the contract code.
+type profileSiteStat struct {
+	profileSite
+	profileStat
+	Op string `json:"op"`
+}
+
+// profileResult is the output of the opcode profiler.
+type profileResult struct {
+	Opcodes   map[string]*profileStat `json:"opcodes"`   // Cost per opcode
+	Contracts []*profileSiteStat      `json:"contracts"` // Cost per instruction, sorted by gas
+	Folded    []string                `json:"folded"`    // Flame graph compatible folded stacks
+}
+
+// profileFrame is a call frame being executed.
+type profileFrame struct {
+	codeHash common.Hash
+	stack    string // Folded stack of the frame, the code addresses separated by semicolons
+}
+
+// profileOp is an instruction being executed, the cost is settled once
+// the next event arrives.
+type profileOp struct {
+	op    vm.OpCode
+	pc    uint64
+	gas   uint64
+	start time.Time
+	frame *profileFrame
+}
+
+type opcodeProfilerConfig struct {
+	Weight string `json:"weight"` // Weight of the folded stacks: "gas" (default), "time" or "count"
+}
+
+// opcodeProfiler aggregates the number of executions, the consumed gas and the
+// wall-clock execution time of the instructions, per opcode and per instruction
+// of the contract code. The folded stacks output can be fed into flame graph
+// tools directly.
+//
+// The gas forwarded to the callee is excluded from the call instructions, the
+// instructions of the callee are accounted on their own instead. Likewise the
+// time spent in the callee isn't attributed to the caller.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "opcodeProfiler", tracerConfig: {weight: "time"}})
+//	{
+//	  opcodes: {PUSH1: {count: 2, gas: 6, time: 120}, SSTORE: {count: 1, gas: 22100, time: 2100}, ...},
+//	  contracts: [{codeHash: "0x...", pc: 4, op: "SSTORE", count: 1, gas: 22100, time: 2100}, ...],
+//	  folded: ["0x...aaaa;PUSH1 120", "0x...aaaa;SSTORE 2100", ...]
+//	}
+type opcodeProfiler struct {
+	env       *tracing.VMContext
+	config    opcodeProfilerConfig
+	opcodes   map[vm.OpCode]*profileStat
+	sites     map[profileSite]*profileSiteStat
+	folded    map[string]*profileStat
+	frames    []*profileFrame
+	pending   *profileOp
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newOpcodeProfiler returns a native go tracer which aggregates the cost of
+// the executed instructions.
+func newOpcodeProfiler(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	var config opcodeProfilerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Weight {
+	case "":
+		config.Weight = "gas"
+	case "gas", "time", "count":
+	default:
+		return nil, fmt.Errorf("unsupported weight %q", config.Weight)
+	}
+	t := &opcodeProfiler{
+		config:  config,
+		opcodes: make(map[vm.OpCode]*profileStat),
+		sites:   make(map[profileSite]*profileSiteStat),
+		folded:  make(map[string]*profileStat),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnTxEnd:   t.OnTxEnd,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+			OnFault:   t.OnFault,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *opcodeProfiler) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+}
+
+func (t *opcodeProfiler) OnTxEnd(receipt *types.Receipt, err error) {
+	t.settle()
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *opcodeProfiler) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	// The gas forwarded to the callee is charged by the call instruction,
+	// exclude it as it's consumed by the instructions of the callee. The
+	// stipend is granted for free on top of the forwarded gas.
+	if op := t.pending; op != nil {
+		switch op.op {
+		case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+			forwarded := gas
+			if value != nil && value.Sign() != 0 && (op.op == vm.CALL || op.op == vm.CALLCODE) {
+				forwarded -= min(forwarded, params.CallStipend)
+			}
+			op.gas -= min(op.gas, forwarded)
+		}
+	}
+	t.settle()
+
+	// The code of the contract creation is the init code in the input,
+	// the code of the callee is resolved from the state.
+	var codeHash common.Hash
+	switch vm.OpCode(typ) {
+	case vm.CREATE, vm.CREATE2:
+		codeHash = crypto.Keccak256Hash(input)
+	case vm.SELFDESTRUCT:
+	default:
+		if t.env != nil {
+			codeHash = t.codeHash(to)
+		}
+	}
+	stack := to.Hex()
+	if len(t.frames) > 0 {
+		stack = t.frames[len(t.frames)-1].stack + ";" + stack
+	}
+	t.frames = append(t.frames, &profileFrame{codeHash: codeHash, stack: stack})
+}
+
+// codeHasher is implemented by the state databases which know the code hash of
+// the accounts, saving the hashing of the code.
+type codeHasher interface {
+	GetCodeHash(common.Address) common.Hash
+}
+
+// codeHash returns the hash of the contract code. Non-existent accounts are
+// reported with the hash of empty code, consistently with the existing accounts
+// without code.
+func (t *opcodeProfiler) codeHash(addr common.Address) common.Hash {
+	if hasher, ok := t.env.StateDB.(codeHasher); ok {
+		if hash := hasher.GetCodeHash(addr); hash != (common.Hash{}) {
+			return hash
+		}
+		return types.EmptyCodeHash
+	}
+	return crypto.Keccak256Hash(t.env.StateDB.GetCode(addr))
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *opcodeProfiler) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.settle()
+	if len(t.frames) > 0 {
+		t.frames = t.frames[:len(t.frames)-1]
+	}
+}
+
+// OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
+func (t *opcodeProfiler) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.settle()
+
+	// The instruction failed before the execution, nothing is accounted.
+	if err != nil || len(t.frames) == 0 {
+		return
+	}
+	t.pending = &profileOp{
+		op:    vm.OpCode(opcode),
+		pc:    pc,
+		gas:   cost,
+		frame: t.frames[len(t.frames)-1],
+		start: time.Now(),
+	}
+}
+
+// OnFault is called when an error occurs during the execution of an opcode.
+func (t *opcodeProfiler) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.settle()
+}
+
+// settle accounts the cost of the pending instruction.
+func (t *opcodeProfiler) settle() {
+	op := t.pending
+	if op == nil {
+		return
+	}
+	t.pending = nil
+	elapsed := time.Since(op.start)
+
+	stat := t.opcodes[op.op]
+	if stat == nil {
+		stat = new(profileStat)
+		t.opcodes[op.op] = stat
+	}
+	stat.add(op.gas, elapsed)
+
+	site := profileSite{CodeHash: op.frame.codeHash, Pc: op.pc}
+	siteStat := t.sites[site]
+	if siteStat == nil {
+		siteStat = &profileSiteStat{profileSite: site, Op: op.op.String()}
+		t.sites[site] = siteStat
+	}
+	siteStat.add(op.gas, elapsed)
+
+	stack := op.frame.stack + ";" + op.op.String()
+	stackStat := t.folded[stack]
+	if stackStat == nil {
+		stackStat = new(profileStat)
+		t.folded[stack] = stackStat
+	}
+	stackStat.add(op.gas, elapsed)
+}
+
+// GetResult returns the json-encoded profile of the transaction, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *opcodeProfiler) GetResult() (json.RawMessage, error) {
+	t.settle()
+
+	res := &profileResult{
+		Opcodes:   make(map[string]*profileStat, len(t.opcodes)),
+		Contracts: make([]*profileSiteStat, 0, len(t.sites)),
+		Folded:    make([]string, 0, len(t.folded)),
+	}
+	for op, stat := range t.opcodes {
+		res.Opcodes[op.String()] = stat
+	}
+	for _, stat := range t.sites {
+		res.Contracts = append(res.Contracts, stat)
+	}
+	slices.SortFunc(res.Contracts, func(a, b *profileSiteStat) int {
+		if c := cmp.Compare(b.Gas, a.Gas); c != 0 {
+			return c
+		}
+		if c := a.CodeHash.Cmp(b.CodeHash); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Pc, b.Pc)
+	})
+	for stack, stat := range t.folded {
+		var weight uint64
+		switch t.config.Weight {
+		case "time":
+			weight = stat.Time
+		case "count":
+			weight = stat.Count
+		default:
+			weight = stat.Gas
+		}
+		res.Folded = append(res.Folded, stack+" "+strconv.FormatUint(weight, 10))
+	}
+	slices.Sort(res.Folded)
+
+	blob, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return blob, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *opcodeProfiler) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,329 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("vmTracer", newVMTracer, false)
+}
+
+// vmTrace is the Parity-style trace of the code executed in a call frame.
+type vmTrace struct {
+	Code hexutil.Bytes  `json:"code"`
+	Ops  []*vmOperation `json:"ops"`
+}
+
+// vmOperation is a single executed instruction, along with the trace of the
+// call frame it spawns if any.
+type vmOperation struct {
+	Cost uint64      `json:"cost"`
+	Ex   *vmExecuted `json:"ex"` // nil if the instruction failed
+	Pc   uint64      `json:"pc"`
+	Sub  *vmTrace    `json:"sub"`
+
+	op      vm.OpCode
+	gas     uint64         // Gas available before the execution
+	memOff  uint64         // Offset of the memory written by the instruction
+	memSize uint64         // Size of the memory written by the instruction
+	store   *vmStorageDiff // Storage slot written by the instruction
+}
+
+// vmExecuted is the effect of a successfully executed instruction.
+type vmExecuted struct {
+	Mem   *vmMemoryDiff  `json:"mem"`
+	Push  []string       `json:"push"`
+	Store *vmStorageDiff `json:"store"`
+	Used  uint64         `json:"used"` // Gas remaining after the execution
+}
+
+// vmMemoryDiff is the memory region written by an instruction.
+type vmMemoryDiff struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// vmStorageDiff is the storage slot written by an instruction.
+type vmStorageDiff struct {
+	Key string `json:"key"`
+	Val string `json:"val"`
+}
+
+// vmFrame tracks the execution of a call frame.
+type vmFrame struct {
+	trace   *vmTrace
+	pending *vmOperation // The instruction whose effects are not yet known
+}
+
+// vmTracer reports the executed instructions of a transaction in the Parity
+// vmTrace format. The effects of an instruction (pushed stack items, written
+// memory and storage) are collected when the next instruction in the same call
+// frame is about to be executed.
+type vmTracer struct {
+	env       *tracing.VMContext
+	root      *vmTrace
+	frames    []*vmFrame
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newVMTracer returns a new vmTracer.
+func newVMTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &vmTracer{}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnTxEnd:   t.OnTxEnd,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+			OnFault:   t.OnFault,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *vmTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+}
+
+func (t *vmTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	// The frames are all exited unless the transaction failed to execute,
+	// drop the leftovers in that case.
+	t.frames = nil
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *vmTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	// Selfdestruct doesn't execute any code, track an empty frame for
+	// keeping the frames aligned with the exits.
+	op := vm.OpCode(typ)
+	if op == vm.SELFDESTRUCT {
+		t.frames = append(t.frames, &vmFrame{})
+		return
+	}
+	trace := &vmTrace{Ops: []*vmOperation{}}
+	if op == vm.CREATE || op == vm.CREATE2 {
+		trace.Code = common.CopyBytes(input)
+	} else if t.env != nil {
+		trace.Code = t.env.StateDB.GetCode(to)
+	}
+	if depth == 0 {
+		t.root = trace
+	} else if len(t.frames) > 0 {
+		if parent := t.frames[len(t.frames)-1]; parent.pending != nil && parent.pending.Sub == nil {
+			parent.pending.Sub = trace
+		}
+	}
+	t.frames = append(t.frames, &vmFrame{trace: trace})
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *vmTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	// The final instruction of the frame has no successor, its effects are
+	// derived from the instruction itself.
+	if frame.pending != nil {
+		t.execute(frame, nil, frame.pending.gas-frame.pending.Cost)
+	}
+}
+
+// OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
+func (t *vmTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.trace == nil {
+		return
+	}
+	if frame.pending != nil {
+		t.execute(frame, scope, gas)
+	}
+	op := &vmOperation{
+		Cost: cost,
+		Pc:   pc,
+		op:   vm.OpCode(opcode),
+		gas:  gas,
+	}
+	frame.trace.Ops = append(frame.trace.Ops, op)
+
+	// The instruction failed before the execution, leave it as unexecuted.
+	if err != nil {
+		return
+	}
+	stack := scope.StackData()
+	op.memOff, op.memSize = memoryWritten(op.op, stack)
+	if op.op == vm.SSTORE && len(stack) >= 2 {
+		op.store = &vmStorageDiff{
+			Key: stack[len(stack)-1].Hex(),
+			Val: stack[len(stack)-2].Hex(),
+		}
+	}
+	frame.pending = op
+}
+
+// OnFault is called when an error occurs during the execution of an opcode.
+func (t *vmTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.pending == nil {
+		return
+	}
+	// Revert is executed successfully, although it's reported as a fault.
+	if vm.OpCode(op) == vm.REVERT && errors.Is(err, vm.ErrExecutionReverted) {
+		t.execute(frame, scope, gas-cost)
+		return
+	}
+	frame.pending = nil
+}
+
+// execute fills the effects of the pending instruction of the frame, using the
+// state of the scope after the execution. The scope is nil if the instruction
+// terminates the frame.
+func (t *vmTracer) execute(frame *vmFrame, scope tracing.OpContext, gas uint64) {
+	op := frame.pending
+	frame.pending = nil
+
+	ex := &vmExecuted{
+		Push:  []string{},
+		Store: op.store,
+		Used:  gas,
+	}
+	if scope != nil {
+		stack := scope.StackData()
+		if n := stackPushed(op.op); n > 0 && n <= len(stack) {
+			for _, item := range stack[len(stack)-n:] {
+				ex.Push = append(ex.Push, item.Hex())
+			}
+		}
+		if op.memSize > 0 {
+			memory := scope.MemoryData()
+			if end := op.memOff + op.memSize; end >= op.memOff && end <= uint64(len(memory)) {
+				ex.Mem = &vmMemoryDiff{
+					Data: common.CopyBytes(memory[op.memOff:end]),
+					Off:  op.memOff,
+				}
+			}
+		}
+	}
+	op.Ex = ex
+}
+
+// GetResult returns the json-encoded vmTrace of the transaction, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.root)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// stackPushed returns the number of the stack items reported as pushed by the
+// instruction. Following Parity, dup and swap operations report all the stack
+// items they touch.
+func stackPushed(op vm.OpCode) int {
+	switch {
+	case op.IsPush():
+		return 1
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY, vm.MCOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.INVALID:
+		return 0
+	}
+	return 1
+}
+
+// memoryWritten returns the memory region written by the instruction, based on
+// the stack before the execution.
+func memoryWritten(op vm.OpCode, stack []uint256.Int) (uint64, uint64) {
+	peek := func(n int) (uint64, bool) {
+		if len(stack) <= n {
+			return 0, false
+		}
+		v := stack[len(stack)-1-n]
+		return v.Uint64(), v.IsUint64()
+	}
+	region := func(off, size int) (uint64, uint64) {
+		o, ok1 := peek(off)
+		s, ok2 := peek(size)
+		if !ok1 || !ok2 {
+			return 0, 0
+		}
+		return o, s
+	}
+	switch op {
+	case vm.MSTORE:
+		if off, ok := peek(0); ok && len(stack) >= 2 {
+			return off, 32
+		}
+	case vm.MSTORE8:
+		if off, ok := peek(0); ok && len(stack) >= 2 {
+			return off, 1
+		}
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		return region(0, 2)
+	case vm.EXTCODECOPY:
+		return region(1, 3)
+	case vm.CALL, vm.CALLCODE:
+		return region(5, 6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		return region(4, 5)
+	}
+	return 0, 0
+}
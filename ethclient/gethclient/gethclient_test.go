@@ -23,9 +23,11 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -139,6 +141,9 @@ func TestGethClient(t *testing.T) {
 		}, {
 			"TestSubscribePendingTxs",
 			func(t *testing.T) { testSubscribeFullPendingTransactions(t, client) },
+		}, {
+			"TestSubscribePendingPrivateTxs",
+			func(t *testing.T) { testSubscribePendingPrivateTransactions(t, client) },
 		}, {
 			"TestCallContract",
 			func(t *testing.T) { testCallContract(t, client) },
@@ -454,6 +459,86 @@ func testSubscribeFullPendingTransactions(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testSubscribePendingPrivateTransactions(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+	ethcl := ethclient.NewClient(client)
+	// Subscribe to Transactions
+	ch := make(chan common.Hash, 2)
+	sub, err := ec.SubscribePendingTransactions(context.Background(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	chainID, err := ethcl.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	privateTx, err := types.SignTx(types.NewTransaction(2, common.Address{1}, big.NewInt(1), 22000, big.NewInt(1), nil), signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicTx, err := types.SignTx(types.NewTransaction(3, common.Address{1}, big.NewInt(1), 22000, big.NewInt(1), nil), signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Send the private transaction first, then the public one
+	raw, err := privateTx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "eth_sendPrivateRawTransaction", hexutil.Bytes(raw), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ethcl.SendTransaction(context.Background(), publicTx); err != nil {
+		t.Fatal(err)
+	}
+	// Check that only the public transaction was sent over the channel
+	if hash := <-ch; hash != publicTx.Hash() {
+		t.Fatalf("Invalid tx hash received, got %v, want %v", hash, publicTx.Hash())
+	}
+	select {
+	case hash := <-ch:
+		t.Fatalf("Unexpected tx hash received: %v", hash)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// Check that the private transaction can't be retrieved
+	if _, _, err := ethcl.TransactionByHash(context.Background(), privateTx.Hash()); err != ethereum.NotFound {
+		t.Fatalf("Private tx retrieved by hash, err %v", err)
+	}
+	if _, _, err := ethcl.TransactionByHash(context.Background(), publicTx.Hash()); err != nil {
+		t.Fatalf("Public tx not retrieved by hash: %v", err)
+	}
+	var raw2 hexutil.Bytes
+	if err := client.Call(&raw2, "eth_getRawTransactionByHash", privateTx.Hash()); err != nil || len(raw2) != 0 {
+		t.Fatalf("Private raw tx retrieved by hash: %x, err %v", raw2, err)
+	}
+	var content map[string]map[string]map[string]struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := client.Call(&content, "txpool_content"); err != nil {
+		t.Fatal(err)
+	}
+	for _, txs := range content["pending"] {
+		for _, tx := range txs {
+			if tx.Hash == privateTx.Hash() {
+				t.Fatal("Private tx in txpool content")
+			}
+		}
+	}
+	var inspect map[string]map[string]map[string]string
+	if err := client.Call(&inspect, "txpool_inspect"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inspect["pending"][testAddr.Hex()]["2"]; ok {
+		t.Fatal("Private tx in txpool inspection")
+	}
+	if _, ok := inspect["pending"][testAddr.Hex()]["3"]; !ok {
+		t.Fatal("Public tx missing from txpool inspection")
+	}
+}
+
 func testCallContract(t *testing.T, client *rpc.Client) {
 	ec := New(client)
 	msg := ethereum.CallMsg{
@@ -241,7 +241,11 @@ func (t *Transaction) resolve(ctx context.Context) (*types.Transaction, *Block)
 		t.index = index
 		return t.tx, t.block
 	}
-	// No finalized transaction, try to retrieve it from the pool
+	// No finalized transaction, try to retrieve it from the pool. The private
+	// transactions are not exposed.
+	if t.r.backend.IsPrivateTx(t.hash) {
+		return nil, nil
+	}
 	t.tx = t.r.backend.GetPoolTransaction(t.hash)
 	return t.tx, nil
 }
@@ -1216,12 +1220,12 @@ type Pending struct {
 }
 
 func (p *Pending) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
-	txs, err := p.r.backend.GetPoolTransactions()
+	txs, err := p.transactions()
 	return hexutil.Uint64(len(txs)), err
 }
 
 func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
-	txs, err := p.r.backend.GetPoolTransactions()
+	txs, err := p.transactions()
 	if err != nil {
 		return nil, err
 	}
@@ -1237,6 +1241,22 @@ func (p *Pending) Transactions(ctx context.Context) (*[]*Transaction, error) {
 	return &ret, nil
 }
 
+// transactions returns the pending transactions of the pool, leaving out the
+// private ones.
+func (p *Pending) transactions() (types.Transactions, error) {
+	txs, err := p.r.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	public := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if !p.r.backend.IsPrivateTx(tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public, nil
+}
+
 func (p *Pending) Account(ctx context.Context, args struct {
 	Address common.Address
 }) *Account {
@@ -192,6 +192,7 @@ func (api *TxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction
 		"queued":  make(map[string]map[string]*RPCTransaction),
 	}
 	pending, queue := api.b.TxPoolContent()
+	pending, queue = publicPoolContent(api.b, pending), publicPoolContent(api.b, queue)
 	curHeader := api.b.CurrentHeader()
 	// Flatten the pending transactions
 	for account, txs := range pending {
@@ -216,6 +217,7 @@ func (api *TxPoolAPI) Content() map[string]map[string]map[string]*RPCTransaction
 func (api *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
 	content := make(map[string]map[string]*RPCTransaction, 2)
 	pending, queue := api.b.TxPoolContentFrom(addr)
+	pending, queue = publicPoolTxs(api.b, pending), publicPoolTxs(api.b, queue)
 	curHeader := api.b.CurrentHeader()
 
 	// Build the pending transactions
@@ -252,6 +254,7 @@ func (api *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 		"queued":  make(map[string]map[string]string),
 	}
 	pending, queue := api.b.TxPoolContent()
+	pending, queue = publicPoolContent(api.b, pending), publicPoolContent(api.b, queue)
 
 	// Define a formatter to flatten a transaction into a string
 	var format = func(tx *types.Transaction) string {
@@ -279,6 +282,31 @@ func (api *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// publicPoolContent removes the private transactions from the pool content. The
+// accounts without public transactions are left out.
+func publicPoolContent(b Backend, content map[common.Address][]*types.Transaction) map[common.Address][]*types.Transaction {
+	public := make(map[common.Address][]*types.Transaction, len(content))
+	for account, txs := range content {
+		if txs = publicPoolTxs(b, txs); len(txs) > 0 {
+			public[account] = txs
+		}
+	}
+	return public
+}
+
+// publicPoolTxs removes the private transactions from the pool transactions of
+// an account. The private transactions are only available to the local block
+// production, they aren't exposed over the RPC APIs.
+func publicPoolTxs(b Backend, txs []*types.Transaction) []*types.Transaction {
+	public := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if !b.IsPrivateTx(tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
 // EthereumAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type EthereumAccountAPI struct {
@@ -606,13 +634,18 @@ func (api *BlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rp
 	if err != nil {
 		return nil, err
 	}
+	return RPCMarshalReceipts(block, receipts, api.b.ChainConfig())
+}
+
+// RPCMarshalReceipts converts the given receipts of the block to the RPC output.
+func RPCMarshalReceipts(block *types.Block, receipts types.Receipts, config *params.ChainConfig) ([]map[string]interface{}, error) {
 	txs := block.Transactions()
 	if len(txs) != len(receipts) {
 		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(txs), len(receipts))
 	}
 
 	// Derive the sender.
-	signer := types.MakeSigner(api.b.ChainConfig(), block.Number(), block.Time())
+	signer := types.MakeSigner(config, block.Number(), block.Time())
 
 	result := make([]map[string]interface{}, len(receipts))
 	for i, receipt := range receipts {
@@ -1440,8 +1473,9 @@ func (api *TransactionAPI) GetTransactionByHash(ctx context.Context, hash common
 	// Try to return an already finalized transaction
 	found, tx, blockHash, blockNumber, index, err := api.b.GetTransaction(ctx, hash)
 	if !found {
-		// No finalized transaction, try to retrieve it from the pool
-		if tx := api.b.GetPoolTransaction(hash); tx != nil {
+		// No finalized transaction, try to retrieve it from the pool. The private
+		// transactions are not exposed.
+		if tx := api.b.GetPoolTransaction(hash); tx != nil && !api.b.IsPrivateTx(hash) {
 			return NewRPCPendingTransaction(tx, api.b.CurrentHeader(), api.b.ChainConfig()), nil
 		}
 		if err == nil {
@@ -1461,7 +1495,7 @@ func (api *TransactionAPI) GetRawTransactionByHash(ctx context.Context, hash com
 	// Retrieve a finalized transaction, or a pooled otherwise
 	found, tx, _, _, _, err := api.b.GetTransaction(ctx, hash)
 	if !found {
-		if tx = api.b.GetPoolTransaction(hash); tx != nil {
+		if tx = api.b.GetPoolTransaction(hash); tx != nil && !api.b.IsPrivateTx(hash) {
 			return tx.MarshalBinary()
 		}
 		if err == nil {
@@ -1881,7 +1915,7 @@ func (api *DebugAPI) GetRawTransaction(ctx context.Context, hash common.Hash) (h
 	// Retrieve a finalized transaction, or a pooled otherwise
 	found, tx, _, _, _, err := api.b.GetTransaction(ctx, hash)
 	if !found {
-		if tx = api.b.GetPoolTransaction(hash); tx != nil {
+		if tx = api.b.GetPoolTransaction(hash); tx != nil && !api.b.IsPrivateTx(hash) {
 			return tx.MarshalBinary()
 		}
 		if err == nil {
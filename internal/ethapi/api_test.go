@@ -46,6 +46,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -609,6 +611,12 @@ func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transactio
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SubscribeTxPoolEvents(events chan<- []txpool.TxEvent) event.Subscription {
+	panic("implement me")
+}
+func (b testBackend) IsPrivateTx(hash common.Hash) bool {
+	return false
+}
 func (b testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
 func (b testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
 func (b testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -1051,6 +1059,143 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestCallMany(t *testing.T) {
+	t.Parallel()
+
+	var (
+		accounts = newAccounts(1)
+		counter  = common.HexToAddress("0xc0")
+		reverter = common.HexToAddress("0xc1")
+		gasprice = common.HexToAddress("0xc2")
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// Increments slot 0 and returns the new value.
+				counter: {Code: common.FromHex("0x6000546001018060005560005260206000f3")},
+				// Reverts without a reason.
+				reverter: {Code: common.FromHex("0x60006000fd")},
+				// Returns the gas price.
+				gasprice: {Code: common.FromHex("0x3a60005260206000f3")},
+			},
+		}
+		backend = newTestBackend(t, 1, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) { b.SetPoS() })
+		api     = NewBlockChainAPI(backend)
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	calls := []CallManyArgs{
+		{TransactionArgs: TransactionArgs{From: &accounts[0].addr, To: &counter}},
+		{TransactionArgs: TransactionArgs{From: &accounts[0].addr, To: &counter}},
+		{
+			TransactionArgs: TransactionArgs{From: &accounts[0].addr, To: &counter},
+			StateOverrides: &StateOverride{
+				counter: OverrideAccount{StateDiff: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(10))}},
+			},
+		},
+		{TransactionArgs: TransactionArgs{From: &accounts[0].addr, To: &reverter}},
+	}
+	results, err := api.CallMany(context.Background(), calls, &latest, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{1, 2, 11} {
+		if have := new(big.Int).SetBytes(results[i].ReturnValue); have.Int64() != want {
+			t.Errorf("call %d: wrong result %d, want %d", i, have, want)
+		}
+		if results[i].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || results[i].GasUsed == 0 {
+			t.Errorf("call %d: wrong status %d, gas used %d", i, results[i].Status, results[i].GasUsed)
+		}
+	}
+	if res := results[3]; res.Status != hexutil.Uint64(types.ReceiptStatusFailed) || res.Error == nil || res.Error.Code != errCodeReverted {
+		t.Errorf("reverted call: wrong status %d, error %+v", res.Status, res.Error)
+	}
+	// The calls don't change the state of the chain.
+	if results, err = api.CallMany(context.Background(), calls[:1], &latest, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if have := new(big.Int).SetBytes(results[0].ReturnValue); have.Int64() != 1 {
+		t.Errorf("state modified by previous request, result %d", have)
+	}
+	if _, err := api.CallMany(context.Background(), nil, &latest, nil, nil); err == nil {
+		t.Error("empty input accepted")
+	}
+	// The invalid calls are reported in their results and don't change the state.
+	invalid := CallManyArgs{TransactionArgs: TransactionArgs{From: &accounts[0].addr, To: &counter, Value: (*hexutil.Big)(big.NewInt(2 * params.Ether))}}
+	results, err = api.CallMany(context.Background(), []CallManyArgs{calls[0], invalid, calls[0]}, &latest, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := results[1]; res.Status != hexutil.Uint64(types.ReceiptStatusFailed) || res.Error == nil || res.Error.Code != errCodeInsufficientFunds {
+		t.Errorf("invalid call: wrong status %d, error %+v", res.Status, res.Error)
+	}
+	if have := new(big.Int).SetBytes(results[2].ReturnValue); have.Int64() != 2 {
+		t.Errorf("call after invalid one: wrong result %d, want 2", have)
+	}
+	// The gas price is derived from the overridden base fee.
+	var (
+		gas     = hexutil.Uint64(100000)
+		baseFee = big.NewInt(2 * params.GWei)
+		priced  = CallManyArgs{TransactionArgs: TransactionArgs{
+			From:                 &accounts[0].addr,
+			To:                   &gasprice,
+			Gas:                  &gas,
+			MaxFeePerGas:         (*hexutil.Big)(big.NewInt(10 * params.GWei)),
+			MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(1)),
+		}}
+	)
+	results, err = api.CallMany(context.Background(), []CallManyArgs{priced}, &latest, nil, &BlockOverrides{BaseFeePerGas: (*hexutil.Big)(baseFee)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := new(big.Int).SetBytes(results[0].ReturnValue), new(big.Int).Add(baseFee, big.NewInt(1)); have.Cmp(want) != 0 {
+		t.Errorf("wrong gas price %v, want %v", have, want)
+	}
+
+	// The calls are traced with the tracers of the calls.
+	statedb, header, err := backend.StateAndHeaderByNumberOrHash(context.Background(), latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTracer := func(index int, tx *types.Transaction) (*CallTracer, error) {
+		var ended bool
+		return &CallTracer{
+			Hooks: &tracing.Hooks{
+				OnTxEnd: func(*types.Receipt, error) { ended = true },
+			},
+			GetResult: func() (json.RawMessage, error) {
+				return json.Marshal(map[string]any{"index": index, "ended": ended})
+			},
+		}, nil
+	}
+	results, err = DoCallMany(context.Background(), NewChainContext(context.Background(), backend), backend.ChainConfig(), calls, statedb, header, nil, nil, 0, 0, newTracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if want := fmt.Sprintf(`{"ended":true,"index":%d}`, i); string(res.Trace) != want {
+			t.Errorf("call %d: wrong trace %s, want %s", i, res.Trace, want)
+		}
+	}
+
+	// The tracers are stopped when the calls time out.
+	stopped := make(chan error, 1)
+	newTracer = func(index int, tx *types.Transaction) (*CallTracer, error) {
+		return &CallTracer{
+			Hooks: &tracing.Hooks{
+				OnTxStart: func(*tracing.VMContext, *types.Transaction, common.Address) { time.Sleep(100 * time.Millisecond) },
+			},
+			GetResult: func() (json.RawMessage, error) { return nil, nil },
+			Stop:      func(err error) { stopped <- err },
+		}, nil
+	}
+	DoCallMany(context.Background(), NewChainContext(context.Background(), backend), backend.ChainConfig(), calls[:1], statedb, header, nil, nil, 10*time.Millisecond, 0, newTracer)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("tracer not stopped on timeout")
+	}
+}
+
 func TestSimulateV1(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
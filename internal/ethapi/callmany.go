@@ -0,0 +1,249 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	gomath "math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxCallManyCalls is the maximum number of calls that can be executed in a
+// single eth_callMany request.
+const maxCallManyCalls = 1000
+
+// CallManyArgs is a single call of eth_callMany. The state overrides are applied
+// right before the call, on top of the state left by the preceding calls.
+type CallManyArgs struct {
+	TransactionArgs
+	StateOverrides *StateOverride `json:"stateOverrides"`
+}
+
+// CallManyResult is the outcome of a single call of eth_callMany.
+type CallManyResult struct {
+	ReturnValue hexutil.Bytes   `json:"returnData"`
+	Logs        []*types.Log    `json:"logs"`
+	GasUsed     hexutil.Uint64  `json:"gasUsed"`
+	Status      hexutil.Uint64  `json:"status"`
+	Error       *callError      `json:"error,omitempty"`
+	Trace       json.RawMessage `json:"trace,omitempty"`
+}
+
+// CallTracer traces a single call executed by DoCallMany.
+type CallTracer struct {
+	Hooks     *tracing.Hooks
+	GetResult func() (json.RawMessage, error)
+	Stop      func(err error) // Aborts the tracing if the timeout is exceeded, optional
+}
+
+// DoCallMany executes the calls in order on top of the given state, each call
+// seeing the state changes of the preceding ones. The overrides are applied before
+// the first call, the overrides of the individual calls right before them. The gas
+// used by all calls is capped by globalGasCap.
+//
+// The calls failing the transaction validation, e.g. due to a wrong nonce, leave
+// the state unchanged and carry the error in their result.
+//
+// If newTracer is non-nil, it's invoked to create the tracer of every call and the
+// results include the traces.
+func DoCallMany(ctx context.Context, chain core.ChainContext, chainConfig *params.ChainConfig, calls []CallManyArgs, statedb *state.StateDB, header *types.Header, overrides *StateOverride, blockOverrides *BlockOverrides, timeout time.Duration, globalGasCap uint64, newTracer func(index int, tx *types.Transaction) (*CallTracer, error)) ([]*CallManyResult, error) {
+	if len(calls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(calls) > maxCallManyCalls {
+		return nil, &clientLimitExceededError{message: "too many calls"}
+	}
+	blockCtx := core.NewEVMBlockContext(header, chain, nil)
+	if blockOverrides != nil {
+		blockOverrides.Apply(&blockCtx)
+	}
+	rules := chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time)
+	precompiles := maps.Clone(vm.ActivePrecompiledContracts(rules))
+	if err := overrides.Apply(statedb, precompiles); err != nil {
+		return nil, err
+	}
+
+	// Setup context so it may be cancelled the calls have completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	gp := new(core.GasPool)
+	if globalGasCap == 0 {
+		gp.AddGas(gomath.MaxUint64)
+	} else {
+		gp.AddGas(globalGasCap)
+	}
+	var (
+		results = make([]*CallManyResult, len(calls))
+		gasUsed uint64
+	)
+	for i, call := range calls {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		if err := call.StateOverrides.Apply(statedb, precompiles); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		args := call.TransactionArgs
+		if args.Nonce == nil {
+			nonce := statedb.GetNonce(args.from())
+			args.Nonce = (*hexutil.Uint64)(&nonce)
+		}
+		if err := args.CallDefaults(gp.Gas(), blockCtx.BaseFee, chainConfig.ChainID); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		var (
+			msg   = args.ToMessage(blockCtx.BaseFee, true, true)
+			tx    = args.ToTransaction(types.DynamicFeeTxType)
+			vmctx = blockCtx
+		)
+		// Lower the basefee to 0 to avoid breaking EVM
+		// invariants (basefee < feecap).
+		if msg.GasPrice.Sign() == 0 {
+			vmctx.BaseFee = new(big.Int)
+		}
+		if msg.BlobGasFeeCap != nil && msg.BlobGasFeeCap.BitLen() == 0 {
+			vmctx.BlobBaseFee = new(big.Int)
+		}
+		var (
+			tracer         *CallTracer
+			tracingStateDB = vm.StateDB(statedb)
+			vmConfig       = vm.Config{NoBaseFee: true}
+		)
+		if newTracer != nil {
+			var err error
+			if tracer, err = newTracer(i, tx); err != nil {
+				return nil, err
+			}
+			vmConfig.Tracer = tracer.Hooks
+			tracingStateDB = state.NewHookedState(statedb, tracer.Hooks)
+		}
+		evm := vm.NewEVM(vmctx, core.NewEVMTxContext(msg), tracingStateDB, chainConfig, vmConfig)
+		if precompiles != nil {
+			evm.SetPrecompiles(precompiles)
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+		stopTracer := func() bool { return false }
+		if tracer != nil && tracer.Stop != nil {
+			stopTracer = context.AfterFunc(ctx, func() {
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					tracer.Stop(errors.New("execution timeout"))
+				}
+			})
+		}
+		if tracer != nil && tracer.Hooks.OnTxStart != nil {
+			tracer.Hooks.OnTxStart(evm.GetVMContext(), tx, msg.From)
+		}
+		result, err := applyMessageWithEVM(ctx, evm, msg, timeout, gp)
+		stopTracer()
+		if err := statedb.Error(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			if evm.Cancelled() {
+				return nil, err
+			}
+			if tracer != nil && tracer.Hooks.OnTxEnd != nil {
+				tracer.Hooks.OnTxEnd(nil, err)
+			}
+			invalid := txValidationError(err)
+			results[i] = &CallManyResult{
+				Logs:  []*types.Log{},
+				Error: &callError{Message: invalid.Message, Code: invalid.Code},
+			}
+			continue
+		}
+		// Update the state with pending changes.
+		var root []byte
+		if chainConfig.IsByzantium(vmctx.BlockNumber) {
+			tracingStateDB.Finalise(true)
+		} else {
+			root = statedb.IntermediateRoot(chainConfig.IsEIP158(vmctx.BlockNumber)).Bytes()
+		}
+		gasUsed += result.UsedGas
+		receipt := core.MakeReceipt(evm, result, statedb, vmctx.BlockNumber, common.Hash{}, tx, gasUsed, root)
+
+		res := &CallManyResult{
+			ReturnValue: result.Return(),
+			Logs:        receipt.Logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+			Status:      hexutil.Uint64(receipt.Status),
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		if result.Failed() {
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				// If the result contains a revert reason, try to unpack it.
+				revertErr := newRevertError(result.Revert())
+				res.Error = &callError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.ErrorData().(string)}
+			} else {
+				res.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		}
+		if tracer != nil {
+			if tracer.Hooks.OnTxEnd != nil {
+				tracer.Hooks.OnTxEnd(receipt, nil)
+			}
+			if res.Trace, err = tracer.GetResult(); err != nil {
+				return nil, fmt.Errorf("call %d: %w", i, err)
+			}
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// CallMany executes the given calls in order on the state of the given block, each
+// call seeing the state changes of the preceding ones. Besides the state overrides
+// applied before the first call, every call can specify its own overrides which are
+// applied right before it.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to execute and retrieve values.
+func (api *BlockChainAPI) CallMany(ctx context.Context, calls []CallManyArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides) ([]*CallManyResult, error) {
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	chain := NewChainContext(ctx, api.b)
+	return DoCallMany(ctx, chain, api.b.ChainConfig(), calls, state, header, overrides, blockOverrides, api.b.RPCEVMTimeout(), api.b.RPCGasCap(), nil)
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// maxBundleTxs is the maximum number of transactions in a bundle.
+	maxBundleTxs = 64
+
+	// maxPendingBundles is the maximum number of bundles kept for inclusion.
+	maxPendingBundles = 4096
+
+	// maxSimulatedBundles is the maximum number of bundles simulated for a block,
+	// the oldest bundles are simulated first. Every simulation runs on a copy of
+	// the state, the bound keeps the building time independent of the pool.
+	maxSimulatedBundles = 100
+)
+
+var (
+	errBundlesDisabled    = errors.New("bundles are disabled")
+	errEmptyBundle        = errors.New("empty bundle")
+	errBundleTooLarge     = errors.New("too many transactions in bundle")
+	errBundleBlobTx       = errors.New("blob transactions are not supported in bundles")
+	errBundleTargetPast   = errors.New("bundle target block already mined")
+	errBundleKnown        = errors.New("bundle already known")
+	errBundlePoolFull     = errors.New("too many pending bundles")
+	errBundleReverted     = errors.New("bundle transaction reverted")
+	errBundleUnprofitable = errors.New("bundle below minimum gas tip")
+)
+
+// Bundle is an ordered list of transactions to be included in a block atomically:
+// either all of them are included in the given order, or none of them.
+type Bundle struct {
+	Txs               []*types.Transaction
+	BlockNumber       uint64        // Number of the block the bundle is valid for
+	MinTimestamp      uint64        // Earliest timestamp of the block, zero if unbounded
+	MaxTimestamp      uint64        // Latest timestamp of the block, zero if unbounded
+	RevertingTxHashes []common.Hash // Transactions allowed to revert without invalidating the bundle
+}
+
+// Hash returns the identifier of the bundle, the hash of its transaction hashes.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// validFor reports whether the bundle can be included in the block.
+func (b *Bundle) validFor(header *types.Header) bool {
+	switch {
+	case b.BlockNumber != header.Number.Uint64():
+		return false
+	case b.MinTimestamp != 0 && header.Time < b.MinTimestamp:
+		return false
+	case b.MaxTimestamp != 0 && header.Time > b.MaxTimestamp:
+		return false
+	}
+	return true
+}
+
+// bundlePool keeps the bundles submitted for inclusion until their target block
+// is mined, or until they fail the simulation.
+type bundlePool struct {
+	mu      sync.Mutex
+	bundles map[common.Hash]*pooledBundle
+	seq     uint64 // submission counter of the bundles
+}
+
+// pooledBundle is a bundle with its position in the submission order.
+type pooledBundle struct {
+	*Bundle
+	seq uint64
+}
+
+func newBundlePool() *bundlePool {
+	return &bundlePool{bundles: make(map[common.Hash]*pooledBundle)}
+}
+
+// add stores the bundle, dropping the ones targeting blocks before the given one.
+func (p *bundlePool) add(bundle *Bundle, next uint64) (common.Hash, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(next)
+	hash := bundle.Hash()
+	if _, ok := p.bundles[hash]; ok {
+		return common.Hash{}, errBundleKnown
+	}
+	if len(p.bundles) >= maxPendingBundles {
+		return common.Hash{}, errBundlePoolFull
+	}
+	p.bundles[hash] = &pooledBundle{Bundle: bundle, seq: p.seq}
+	p.seq++
+	return hash, nil
+}
+
+// valid returns the bundles which can be included in the block, in the order of
+// their submission and at most limit of them.
+func (p *bundlePool) valid(header *types.Header, limit int) []*Bundle {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(header.Number.Uint64())
+	var valid []*pooledBundle
+	for _, bundle := range p.bundles {
+		if bundle.validFor(header) {
+			valid = append(valid, bundle)
+		}
+	}
+	slices.SortFunc(valid, func(a, b *pooledBundle) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	bundles := make([]*Bundle, 0, min(len(valid), limit))
+	for _, bundle := range valid[:min(len(valid), limit)] {
+		bundles = append(bundles, bundle.Bundle)
+	}
+	return bundles
+}
+
+// remove drops the bundle from the pool.
+func (p *bundlePool) remove(hash common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.bundles, hash)
+}
+
+// prune drops the bundles targeting blocks before the given one.
+func (p *bundlePool) prune(number uint64) {
+	for hash, bundle := range p.bundles {
+		if bundle.BlockNumber < number {
+			delete(p.bundles, hash)
+		}
+	}
+}
+
+// SendBundle submits a bundle for inclusion in its target block. Bundles are
+// included ahead of the pool transactions if they pay at least the minimum gas
+// tip per gas used to the fee recipient, and none of their transactions revert
+// unless allowed to. The bundles are only accepted if enabled in the config.
+func (miner *Miner) SendBundle(bundle *Bundle) (common.Hash, error) {
+	if !miner.config.Bundles {
+		return common.Hash{}, errBundlesDisabled
+	}
+	if len(bundle.Txs) == 0 {
+		return common.Hash{}, errEmptyBundle
+	}
+	if len(bundle.Txs) > maxBundleTxs {
+		return common.Hash{}, errBundleTooLarge
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return common.Hash{}, errBundleBlobTx
+		}
+	}
+	next := miner.chain.CurrentHeader().Number.Uint64() + 1
+	if bundle.BlockNumber < next {
+		return common.Hash{}, errBundleTargetPast
+	}
+	return miner.bundles.add(bundle, next)
+}
+
+// simulatedBundle is a bundle with the outcome of its execution on top of the
+// state of the block being built.
+type simulatedBundle struct {
+	bundle *Bundle
+	price  *big.Int // payment to the fee recipient per gas used
+}
+
+// commitBundles includes the profitable bundles valid for the block, ordered by
+// their payment to the fee recipient per gas used. The bundles failing the
+// simulation are dropped from the pool.
+func (miner *Miner) commitBundles(env *environment, minTip *big.Int, interrupt *atomic.Int32) error {
+	if !miner.config.Bundles {
+		return nil
+	}
+	bundles := miner.bundles.valid(env.header, maxSimulatedBundles)
+	if len(bundles) == 0 {
+		return nil
+	}
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	// Simulate the bundles on top of the current state to find the most
+	// profitable ones.
+	var sims []simulatedBundle
+	for _, bundle := range bundles {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		sim := &environment{
+			signer:   env.signer,
+			state:    env.state.Copy(),
+			gasPool:  new(core.GasPool).AddGas(env.gasPool.Gas()),
+			coinbase: env.coinbase,
+			header:   types.CopyHeader(env.header),
+			tcount:   env.tcount,
+		}
+		price, err := miner.applyBundle(sim, bundle, minTip)
+		if err != nil {
+			log.Debug("Dropping bundle failing simulation", "hash", bundle.Hash(), "err", err)
+			miner.bundles.remove(bundle.Hash())
+			continue
+		}
+		sims = append(sims, simulatedBundle{bundle, price})
+	}
+	slices.SortStableFunc(sims, func(a, b simulatedBundle) int {
+		return b.price.Cmp(a.price)
+	})
+	// Include the bundles in order. The ones invalidated by the preceding bundles
+	// are skipped.
+	for _, sim := range sims {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		if err := miner.commitBundle(env, sim.bundle, minTip); err != nil {
+			log.Debug("Bundle skipped", "hash", sim.bundle.Hash(), "err", err)
+		}
+	}
+	return nil
+}
+
+// commitBundle includes the bundle in the block atomically. If any of its
+// transactions fails, the environment is restored to the state before the bundle.
+func (miner *Miner) commitBundle(env *environment, bundle *Bundle, minTip *big.Int) error {
+	var (
+		backup   = env.state.Copy()
+		gas      = env.gasPool.Gas()
+		gasUsed  = env.header.GasUsed
+		txs      = len(env.txs)
+		receipts = len(env.receipts)
+		tcount   = env.tcount
+	)
+	if _, err := miner.applyBundle(env, bundle, minTip); err != nil {
+		env.state, env.witness = backup, backup.Witness()
+		env.gasPool.SetGas(gas)
+		env.header.GasUsed = gasUsed
+		env.txs, env.receipts, env.tcount = env.txs[:txs], env.receipts[:receipts], tcount
+		return err
+	}
+	return nil
+}
+
+// applyBundle executes the transactions of the bundle on the environment, and
+// returns the payment to the fee recipient per gas used. On failure, the
+// environment is left in an undefined state.
+func (miner *Miner) applyBundle(env *environment, bundle *Bundle, minTip *big.Int) (*big.Int, error) {
+	var (
+		balance = env.state.GetBalance(env.coinbase).ToBig()
+		gasUsed = env.header.GasUsed
+	)
+	for _, tx := range bundle.Txs {
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		if err := miner.commitTransaction(env, tx); err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.Hash(), err)
+		}
+		receipt := env.receipts[len(env.receipts)-1]
+		if receipt.Status == types.ReceiptStatusFailed && !slices.Contains(bundle.RevertingTxHashes, tx.Hash()) {
+			return nil, fmt.Errorf("%w: %s", errBundleReverted, tx.Hash())
+		}
+	}
+	price := new(big.Int).Sub(env.state.GetBalance(env.coinbase).ToBig(), balance)
+	price.Div(price, new(big.Int).SetUint64(env.header.GasUsed-gasUsed))
+	if minTip != nil && price.Cmp(minTip) < 0 {
+		return nil, errBundleUnprofitable
+	}
+	return price, nil
+}
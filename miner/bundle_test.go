@@ -0,0 +1,182 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func newBundleTx(nonce uint64, to *common.Address, gas uint64, data []byte) *types.Transaction {
+	signer := types.LatestSigner(params.TestChainConfig)
+	return types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		To:        to,
+		Gas:       gas,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(2 * params.InitialBaseFee),
+		Data:      data,
+	})
+}
+
+func TestBundleInclusion(t *testing.T) {
+	var (
+		recipient = common.HexToAddress("0xdeadbeef")
+		transfer0 = newBundleTx(0, &testUserAddress, params.TxGas, nil)
+		transfer1 = newBundleTx(1, &testUserAddress, params.TxGas, nil)
+		reverting = newBundleTx(1, nil, 100000, common.FromHex("0x60006000fd"))
+	)
+	tests := []struct {
+		name    string
+		bundle  *Bundle
+		want    []common.Hash // transactions expected at the start of the block
+		exclude bool          // whether the bundle is expected to be excluded
+		dropped bool          // whether the bundle is expected to be dropped from the pool
+	}{
+		{
+			name:   "included",
+			bundle: &Bundle{Txs: []*types.Transaction{transfer0, transfer1}, BlockNumber: 1},
+			want:   []common.Hash{transfer0.Hash(), transfer1.Hash()},
+		},
+		{
+			name:    "reverted",
+			bundle:  &Bundle{Txs: []*types.Transaction{transfer0, reverting}, BlockNumber: 1},
+			exclude: true,
+			dropped: true,
+		},
+		{
+			name: "revert allowed",
+			bundle: &Bundle{
+				Txs:               []*types.Transaction{transfer0, reverting},
+				BlockNumber:       1,
+				RevertingTxHashes: []common.Hash{reverting.Hash()},
+			},
+			want: []common.Hash{transfer0.Hash(), reverting.Hash()},
+		},
+		{
+			name:    "other block",
+			bundle:  &Bundle{Txs: []*types.Transaction{transfer0, transfer1}, BlockNumber: 2},
+			exclude: true,
+		},
+		{
+			name:    "expired",
+			bundle:  &Bundle{Txs: []*types.Transaction{transfer0, transfer1}, BlockNumber: 1, MaxTimestamp: 1},
+			exclude: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+			w.config.Bundles = true
+			if _, err := w.SendBundle(tt.bundle); err != nil {
+				t.Fatalf("failed to send bundle: %v", err)
+			}
+			res := w.generateWork(&generateParams{
+				parentHash: b.chain.CurrentBlock().Hash(),
+				timestamp:  uint64(time.Now().Unix()),
+				coinbase:   recipient,
+			}, false)
+			if res.err != nil {
+				t.Fatalf("failed to generate block: %v", res.err)
+			}
+			if dropped := len(w.bundles.bundles) == 0; dropped != tt.dropped {
+				t.Errorf("bundle dropped %v, want %v", dropped, tt.dropped)
+			}
+			txs := res.block.Transactions()
+			if tt.exclude {
+				// Only the pool transaction is expected.
+				if len(txs) != len(pendingTxs) || txs[0].Hash() != pendingTxs[0].Hash() {
+					t.Fatalf("bundle included: have %d transactions", len(txs))
+				}
+				return
+			}
+			if len(txs) != len(tt.want) {
+				t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(tt.want))
+			}
+			for i, hash := range tt.want {
+				if txs[i].Hash() != hash {
+					t.Errorf("transaction %d mismatch: have %x, want %x", i, txs[i].Hash(), hash)
+				}
+			}
+		})
+	}
+}
+
+func TestSendBundle(t *testing.T) {
+	w, _ := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+
+	tx := newBundleTx(0, &testUserAddress, params.TxGas, nil)
+	if _, err := w.SendBundle(&Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 1}); !errors.Is(err, errBundlesDisabled) {
+		t.Fatalf("bundle accepted while disabled: %v", err)
+	}
+	w.config.Bundles = true
+
+	tests := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{&Bundle{BlockNumber: 1}, errEmptyBundle},
+		{&Bundle{Txs: make([]*types.Transaction, maxBundleTxs+1), BlockNumber: 1}, errBundleTooLarge},
+		{&Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 0}, errBundleTargetPast},
+		{&Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 1}, nil},
+		{&Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 1}, errBundleKnown},
+	}
+	for i, tt := range tests {
+		if _, err := w.SendBundle(tt.bundle); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestBundlePoolValid(t *testing.T) {
+	var (
+		pool   = newBundlePool()
+		header = &types.Header{Number: big.NewInt(1)}
+		hashes []common.Hash
+	)
+	for i := 0; i < 10; i++ {
+		tx := newBundleTx(uint64(i), &testUserAddress, params.TxGas, nil)
+		hash, err := pool.add(&Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 1}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	// The oldest bundles are returned first, up to the limit.
+	bundles := pool.valid(header, 4)
+	if len(bundles) != 4 {
+		t.Fatalf("wrong number of bundles: have %d, want 4", len(bundles))
+	}
+	for i, bundle := range bundles {
+		if bundle.Hash() != hashes[i] {
+			t.Errorf("bundle %d out of order", i)
+		}
+	}
+	pool.remove(hashes[0])
+	if bundles = pool.valid(header, 4); bundles[0].Hash() != hashes[1] {
+		t.Errorf("removed bundle returned")
+	}
+}
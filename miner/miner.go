@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -48,6 +49,12 @@ type Config struct {
 	GasCeil             uint64         // Target gas ceiling for mined blocks.
 	GasPrice            *big.Int       // Minimum gas price for mining a transaction
 	Recommit            time.Duration  // The time interval for miner to re-create mining work.
+
+	Bundles bool `toml:",omitempty"` // Accept the transaction bundles of eth_sendBundle for inclusion
+
+	Strategy  string                `toml:",omitempty"` // Block building strategy: price (default), arrival or fair
+	SenderCap uint64                `toml:",omitempty"` // Maximum transactions of a sender in a block for the fair strategy
+	Builder   BlockBuildingStrategy `toml:"-"`          // Custom block building strategy, overrides Strategy
 }
 
 // DefaultConfig contains default settings for miner.
@@ -67,23 +74,35 @@ var DefaultConfig = Config{
 type Miner struct {
 	confMu      sync.RWMutex // The lock used to protect the config fields: GasCeil, GasTip and Extradata
 	config      *Config
+	strategy    BlockBuildingStrategy
 	chainConfig *params.ChainConfig
 	engine      consensus.Engine
 	txpool      *txpool.TxPool
 	chain       *core.BlockChain
 	pending     *pending
-	pendingMu   sync.Mutex // Lock protects the pending block
+	pendingMu   sync.Mutex  // Lock protects the pending block
+	bundles     *bundlePool // Bundles submitted for inclusion
 }
 
 // New creates a new miner with provided config.
 func New(eth Backend, config Config, engine consensus.Engine) *Miner {
+	strategy := config.Builder
+	if strategy == nil {
+		var err error
+		if strategy, err = NewStrategy(config.Strategy, config.SenderCap); err != nil {
+			log.Warn("Invalid block building strategy, using default", "err", err)
+			strategy = priceStrategy{}
+		}
+	}
 	return &Miner{
 		config:      &config,
+		strategy:    strategy,
 		chainConfig: eth.BlockChain().Config(),
 		engine:      engine,
 		txpool:      eth.TxPool(),
 		chain:       eth.BlockChain(),
 		pending:     &pending{},
+		bundles:     newBundlePool(),
 	}
 }
 
@@ -160,7 +160,100 @@ func (t *transactionsByPriceAndNonce) Empty() bool {
 	return len(t.heads) == 0
 }
 
-// Clear removes the entire content of the heap.
-func (t *transactionsByPriceAndNonce) Clear() {
-	t.heads, t.txs = nil, nil
+// txByTime implements the heap interface, ordering the transactions by the time
+// they were first seen.
+type txByTime []*txWithMinerFee
+
+func (s txByTime) Len() int { return len(s) }
+func (s txByTime) Less(i, j int) bool {
+	// If the transactions were seen at the same time, use the hash for
+	// deterministic sorting
+	if s[i].tx.Time.Equal(s[j].tx.Time) {
+		return s[i].tx.Hash.Cmp(s[j].tx.Hash) < 0
+	}
+	return s[i].tx.Time.Before(s[j].tx.Time)
+}
+func (s txByTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *txByTime) Push(x interface{}) {
+	*s = append(*s, x.(*txWithMinerFee))
+}
+
+func (s *txByTime) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*s = old[0 : n-1]
+	return x
+}
+
+// transactionsByTimeAndNonce represents a set of transactions that can return
+// transactions in the order they were first seen, while supporting removing
+// entire batches of transactions for non-executable accounts.
+type transactionsByTimeAndNonce struct {
+	txs     map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
+	heads   txByTime                                     // Next transaction for each unique account (arrival heap)
+	baseFee *uint256.Int                                 // Current base fee
+}
+
+// newTransactionsByTimeAndNonce creates a transaction set that can retrieve
+// arrival sorted transactions in a nonce-honouring way.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func newTransactionsByTimeAndNonce(txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) *transactionsByTimeAndNonce {
+	var baseFeeUint *uint256.Int
+	if baseFee != nil {
+		baseFeeUint = uint256.MustFromBig(baseFee)
+	}
+	heads := make(txByTime, 0, len(txs))
+	for from, accTxs := range txs {
+		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFeeUint)
+		if err != nil {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, wrapped)
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &transactionsByTimeAndNonce{
+		txs:     txs,
+		heads:   heads,
+		baseFee: baseFeeUint,
+	}
+}
+
+// Peek returns the next transaction by arrival time.
+func (t *transactionsByTimeAndNonce) Peek() (*txpool.LazyTransaction, *uint256.Int) {
+	if len(t.heads) == 0 {
+		return nil, nil
+	}
+	return t.heads[0].tx, t.heads[0].fees
+}
+
+// Shift replaces the current first head with the next one from the same account.
+func (t *transactionsByTimeAndNonce) Shift() {
+	acc := t.heads[0].from
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee); err == nil {
+			t.heads[0], t.txs[acc] = wrapped, txs[1:]
+			heap.Fix(&t.heads, 0)
+			return
+		}
+	}
+	heap.Pop(&t.heads)
+}
+
+// Pop removes the first transaction, *not* replacing it with the next one from
+// the same account.
+func (t *transactionsByTimeAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// Empty returns if the arrival heap is empty.
+func (t *transactionsByTimeAndNonce) Empty() bool {
+	return len(t.heads) == 0
 }
@@ -0,0 +1,153 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// Names of the built-in block building strategies.
+const (
+	StrategyPrice   = "price"   // Highest effective tip first
+	StrategyArrival = "arrival" // First come, first served
+	StrategyFair    = "fair"    // Highest effective tip first, capped per sender
+)
+
+// TransactionSet yields the pending transactions in the order they should be
+// included in the block, honouring the nonce order of every sender.
+type TransactionSet interface {
+	// Peek returns the next transaction and its effective miner tip, or nil
+	// if the set is empty.
+	Peek() (*txpool.LazyTransaction, *uint256.Int)
+
+	// Shift replaces the next transaction with the following one from the
+	// same sender.
+	Shift()
+
+	// Pop removes the next transaction along with all the following ones
+	// from the same sender. It's used when a transaction cannot be executed.
+	Pop()
+
+	// Empty returns whether the set has no more transactions.
+	Empty() bool
+}
+
+// BlockBuildingStrategy decides the order in which the miner includes the pending
+// transactions in a block.
+type BlockBuildingStrategy interface {
+	// Order creates the set yielding the given transactions in inclusion order.
+	// The transactions are grouped by sender and sorted by nonce, the map is
+	// reowned by the set. Transactions not paying the base fee must be skipped.
+	Order(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet
+}
+
+// totalOrderStrategy is implemented by the strategies defining the order of all
+// transactions by their own criteria. The miner doesn't include the local
+// transactions ahead of the others for them.
+type totalOrderStrategy interface {
+	totalOrder()
+}
+
+// transactionSkipper is implemented by the sets which need to tell the skipped
+// transactions apart from the included ones.
+type transactionSkipper interface {
+	// skip replaces the next transaction with the following one from the same
+	// sender, without counting it as included.
+	skip()
+}
+
+// NewStrategy creates the built-in block building strategy with the given name.
+// The sender cap is the maximum number of transactions of a sender in a block,
+// used by the fair strategy only.
+func NewStrategy(name string, senderCap uint64) (BlockBuildingStrategy, error) {
+	switch name {
+	case "", StrategyPrice:
+		return priceStrategy{}, nil
+	case StrategyArrival:
+		return arrivalStrategy{}, nil
+	case StrategyFair:
+		if senderCap == 0 {
+			return nil, errors.New("fair strategy requires a sender cap")
+		}
+		return fairStrategy{senderCap: senderCap}, nil
+	default:
+		return nil, fmt.Errorf("unknown block building strategy %q", name)
+	}
+}
+
+// priceStrategy greedily includes the transactions paying the highest effective
+// tip first.
+type priceStrategy struct{}
+
+func (priceStrategy) Order(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+	return newTransactionsByPriceAndNonce(signer, txs, baseFee)
+}
+
+// arrivalStrategy includes the transactions in the order they were first seen.
+type arrivalStrategy struct{}
+
+func (arrivalStrategy) Order(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+	return newTransactionsByTimeAndNonce(txs, baseFee)
+}
+
+func (arrivalStrategy) totalOrder() {}
+
+// fairStrategy includes the transactions paying the highest effective tip first,
+// but at most senderCap transactions of every sender.
+type fairStrategy struct {
+	senderCap uint64
+}
+
+func (s fairStrategy) Order(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TransactionSet {
+	return &cappedTransactions{
+		transactionsByPriceAndNonce: newTransactionsByPriceAndNonce(signer, txs, baseFee),
+		senderCap:                   s.senderCap,
+		shifted:                     make(map[common.Address]uint64),
+	}
+}
+
+// cappedTransactions is a price sorted transaction set dropping the senders
+// after a number of their transactions were taken.
+type cappedTransactions struct {
+	*transactionsByPriceAndNonce
+	senderCap uint64
+	shifted   map[common.Address]uint64 // Number of transactions taken per sender
+}
+
+// Shift replaces the current best head with the next one from the same account,
+// or drops the account if its included transactions reached the cap.
+func (t *cappedTransactions) Shift() {
+	acc := t.heads[0].from
+	if t.shifted[acc]++; t.shifted[acc] >= t.senderCap {
+		t.Pop()
+		return
+	}
+	t.transactionsByPriceAndNonce.Shift()
+}
+
+// skip replaces the current best head with the next one from the same account,
+// without counting it towards the cap.
+func (t *cappedTransactions) skip() {
+	t.transactionsByPriceAndNonce.Shift()
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// strategyTestTx creates a pending transaction with the given nonce, gas price
+// and arrival time.
+func strategyTestTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, price int64, seen int64) *txpool.LazyTransaction {
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), 100, big.NewInt(price), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	tx.SetTime(time.Unix(0, seen))
+	return &txpool.LazyTransaction{
+		Hash:      tx.Hash(),
+		Tx:        tx,
+		Time:      tx.Time(),
+		GasFeeCap: uint256.MustFromBig(tx.GasFeeCap()),
+		GasTipCap: uint256.MustFromBig(tx.GasTipCap()),
+		Gas:       tx.Gas(),
+	}
+}
+
+// drainStrategy orders the transactions with the strategy and returns them in
+// inclusion order.
+func drainStrategy(strategy BlockBuildingStrategy, groups map[common.Address][]*txpool.LazyTransaction) []*txpool.LazyTransaction {
+	var (
+		txset = strategy.Order(types.HomesteadSigner{}, groups, nil)
+		txs   []*txpool.LazyTransaction
+	)
+	for tx, _ := txset.Peek(); tx != nil; tx, _ = txset.Peek() {
+		txs = append(txs, tx)
+		txset.Shift()
+	}
+	return txs
+}
+
+// Tests that the arrival strategy orders the transactions by the time they were
+// first seen regardless of their price, while honouring the nonce order.
+func TestArrivalStrategy(t *testing.T) {
+	t.Parallel()
+
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	var (
+		a0 = strategyTestTx(t, key1, 0, 1, 1)
+		a1 = strategyTestTx(t, key1, 1, 1, 2)
+		a2 = strategyTestTx(t, key1, 2, 1, 5)
+		b0 = strategyTestTx(t, key2, 0, 100, 3)
+		b1 = strategyTestTx(t, key2, 1, 100, 4)
+	)
+	groups := map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(key1.PublicKey): {a0, a1, a2},
+		crypto.PubkeyToAddress(key2.PublicKey): {b0, b1},
+	}
+	txs := drainStrategy(arrivalStrategy{}, groups)
+
+	want := []*txpool.LazyTransaction{a0, a1, b0, b1, a2}
+	if len(txs) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i := range want {
+		if txs[i] != want[i] {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, txs[i].Hash, want[i].Hash)
+		}
+	}
+}
+
+// Tests that the fair strategy orders the transactions by price, but doesn't
+// include more transactions of a sender than the cap.
+func TestFairStrategy(t *testing.T) {
+	t.Parallel()
+
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	var (
+		a0 = strategyTestTx(t, key1, 0, 100, 1)
+		a1 = strategyTestTx(t, key1, 1, 100, 2)
+		a2 = strategyTestTx(t, key1, 2, 100, 3)
+		b0 = strategyTestTx(t, key2, 0, 1, 4)
+	)
+	groups := map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(key1.PublicKey): {a0, a1, a2},
+		crypto.PubkeyToAddress(key2.PublicKey): {b0},
+	}
+	strategy, err := NewStrategy(StrategyFair, 2)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	txs := drainStrategy(strategy, groups)
+
+	want := []*txpool.LazyTransaction{a0, a1, b0}
+	if len(txs) != len(want) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i := range want {
+		if txs[i] != want[i] {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, txs[i].Hash, want[i].Hash)
+		}
+	}
+}
+
+// Tests that the transactions skipped due to a low nonce don't count towards the
+// sender cap of the fair strategy.
+func TestFairStrategySkip(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	var (
+		a0 = strategyTestTx(t, key, 0, 100, 1)
+		a1 = strategyTestTx(t, key, 1, 100, 2)
+		a2 = strategyTestTx(t, key, 2, 100, 3)
+	)
+	groups := map[common.Address][]*txpool.LazyTransaction{
+		crypto.PubkeyToAddress(key.PublicKey): {a0, a1, a2},
+	}
+	strategy, err := NewStrategy(StrategyFair, 2)
+	if err != nil {
+		t.Fatalf("failed to create strategy: %v", err)
+	}
+	txset := strategy.Order(types.HomesteadSigner{}, groups, nil)
+	txset.(transactionSkipper).skip() // a0 already included in the chain
+	for _, want := range []*txpool.LazyTransaction{a1, a2} {
+		if tx, _ := txset.Peek(); tx != want {
+			t.Fatalf("wrong transaction %v, want %x", tx, want.Hash)
+		}
+		txset.Shift()
+	}
+	if !txset.Empty() {
+		t.Fatal("transactions left beyond the cap")
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		senderCap uint64
+		fail      bool
+	}{
+		{"", 0, false},
+		{StrategyPrice, 0, false},
+		{StrategyArrival, 0, false},
+		{StrategyFair, 1, false},
+		{StrategyFair, 0, true},
+		{"random", 0, true},
+	}
+	for _, tt := range tests {
+		if _, err := NewStrategy(tt.name, tt.senderCap); (err != nil) != tt.fail {
+			t.Errorf("strategy %q (cap %d): unexpected error %v", tt.name, tt.senderCap, err)
+		}
+	}
+}
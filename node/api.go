@@ -178,9 +178,13 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		sse:                api.node.config.HTTPSSE,
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			rateLimit:              api.node.config.RPCRateLimit,
+			access:                 api.node.config.RPCAccess,
+			recorder:               api.node.startRPCRecorder(),
 		},
 	}
 	if cors != nil {
@@ -259,6 +263,9 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			rateLimit:              api.node.config.RPCRateLimit,
+			access:                 api.node.config.RPCAccess,
+			recorder:               api.node.startRPCRecorder(),
 		},
 	}
 	if apis != nil {
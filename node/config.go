@@ -137,6 +137,10 @@ type Config struct {
 	// HTTPPathPrefix specifies a path prefix on which http-rpc is to be served.
 	HTTPPathPrefix string `toml:",omitempty"`
 
+	// HTTPSSE enables the subscriptions over the HTTP RPC server, streamed to the
+	// clients as Server-Sent Events.
+	HTTPSSE bool `toml:",omitempty"`
+
 	// AuthAddr is the listening address on which authenticated APIs are provided.
 	AuthAddr string `toml:",omitempty"`
 
@@ -204,6 +208,33 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCRateLimit is the per-client rate limiting of the HTTP and WebSocket RPC
+	// endpoints. The limiting is disabled if the rate is zero.
+	RPCRateLimit rpc.RateLimitConfig `toml:",omitempty"`
+
+	// RPCAccess scopes the methods available over the HTTP and WebSocket RPC
+	// endpoints by the API key of the client. All modules of the endpoint are
+	// available to every client if no keys and public methods are configured.
+	RPCAccess rpc.AccessConfig `toml:",omitempty"`
+
+	// AuthRPCRateLimit is the per-client rate limiting of the authenticated RPC
+	// endpoints. The clients can be identified by the subject of their JWT token
+	// with the "jwt" key. The limiting is disabled if the rate is zero.
+	AuthRPCRateLimit rpc.RateLimitConfig `toml:",omitempty"`
+
+	// AuthRPCAccess scopes the methods available over the authenticated RPC
+	// endpoints. The subject of the JWT token is used as the API key of the
+	// client if no key header is sent.
+	AuthRPCAccess rpc.AccessConfig `toml:",omitempty"`
+
+	// RPCRecordFile is the file the calls served over HTTP and WebSocket are recorded
+	// to as JSON lines. Relative paths are resolved in the instance directory. The
+	// recording is disabled if empty.
+	RPCRecordFile string `toml:",omitempty"`
+
+	// RPCRecordMaxSize is the size in megabytes above which the record file is rotated.
+	RPCRecordMaxSize int `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
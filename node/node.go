@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"hash/crc32"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gofrs/flock"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Node is a container on which services can be registered.
@@ -57,14 +59,15 @@ type Node struct {
 	state         int           // Tracks state of node lifecycle
 
 	lock          sync.Mutex
-	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	http          *httpServer //
-	ws            *httpServer //
-	httpAuth      *httpServer //
-	wsAuth        *httpServer //
-	ipc           *ipcServer  // Stores information about the ipc http server
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	lifecycles    []Lifecycle        // All registered backends, services, and auxiliary services that have a lifecycle
+	rpcAPIs       []rpc.API          // List of APIs currently provided by the node
+	http          *httpServer        //
+	ws            *httpServer        //
+	httpAuth      *httpServer        //
+	wsAuth        *httpServer        //
+	ipc           *ipcServer         // Stores information about the ipc http server
+	inprocHandler *rpc.Server        // In-process RPC request handler to process the API requests
+	rpcRecorder   *lumberjack.Logger // Output of the RPC call recording, nil if disabled
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -393,6 +396,9 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		rateLimit:              n.config.RPCRateLimit,
+		access:                 n.config.RPCAccess,
+		recorder:               n.startRPCRecorder(),
 	}
 
 	initHttp := func(server *httpServer, port int) error {
@@ -404,6 +410,7 @@ func (n *Node) startRPC() error {
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
+			sse:                n.config.HTTPSSE,
 			rpcEndpointConfig:  rpcConfig,
 		}); err != nil {
 			return err
@@ -440,6 +447,8 @@ func (n *Node) startRPC() error {
 			batchItemLimit:         engineAPIBatchItemLimit,
 			batchResponseSizeLimit: engineAPIBatchResponseSizeLimit,
 			httpBodyLimit:          engineAPIBodyLimit,
+			rateLimit:              n.config.AuthRPCRateLimit,
+			access:                 n.config.AuthRPCAccess,
 		}
 		err := server.enableRPC(allAPIs, httpConfig{
 			CorsAllowedOrigins: DefaultAuthCors,
@@ -521,6 +530,35 @@ func (n *Node) stopRPC() {
 	n.wsAuth.stop()
 	n.ipc.stop()
 	n.stopInProc()
+	n.stopRPCRecorder()
+}
+
+// startRPCRecorder opens the output of the RPC call recording if enabled, and
+// returns it.
+func (n *Node) startRPCRecorder() io.Writer {
+	if n.config.RPCRecordFile == "" {
+		return nil
+	}
+	if n.rpcRecorder == nil {
+		path := n.config.ResolvePath(n.config.RPCRecordFile)
+		if path == "" {
+			path = n.config.RPCRecordFile
+		}
+		n.rpcRecorder = &lumberjack.Logger{
+			Filename: path,
+			MaxSize:  n.config.RPCRecordMaxSize,
+		}
+		n.log.Info("Recording RPC calls", "file", path)
+	}
+	return n.rpcRecorder
+}
+
+// stopRPCRecorder closes the output of the RPC call recording.
+func (n *Node) stopRPCRecorder() {
+	if n.rpcRecorder != nil {
+		n.rpcRecorder.Close()
+		n.rpcRecorder = nil
+	}
 }
 
 // startInProc registers all RPC APIs on the inproc server.
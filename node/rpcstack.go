@@ -42,6 +42,7 @@ type httpConfig struct {
 	CorsAllowedOrigins []string
 	Vhosts             []string
 	prefix             string // path prefix on which to mount http handler
+	sse                bool   // whether subscriptions are served as event streams
 	rpcEndpointConfig
 }
 
@@ -58,11 +59,15 @@ type rpcEndpointConfig struct {
 	batchItemLimit         int
 	batchResponseSizeLimit int
 	httpBodyLimit          int
+	rateLimit              rpc.RateLimitConfig // optional per-client rate limiting
+	access                 rpc.AccessConfig    // optional API key scoping of the methods
+	recorder               io.Writer           // optional output of the call recording
 }
 
 type rpcHandler struct {
 	http.Handler
 	server *rpc.Server
+	sse    http.Handler // event stream handler of the subscriptions, nil if disabled
 }
 
 type httpServer struct {
@@ -220,6 +225,10 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if checkPath(r, h.httpConfig.prefix) {
+			if rpc.sse != nil && isEventStream(r) {
+				rpc.sse.ServeHTTP(w, r)
+				return
+			}
 			rpc.ServeHTTP(w, r)
 			return
 		}
@@ -309,14 +318,31 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
+	if config.rateLimit.Rate > 0 {
+		if err := srv.SetRateLimit(config.rateLimit); err != nil {
+			return fmt.Errorf("invalid rpc rate limit: %w", err)
+		}
+	}
+	if len(config.access.Keys) > 0 || len(config.access.Public) > 0 {
+		if err := srv.SetAccess(config.access); err != nil {
+			return fmt.Errorf("invalid rpc access config: %w", err)
+		}
+	}
+	if config.recorder != nil {
+		srv.SetRecorder(config.recorder)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
 	h.httpConfig = config
-	h.httpHandler.Store(&rpcHandler{
+	handler := &rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
 		server:  srv,
-	})
+	}
+	if config.sse {
+		handler.sse = NewHTTPHandlerStack(srv.SSEHandler(), config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret)
+	}
+	h.httpHandler.Store(handler)
 	return nil
 }
 
@@ -344,6 +370,19 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
+	if config.rateLimit.Rate > 0 {
+		if err := srv.SetRateLimit(config.rateLimit); err != nil {
+			return fmt.Errorf("invalid rpc rate limit: %w", err)
+		}
+	}
+	if len(config.access.Keys) > 0 || len(config.access.Public) > 0 {
+		if err := srv.SetAccess(config.access); err != nil {
+			return fmt.Errorf("invalid rpc access config: %w", err)
+		}
+	}
+	if config.recorder != nil {
+		srv.SetRecorder(config.recorder)
+	}
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
 		return err
 	}
@@ -387,6 +426,12 @@ func (h *httpServer) wsAllowed() bool {
 	return h.wsHandler.Load().(*rpcHandler) != nil
 }
 
+// isEventStream checks the header of an http request for a Server-Sent Events
+// stream request.
+func isEventStream(r *http.Request) bool {
+	return rpc.IsSSERequest(r)
+}
+
 // isWebsocket checks the header of an http request for a websocket upgrade request.
 func isWebsocket(r *http.Request) bool {
 	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
@@ -561,6 +606,12 @@ func (w *gzipResponseWriter) Flush() {
 	}
 }
 
+// Unwrap returns the underlying response writer, allowing the handlers to control
+// the connection via http.ResponseController.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.resp
+}
+
 func (w *gzipResponseWriter) close() {
 	if w.gz == nil {
 		return
@@ -17,7 +17,9 @@
 package node
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -449,6 +451,68 @@ func TestJWT(t *testing.T) {
 	srv.stop()
 }
 
+// TestJWTRateLimit checks that the clients of the authenticated endpoint can be
+// rate limited by the subject of their JWT token.
+func TestJWTRateLimit(t *testing.T) {
+	var secret = []byte("secret")
+	cfg := rpcEndpointConfig{
+		jwtSecret: secret,
+		rateLimit: rpc.RateLimitConfig{Rate: 0.001, Burst: 1, Key: rpc.RateLimitKeyJWT},
+	}
+	srv := createAndStartServer(t, &httpConfig{rpcEndpointConfig: cfg}, false, nil, nil)
+	defer srv.stop()
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+
+	call := func(subject string) string {
+		claims := testClaim{"iat": time.Now().Unix(), "sub": subject}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		resp := rpcRequest(t, url, testMethod, "Authorization", "Bearer "+token)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+	if body := call("first"); strings.Contains(body, "error") {
+		t.Fatalf("call within budget failed: %s", body)
+	}
+	if body := call("first"); !strings.Contains(body, "-32005") {
+		t.Fatalf("call above budget not limited: %s", body)
+	}
+	if body := call("second"); strings.Contains(body, "error") {
+		t.Fatalf("call of other subject failed: %s", body)
+	}
+}
+
+func TestJWTAccess(t *testing.T) {
+	var secret = []byte("secret")
+	cfg := rpcEndpointConfig{
+		jwtSecret: secret,
+		access:    rpc.AccessConfig{Keys: []rpc.APIKey{{Key: "engine", Methods: []string{testMethod}}}},
+	}
+	srv := createAndStartServer(t, &httpConfig{rpcEndpointConfig: cfg}, false, nil, nil)
+	defer srv.stop()
+	url := fmt.Sprintf("http://%v", srv.listenAddr())
+
+	call := func(subject string) *http.Response {
+		claims := testClaim{"iat": time.Now().Unix(), "sub": subject}
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		return rpcRequest(t, url, testMethod, "Authorization", "Bearer "+token)
+	}
+	// The subject of the token selects the scope of the client.
+	resp := call("engine")
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || strings.Contains(string(body), "error") {
+		t.Fatalf("call in scope of the subject failed: %d %s", resp.StatusCode, body)
+	}
+	if resp := call("unknown"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong status for unknown subject: %d", resp.StatusCode)
+	}
+}
+
 func TestGzipHandler(t *testing.T) {
 	type gzipTest struct {
 		name    string
@@ -595,6 +659,58 @@ func TestHTTPWriteTimeout(t *testing.T) {
 	})
 }
 
+// TestHTTPEventStream checks that the subscriptions are served as event streams
+// if enabled, outliving the timeouts of the HTTP server.
+func TestHTTPEventStream(t *testing.T) {
+	timeouts := rpc.HTTPTimeouts{ReadTimeout: time.Second, WriteTimeout: time.Second, IdleTimeout: time.Second}
+	request := func(url string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, url+`/?method=test_subscribe&params=["ticks",3]`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("accept", "text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := createAndStartServer(t, &httpConfig{}, false, &wsConfig{}, &timeouts)
+		defer srv.stop()
+
+		resp := request("http://" + srv.listenAddr())
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("content-type"); ct == "text/event-stream" {
+			t.Fatal("event stream served while disabled")
+		}
+	})
+	t.Run("enabled", func(t *testing.T) {
+		srv := createAndStartServer(t, &httpConfig{sse: true}, false, &wsConfig{}, &timeouts)
+		defer srv.stop()
+
+		resp := request("http://" + srv.listenAddr())
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("wrong status %d", resp.StatusCode)
+		}
+		var (
+			lines = bufio.NewScanner(resp.Body)
+			ticks []string
+		)
+		for len(ticks) < 4 && lines.Scan() {
+			if tick, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+				ticks = append(ticks, tick)
+			}
+		}
+		// The first data event carries the subscription ID.
+		if len(ticks) != 4 || ticks[1] != "0" || ticks[3] != "2" {
+			t.Fatalf("wrong events %q, err %v", ticks, lines.Err())
+		}
+	})
+}
+
 func apis() []rpc.API {
 	return []rpc.API{
 		{
@@ -613,3 +729,21 @@ func (s *testService) Greet() string {
 func (s *testService) Sleep() {
 	time.Sleep(1500 * time.Millisecond)
 }
+
+// Ticks sends the given number of notifications, one every 750ms.
+func (s *testService) Ticks(ctx context.Context, n int) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := notifier.Notify(sub.ID, i); err != nil {
+				return
+			}
+			time.Sleep(750 * time.Millisecond)
+		}
+	}()
+	return sub, nil
+}
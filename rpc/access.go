@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key of the client.
+const APIKeyHeader = "X-Api-Key"
+
+var (
+	errMissingAPIKey = errors.New("missing API key")
+	errInvalidAPIKey = errors.New("invalid API key")
+)
+
+// AccessConfig scopes the methods available to the HTTP and WebSocket clients by
+// their API key. The key is sent in the X-Api-Key header, or as the subject of the
+// JWT token on the authenticated endpoints.
+//
+// The methods are listed as namespaces ("eth"), prefixes ("debug_trace*"), exact
+// method names ("admin_peers"), or "*" for all methods. Only the registered methods
+// can be allowed, the scopes restrict the modules of the endpoint further.
+type AccessConfig struct {
+	Keys   []APIKey `toml:",omitempty"` // API keys and their scopes
+	Public []string `toml:",omitempty"` // Methods allowed without an API key, the clients are rejected if empty
+}
+
+// APIKey is the scope of a single API key.
+type APIKey struct {
+	Key     string   // Secret value of the key
+	Methods []string // Methods allowed for the key
+	Rate    float64  `toml:",omitempty"` // Cost units replenished per second for all clients of the key, zero uses the endpoint limit
+	Burst   float64  `toml:",omitempty"` // Maximum budget of the key, defaults to the rate
+}
+
+// accessScope is the set of methods available to a client.
+type accessScope struct {
+	methods []string
+	limiter *rateLimiter // rate limiter shared by the clients of the scope, nil if not limited separately
+	key     string       // rate limiting identifier of the scope
+}
+
+// allows reports whether the method is in the scope. The nil scope allows all methods.
+func (s *accessScope) allows(method string) bool {
+	if s == nil {
+		return true
+	}
+	for _, pattern := range s.methods {
+		switch {
+		case pattern == "*":
+			return true
+		case strings.HasSuffix(pattern, "*"):
+			if strings.HasPrefix(method, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		case strings.Contains(pattern, serviceMethodSeparator):
+			if method == pattern {
+				return true
+			}
+		default:
+			if strings.HasPrefix(method, pattern+serviceMethodSeparator) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accessPolicy maps the API keys to their scopes.
+type accessPolicy struct {
+	keys   map[string]*accessScope
+	public *accessScope // nil if the clients without a key are rejected
+}
+
+// newAccessPolicy creates the policy of the configuration. The separately limited
+// keys use the method costs of the given limiter, if any.
+func newAccessPolicy(config AccessConfig, limiter *rateLimiter) (*accessPolicy, error) {
+	policy := &accessPolicy{keys: make(map[string]*accessScope)}
+	if len(config.Public) > 0 {
+		policy.public = &accessScope{methods: config.Public}
+	}
+	for i, key := range config.Keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("API key %d is empty", i)
+		}
+		if _, ok := policy.keys[key.Key]; ok {
+			return nil, fmt.Errorf("API key %d is duplicated", i)
+		}
+		scope := &accessScope{methods: key.Methods, key: fmt.Sprintf("apikey:%d", i)}
+		if key.Rate > 0 {
+			limit := RateLimitConfig{Rate: key.Rate, Burst: key.Burst}
+			if limiter != nil {
+				limit.Costs = limiter.config.Costs
+			}
+			var err error
+			if scope.limiter, err = newRateLimiter(limit, mclock.System{}); err != nil {
+				return nil, fmt.Errorf("API key %d: %v", i, err)
+			}
+		}
+		policy.keys[key.Key] = scope
+	}
+	return policy, nil
+}
+
+// stop terminates the rate limiters of the separately limited keys.
+func (p *accessPolicy) stop() {
+	for _, scope := range p.keys {
+		if scope.limiter != nil {
+			scope.limiter.stop()
+		}
+	}
+}
+
+// scope returns the scope of the client sending the request.
+func (p *accessPolicy) scope(r *http.Request) (*accessScope, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		key = jwtSubjectFromContext(r.Context())
+	}
+	if key == "" {
+		if p.public == nil {
+			return nil, errMissingAPIKey
+		}
+		return p.public, nil
+	}
+	scope := p.keys[key]
+	if scope == nil {
+		return nil, errInvalidAPIKey
+	}
+	return scope, nil
+}
+
+// clientAccess holds the restrictions of a client connection.
+type clientAccess struct {
+	limiter  *rateLimiter // nil if unlimited
+	limitKey string       // rate limiting identifier of the client
+	scope    *accessScope // allowed methods, nil if unrestricted
+}
+
+// clientAccess returns the restrictions of the client sending the request. An
+// error is returned if the client isn't allowed to connect.
+func (s *Server) clientAccess(r *http.Request) (clientAccess, error) {
+	var access clientAccess
+	if s.rateLimiter != nil {
+		access.limiter, access.limitKey = s.rateLimiter, s.rateLimiter.clientKey(r)
+	}
+	if s.access != nil {
+		scope, err := s.access.scope(r)
+		if err != nil {
+			return clientAccess{}, err
+		}
+		access.scope = scope
+		if scope.limiter != nil {
+			access.limiter, access.limitKey = scope.limiter, scope.key
+		}
+	}
+	return access, nil
+}
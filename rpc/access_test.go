@@ -0,0 +1,140 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessScopeAllows(t *testing.T) {
+	scope := &accessScope{methods: []string{"eth", "debug_trace*", "admin_peers"}}
+	for method, want := range map[string]bool{
+		"eth_call":         true,
+		"ethx_call":        false,
+		"debug_traceBlock": true,
+		"debug_setHead":    false,
+		"admin_peers":      true,
+		"admin_peersX":     false,
+		"admin_addPeer":    false,
+	} {
+		if have := scope.allows(method); have != want {
+			t.Errorf("%s: have %t, want %t", method, have, want)
+		}
+	}
+	if !(&accessScope{methods: []string{"*"}}).allows("admin_addPeer") {
+		t.Error("wildcard scope rejected method")
+	}
+	if !(*accessScope)(nil).allows("admin_addPeer") {
+		t.Error("nil scope rejected method")
+	}
+}
+
+func TestAccessPolicyConfig(t *testing.T) {
+	if _, err := newAccessPolicy(AccessConfig{Keys: []APIKey{{Methods: []string{"eth"}}}}, nil); err == nil {
+		t.Error("empty key accepted")
+	}
+	if _, err := newAccessPolicy(AccessConfig{Keys: []APIKey{{Key: "a"}, {Key: "a"}}}, nil); err == nil {
+		t.Error("duplicate key accepted")
+	}
+}
+
+func TestAccessHTTP(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	err := server.SetAccess(AccessConfig{
+		Keys: []APIKey{
+			{Key: "full", Methods: []string{"*"}},
+			{Key: "echo", Methods: []string{"test_echo"}, Rate: 0.001, Burst: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	dial := func(key string) *Client {
+		var options []ClientOption
+		if key != "" {
+			options = append(options, WithHeader(APIKeyHeader, key))
+		}
+		client, err := DialOptions(context.Background(), httpsrv.URL, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(client.Close)
+		return client
+	}
+	// The clients without a valid key are rejected.
+	for _, key := range []string{"", "wrong"} {
+		var httpErr HTTPError
+		if err := dial(key).Call(nil, "test_null"); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("key %q: wrong error %v", key, err)
+		}
+	}
+	// The methods outside the scope of the key are not found.
+	var rpcErr Error
+	echo := dial("echo")
+	if err := echo.Call(nil, "test_null"); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32601 {
+		t.Fatalf("wrong error for method outside the scope: %v", err)
+	}
+	// The key is rate limited separately.
+	var result echoResult
+	if err := echo.Call(&result, "test_echo", "x", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := echo.Call(&result, "test_echo", "x", 1); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeRateLimited {
+		t.Fatalf("wrong error for exceeded budget: %v", err)
+	}
+	if err := dial("full").Call(nil, "test_null"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAccessPublicWebsocket(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetAccess(AccessConfig{Public: []string{"test_null"}}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer httpsrv.Close()
+
+	client, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(httpsrv.URL, "http:"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.Call(nil, "test_null"); err != nil {
+		t.Fatal(err)
+	}
+	var rpcErr Error
+	if err := client.Call(nil, "test_echo", "x", 1); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32601 {
+		t.Fatalf("wrong error for method outside the scope: %v", err)
+	}
+	// Subscriptions are scoped as well.
+	_, err = client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 1, 1)
+	if err == nil {
+		t.Fatal("subscription outside the scope succeeded")
+	}
+}
@@ -0,0 +1,642 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	balancerMaxHeadLag         = 2 // blocks a backend may lag behind the best one and still be preferred
+)
+
+var (
+	errNoBackend       = errors.New("no RPC backend available")
+	errBalancerClosed  = errors.New("balancer closed")
+	errBackendSubEnded = errors.New("subscription ended by the RPC backend")
+)
+
+// idempotentMethods are the methods which are retried on another backend if the
+// selected backend fails.
+var idempotentMethods = map[string]bool{
+	"eth_blobBaseFee":                         true,
+	"eth_blockNumber":                         true,
+	"eth_call":                                true,
+	"eth_chainId":                             true,
+	"eth_createAccessList":                    true,
+	"eth_estimateGas":                         true,
+	"eth_feeHistory":                          true,
+	"eth_gasPrice":                            true,
+	"eth_getBalance":                          true,
+	"eth_getBlockByHash":                      true,
+	"eth_getBlockByNumber":                    true,
+	"eth_getBlockReceipts":                    true,
+	"eth_getBlockTransactionCountByHash":      true,
+	"eth_getBlockTransactionCountByNumber":    true,
+	"eth_getCode":                             true,
+	"eth_getHeaderByHash":                     true,
+	"eth_getHeaderByNumber":                   true,
+	"eth_getLogs":                             true,
+	"eth_getProof":                            true,
+	"eth_getStorageAt":                        true,
+	"eth_getTransactionByBlockHashAndIndex":   true,
+	"eth_getTransactionByBlockNumberAndIndex": true,
+	"eth_getTransactionByHash":                true,
+	"eth_getTransactionCount":                 true,
+	"eth_getTransactionReceipt":               true,
+	"eth_getUncleCountByBlockHash":            true,
+	"eth_getUncleCountByBlockNumber":          true,
+	"eth_maxPriorityFeePerGas":                true,
+	"eth_syncing":                             true,
+	"net_listening":                           true,
+	"net_peerCount":                           true,
+	"net_version":                             true,
+	"web3_clientVersion":                      true,
+	"web3_sha3":                               true,
+}
+
+// DialBalanced creates a client which spreads the requests over several endpoints.
+// The returned client can be used like any other, e.g. wrapped by ethclient.
+//
+// The backends are health-checked periodically using eth_blockNumber and eth_syncing.
+// Calls are routed to the healthy backend closest to the best known head, preferring
+// the one with the lowest latency. If the backend fails, idempotent calls (reads)
+// are retried on the next best backend, other calls fail with the backend error.
+// Subscriptions are transparently re-established on another backend if their
+// backend fails. Note that notifications may be missed or delivered twice while
+// the subscription is moved.
+//
+// Server-side state such as filters created by eth_newFilter is local to a backend,
+// the methods using it only work as long as the same backend is selected.
+//
+// The given options are applied to all backend connections.
+func DialBalanced(ctx context.Context, endpoints []string, options ...ClientOption) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints given")
+	}
+	cfg := new(clientConfig)
+	for _, opt := range options {
+		opt.applyOption(cfg)
+	}
+	interval := cfg.healthCheckInterval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	connect := func(ctx context.Context) (ServerCodec, error) {
+		return newBalancer(ctx, endpoints, options, interval)
+	}
+	return newClient(ctx, cfg, connect)
+}
+
+// balancerBackend is a single endpoint of the balancer.
+type balancerBackend struct {
+	url     string
+	client  *Client // nil if not connected
+	healthy bool
+	head    uint64
+	latency time.Duration
+}
+
+// balancer is the codec of a load-balancing client. The messages written by the
+// client are forwarded to the backends, the responses and notifications of the
+// backends are read back by the client.
+type balancer struct {
+	options  []ClientOption
+	interval time.Duration
+	incoming chan readOp
+
+	mu       sync.Mutex
+	backends []*balancerBackend
+	subs     map[string]*balancerSub
+
+	closeCh   chan interface{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func newBalancer(ctx context.Context, endpoints []string, options []ClientOption, interval time.Duration) (*balancer, error) {
+	b := &balancer{
+		options:  options,
+		interval: interval,
+		incoming: make(chan readOp),
+		subs:     make(map[string]*balancerSub),
+		closeCh:  make(chan interface{}),
+	}
+	for _, url := range endpoints {
+		b.backends = append(b.backends, &balancerBackend{url: url})
+	}
+	b.checkHealth(ctx)
+	if len(b.candidates()) == 0 {
+		b.close()
+		return nil, fmt.Errorf("%w: all %d endpoints unreachable", errNoBackend, len(endpoints))
+	}
+	b.wg.Add(1)
+	go b.healthLoop()
+	return b, nil
+}
+
+func (b *balancer) peerInfo() PeerInfo {
+	return PeerInfo{Transport: "balancer", RemoteAddr: b.remoteAddr()}
+}
+
+func (b *balancer) remoteAddr() string {
+	urls := make([]string, len(b.backends))
+	for i, be := range b.backends {
+		urls[i] = be.url
+	}
+	return strings.Join(urls, ",")
+}
+
+func (b *balancer) readBatch() ([]*jsonrpcMessage, bool, error) {
+	select {
+	case op := <-b.incoming:
+		return op.msgs, op.batch, nil
+	case <-b.closeCh:
+		return nil, false, errBalancerClosed
+	}
+}
+
+func (b *balancer) writeJSON(ctx context.Context, v interface{}, isErrorResponse bool) error {
+	select {
+	case <-b.closeCh:
+		return errBalancerClosed
+	default:
+	}
+	switch v := v.(type) {
+	case *jsonrpcMessage:
+		go b.handleMsg(ctx, v)
+	case []*jsonrpcMessage:
+		go b.handleBatch(ctx, v)
+	default:
+		return fmt.Errorf("unsupported message type %T", v)
+	}
+	return nil
+}
+
+func (b *balancer) closed() <-chan interface{} {
+	return b.closeCh
+}
+
+func (b *balancer) close() {
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		close(b.closeCh)
+		b.mu.Unlock()
+		b.wg.Wait()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, be := range b.backends {
+			if be.client != nil {
+				be.client.Close()
+			}
+		}
+	})
+}
+
+// deliver hands the messages to the reader of the codec.
+func (b *balancer) deliver(msgs []*jsonrpcMessage, batch bool) {
+	select {
+	case b.incoming <- readOp{msgs, batch}:
+	case <-b.closeCh:
+	}
+}
+
+// handleMsg forwards a single message and delivers the response.
+func (b *balancer) handleMsg(ctx context.Context, msg *jsonrpcMessage) {
+	var resp *jsonrpcMessage
+	switch {
+	case msg.isNotification():
+		args, err := splitParams(msg.Params)
+		if err == nil {
+			err = b.try(ctx, false, func(c *Client) error {
+				return c.Notify(ctx, msg.Method, args...)
+			})
+		}
+		if err != nil {
+			log.Debug("Failed to forward RPC notification", "method", msg.Method, "err", err)
+		}
+		return
+	case msg.isSubscribe():
+		b.subscribe(ctx, msg)
+		return
+	case msg.isUnsubscribe():
+		resp = b.unsubscribe(msg)
+	default:
+		resp = b.call(ctx, msg)
+	}
+	if ctx.Err() == nil {
+		b.deliver([]*jsonrpcMessage{resp}, false)
+	}
+}
+
+// call forwards a method call to the backends and returns the response.
+func (b *balancer) call(ctx context.Context, msg *jsonrpcMessage) *jsonrpcMessage {
+	args, err := splitParams(msg.Params)
+	if err != nil {
+		return msg.errorResponse(&invalidParamsError{err.Error()})
+	}
+	var result json.RawMessage
+	err = b.try(ctx, idempotentMethods[msg.Method], func(c *Client) error {
+		return c.CallContext(ctx, &result, msg.Method, args...)
+	})
+	switch {
+	case errors.Is(err, ErrNoResult):
+		result = null
+	case err != nil:
+		return msg.errorResponse(err)
+	}
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+}
+
+// handleBatch forwards a batch of calls to a single backend and delivers the
+// responses. The batch is retried only if all of its calls are idempotent.
+func (b *balancer) handleBatch(ctx context.Context, msgs []*jsonrpcMessage) {
+	var (
+		resps = make([]*jsonrpcMessage, len(msgs))
+		elems []BatchElem
+		index []int // position of the forwarded calls in the batch
+		retry = true
+	)
+	for i, msg := range msgs {
+		args, err := splitParams(msg.Params)
+		if err != nil {
+			resps[i] = msg.errorResponse(&invalidParamsError{err.Error()})
+			continue
+		}
+		elems = append(elems, BatchElem{Method: msg.Method, Args: args, Result: new(json.RawMessage)})
+		index = append(index, i)
+		retry = retry && idempotentMethods[msg.Method]
+	}
+	if len(elems) > 0 {
+		err := b.try(ctx, retry, func(c *Client) error {
+			for i := range elems {
+				elems[i].Error = nil
+			}
+			return c.BatchCallContext(ctx, elems)
+		})
+		for i, elem := range elems {
+			msg := msgs[index[i]]
+			switch {
+			case err != nil:
+				resps[index[i]] = msg.errorResponse(err)
+			case errors.Is(elem.Error, ErrNoResult):
+				resps[index[i]] = &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: null}
+			case elem.Error != nil:
+				resps[index[i]] = msg.errorResponse(elem.Error)
+			default:
+				resps[index[i]] = &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: *elem.Result.(*json.RawMessage)}
+			}
+		}
+	}
+	if ctx.Err() == nil {
+		b.deliver(resps, true)
+	}
+}
+
+// try runs fn with the client of the preferred backend. If the backend fails and
+// retry is set, fn is run again with the next backends in order of preference.
+func (b *balancer) try(ctx context.Context, retry bool, fn func(*Client) error) error {
+	candidates := b.candidates()
+	if len(candidates) == 0 {
+		return errNoBackend
+	}
+	var err error
+	for _, be := range candidates {
+		if err = fn(be.client); !b.failed(ctx, be, err) || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// failed reports whether the error returned by the backend is a failure of the
+// backend itself, rather than an error response. The failed backends are avoided
+// until their next successful health check.
+func (b *balancer) failed(ctx context.Context, be *balancerBackend, err error) bool {
+	switch {
+	case err == nil || ctx.Err() != nil || errors.Is(err, ErrNoResult):
+		return false
+	case errors.Is(err, ErrNotificationsUnsupported):
+		return true // the method may be available on another backend
+	}
+	var rpcErr Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if be.healthy {
+		log.Debug("RPC backend failed", "url", be.url, "err", err)
+		be.healthy = false
+	}
+	return true
+}
+
+// candidates returns the connected backends in order of preference. The healthy
+// backends close to the best head are preferred, then the lagging ones, then the
+// unhealthy ones. Backends of the same rank are ordered by latency.
+func (b *balancer) candidates() []*balancerBackend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var (
+		list []*balancerBackend
+		head uint64
+	)
+	for _, be := range b.backends {
+		if be.client == nil {
+			continue
+		}
+		list = append(list, be)
+		if be.healthy {
+			head = max(head, be.head)
+		}
+	}
+	rank := func(be *balancerBackend) int {
+		switch {
+		case !be.healthy:
+			return 2
+		case be.head+balancerMaxHeadLag < head:
+			return 1
+		default:
+			return 0
+		}
+	}
+	slices.SortStableFunc(list, func(a, b *balancerBackend) int {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+		return cmp.Compare(a.latency, b.latency)
+	})
+	return list
+}
+
+// healthLoop periodically checks the health of the backends.
+func (b *balancer) healthLoop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), b.interval)
+			b.checkHealth(ctx)
+			cancel()
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// checkHealth connects the disconnected backends, and checks the health of all
+// backends concurrently.
+func (b *balancer) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, be := range b.backends {
+		wg.Add(1)
+		go func(be *balancerBackend) {
+			defer wg.Done()
+			b.checkBackend(ctx, be)
+		}(be)
+	}
+	wg.Wait()
+}
+
+func (b *balancer) checkBackend(ctx context.Context, be *balancerBackend) {
+	b.mu.Lock()
+	client := be.client
+	b.mu.Unlock()
+
+	if client == nil {
+		var err error
+		if client, err = DialOptions(ctx, be.url, b.options...); err != nil {
+			log.Debug("RPC backend unreachable", "url", be.url, "err", err)
+			return
+		}
+		b.mu.Lock()
+		select {
+		case <-b.closeCh:
+			b.mu.Unlock()
+			client.Close()
+			return
+		default:
+			be.client = client
+		}
+		b.mu.Unlock()
+	}
+	var (
+		head    hexutil.Uint64
+		syncing json.RawMessage
+		start   = time.Now()
+	)
+	err := client.BatchCallContext(ctx, []BatchElem{
+		{Method: "eth_blockNumber", Result: &head},
+		{Method: "eth_syncing", Result: &syncing},
+	})
+	latency := time.Since(start)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	healthy := err == nil && string(syncing) == "false"
+	if healthy != be.healthy {
+		log.Debug("RPC backend health changed", "url", be.url, "healthy", healthy, "err", err)
+	}
+	be.healthy, be.latency = healthy, latency
+	if healthy {
+		be.head = uint64(head)
+	}
+}
+
+// balancerSub is a subscription of the client, forwarded from the current backend.
+type balancerSub struct {
+	id        string
+	namespace string
+	args      []interface{}
+	quit      chan struct{}
+}
+
+// subscribe creates the subscription on the preferred backend supporting the
+// notifications, and starts forwarding the notifications.
+func (b *balancer) subscribe(ctx context.Context, msg *jsonrpcMessage) {
+	args, err := splitParams(msg.Params)
+	if err != nil {
+		b.deliver([]*jsonrpcMessage{msg.errorResponse(&invalidParamsError{err.Error()})}, false)
+		return
+	}
+	sub := &balancerSub{
+		id:        string(NewID()),
+		namespace: msg.namespace(),
+		args:      args,
+		quit:      make(chan struct{}),
+	}
+	ch := make(chan json.RawMessage)
+	var backendSub *ClientSubscription
+	err = b.try(ctx, true, func(c *Client) (err error) {
+		backendSub, err = c.Subscribe(ctx, sub.namespace, ch, sub.args...)
+		return err
+	})
+	if err != nil {
+		b.deliver([]*jsonrpcMessage{msg.errorResponse(err)}, false)
+		return
+	}
+	if ctx.Err() != nil {
+		// The client gave up on the subscription, don't leak it.
+		backendSub.Unsubscribe()
+		return
+	}
+	b.mu.Lock()
+	select {
+	case <-b.closeCh:
+		b.mu.Unlock()
+		backendSub.Unsubscribe()
+		return
+	default:
+		b.subs[sub.id] = sub
+		b.wg.Add(1)
+	}
+	b.mu.Unlock()
+
+	// The response must be delivered before the first notification.
+	resp := &jsonrpcMessage{Version: vsn, ID: msg.ID}
+	resp.Result, _ = json.Marshal(sub.id)
+	b.deliver([]*jsonrpcMessage{resp}, false)
+	go b.forward(sub, backendSub, ch)
+}
+
+// forward delivers the notifications of the backend subscription to the client.
+// If the backend fails, the subscription is re-established on another one. If the
+// backend ends the subscription, the client subscription is ended too.
+func (b *balancer) forward(sub *balancerSub, backendSub *ClientSubscription, ch chan json.RawMessage) {
+	defer b.wg.Done()
+	defer b.removeSub(sub.id)
+
+	method := sub.namespace + notificationMethodSuffix
+	for {
+		select {
+		case result := <-ch:
+			params, _ := json.Marshal(subscriptionResult{ID: sub.id, Result: result})
+			b.deliver([]*jsonrpcMessage{{Version: vsn, Method: method, Params: params}}, false)
+
+		case err := <-backendSub.Err():
+			if err == nil {
+				// The backend ended the subscription without an error, there is
+				// nothing to resubscribe to. End the client subscription as well.
+				b.closeSub(sub, errBackendSubEnded)
+				return
+			}
+			log.Debug("RPC backend subscription failed, resubscribing", "id", sub.id, "err", err)
+			if backendSub = b.resubscribe(sub, ch); backendSub == nil {
+				return
+			}
+
+		case <-sub.quit:
+			backendSub.Unsubscribe()
+			return
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// closeSub ends the subscription of the client with the given error. This is
+// the last notification of the subscription.
+func (b *balancer) closeSub(sub *balancerSub, err error) {
+	params, _ := json.Marshal(subscriptionResultEnc{ID: sub.id, Error: errorMessage(err).Error})
+	b.deliver([]*jsonrpcMessage{{Version: vsn, Method: sub.namespace + notificationMethodSuffix, Params: params}}, false)
+}
+
+// resubscribe re-creates the subscription on the preferred backend, retrying until
+// it succeeds or the subscription is canceled.
+func (b *balancer) resubscribe(sub *balancerSub, ch chan json.RawMessage) *ClientSubscription {
+	for {
+		var backendSub *ClientSubscription
+		err := b.try(context.Background(), true, func(c *Client) (err error) {
+			ctx, cancel := context.WithTimeout(context.Background(), b.interval)
+			defer cancel()
+			backendSub, err = c.Subscribe(ctx, sub.namespace, ch, sub.args...)
+			return err
+		})
+		if err == nil {
+			return backendSub
+		}
+		log.Debug("RPC backend resubscription failed", "id", sub.id, "err", err)
+		select {
+		case <-time.After(b.interval):
+		case <-sub.quit:
+			return nil
+		case <-b.closeCh:
+			return nil
+		}
+	}
+}
+
+// unsubscribe cancels a subscription of the client.
+func (b *balancer) unsubscribe(msg *jsonrpcMessage) *jsonrpcMessage {
+	var ids []string
+	if err := json.Unmarshal(msg.Params, &ids); err != nil || len(ids) != 1 {
+		return msg.errorResponse(&invalidParamsError{"expected subscription id"})
+	}
+	sub := b.removeSub(ids[0])
+	if sub == nil {
+		return msg.errorResponse(ErrSubscriptionNotFound)
+	}
+	close(sub.quit)
+	return msg.response(true)
+}
+
+// removeSub removes the subscription from the active ones, returning it if it
+// was still active.
+func (b *balancer) removeSub(id string) *balancerSub {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := b.subs[id]
+	delete(b.subs, id)
+	return sub
+}
+
+// splitParams splits the positional parameters of a message, allowing them to be
+// passed to a Client call as is.
+func splitParams(params json.RawMessage) ([]interface{}, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(params, &list); err != nil {
+		return nil, errors.New("non-array args")
+	}
+	args := make([]interface{}, len(list))
+	for i, param := range list {
+		args[i] = param
+	}
+	return args, nil
+}
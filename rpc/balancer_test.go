@@ -0,0 +1,261 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// balancerTestService is a minimal eth API reporting the name of the backend.
+type balancerTestService struct {
+	name string
+	head uint64
+}
+
+func (s *balancerTestService) BlockNumber() hexutil.Uint64 { return hexutil.Uint64(s.head) }
+func (s *balancerTestService) Syncing() bool               { return false }
+func (s *balancerTestService) ChainId() string             { return s.name }
+func (s *balancerTestService) SendRawTransaction() string  { return s.name }
+
+func (s *balancerTestService) Ticks(ctx context.Context) (*Subscription, error) {
+	notifier, _ := NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			if err := notifier.Notify(sub.ID, s.name); err != nil {
+				return
+			}
+			select {
+			case <-time.After(20 * time.Millisecond):
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+type balancerTestBackend struct {
+	server  *Server
+	httpsrv *httptest.Server
+	url     string
+}
+
+func newBalancerTestBackend(t *testing.T, name string, head uint64) *balancerTestBackend {
+	server := NewServer()
+	if err := server.RegisterName("eth", &balancerTestService{name, head}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	t.Cleanup(func() { httpsrv.Close(); server.Stop() })
+	return &balancerTestBackend{server, httpsrv, "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")}
+}
+
+// kill stops the backend, dropping its connections.
+func (b *balancerTestBackend) kill() {
+	b.server.Stop()
+	b.httpsrv.Close()
+}
+
+func TestBalancerRouting(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = newBalancerTestBackend(t, "a", 10)
+		b = newBalancerTestBackend(t, "b", 20)
+	)
+	client, err := DialBalanced(context.Background(), []string{a.url, b.url}, WithHealthCheckInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// The backend with the best head is preferred.
+	var name string
+	if err := client.Call(&name, "eth_chainId"); err != nil {
+		t.Fatal(err)
+	}
+	if name != "b" {
+		t.Fatalf("call routed to %q, want b", name)
+	}
+	// Non-idempotent calls fail with the backend.
+	b.kill()
+	if err := client.Call(&name, "eth_sendRawTransaction"); err == nil {
+		t.Fatalf("non-idempotent call retried on %q", name)
+	}
+	// The failed backend is avoided afterwards.
+	if err := client.Call(&name, "eth_sendRawTransaction"); err != nil {
+		t.Fatal(err)
+	}
+	if name != "a" {
+		t.Fatalf("call routed to %q, want a", name)
+	}
+}
+
+func TestBalancerRetry(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = newBalancerTestBackend(t, "a", 10)
+		b = newBalancerTestBackend(t, "b", 20)
+	)
+	client, err := DialBalanced(context.Background(), []string{a.url, b.url}, WithHealthCheckInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Idempotent calls are retried on the next backend.
+	b.kill()
+	batch := []BatchElem{
+		{Method: "eth_chainId", Result: new(string)},
+		{Method: "eth_blockNumber", Result: new(hexutil.Uint64)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Error != nil || *batch[0].Result.(*string) != "a" {
+		t.Fatalf("batch routed to %q, err %v", *batch[0].Result.(*string), batch[0].Error)
+	}
+	if batch[1].Error != nil || *batch[1].Result.(*hexutil.Uint64) != 10 {
+		t.Fatalf("wrong head %d, err %v", *batch[1].Result.(*hexutil.Uint64), batch[1].Error)
+	}
+	// Unknown methods are reported by the backend.
+	if err := client.Call(nil, "eth_noSuchMethod"); err == nil {
+		t.Fatal("expected error for unknown method")
+	}
+}
+
+func TestBalancerResubscribe(t *testing.T) {
+	t.Parallel()
+
+	var (
+		a = newBalancerTestBackend(t, "a", 10)
+		b = newBalancerTestBackend(t, "b", 20)
+	)
+	client, err := DialBalanced(context.Background(), []string{a.url, b.url}, WithHealthCheckInterval(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ch := make(chan string)
+	sub, err := client.EthSubscribe(context.Background(), ch, "ticks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	wait := func(want string) {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case name := <-ch:
+				if name == want {
+					return
+				}
+			case err := <-sub.Err():
+				t.Fatal("subscription failed:", err)
+			case <-timeout:
+				t.Fatalf("no notification from %q", want)
+			}
+		}
+	}
+	wait("b")
+	b.kill()
+	wait("a")
+}
+
+func TestBalancerBackendSubscriptionEnd(t *testing.T) {
+	t.Parallel()
+
+	a := newBalancerTestBackend(t, "a", 10)
+	var bal *balancer
+	client, err := newClient(context.Background(), new(clientConfig), func(ctx context.Context) (ServerCodec, error) {
+		var err error
+		bal, err = newBalancer(ctx, []string{a.url}, nil, time.Hour)
+		return bal, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ch := make(chan string)
+	sub, err := client.EthSubscribe(context.Background(), ch, "ticks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+
+	// Closing the backend client ends its subscriptions without an error,
+	// the client subscription must end as well.
+	bal.mu.Lock()
+	backend := bal.backends[0].client
+	bal.mu.Unlock()
+	backend.Close()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-ch:
+		case err := <-sub.Err():
+			if err == nil || err.Error() != errBackendSubEnded.Error() {
+				t.Fatalf("wrong subscription error: %v", err)
+			}
+			return
+		case <-timeout:
+			t.Fatal("client subscription not ended")
+		}
+	}
+}
+
+func TestBalancerBatchInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	a := newBalancerTestBackend(t, "a", 10)
+	bal, err := newBalancer(context.Background(), []string{a.url}, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bal.close()
+
+	go bal.handleBatch(context.Background(), []*jsonrpcMessage{
+		{Version: vsn, ID: json.RawMessage("1"), Method: "eth_chainId"},
+		{Version: vsn, ID: json.RawMessage("2"), Method: "eth_chainId", Params: json.RawMessage(`{"a":1}`)},
+	})
+	resps, batch, err := bal.readBatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch || len(resps) != 2 {
+		t.Fatalf("wrong responses: batch %v, %d responses", batch, len(resps))
+	}
+	if resps[0].Error != nil || string(resps[0].Result) != `"a"` {
+		t.Fatalf("wrong response to valid call: result %s, error %v", resps[0].Result, resps[0].Error)
+	}
+	if resps[1].Error == nil || resps[1].Error.Code != (&invalidParamsError{}).ErrorCode() {
+		t.Fatalf("wrong response to invalid params: %+v", resps[1].Error)
+	}
+}
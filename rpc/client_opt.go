@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -41,6 +42,11 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	access             clientAccess
+	recorder           *recorder
+
+	// Load balancing options
+	healthCheckInterval time.Duration
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -142,3 +148,11 @@ func WithBatchResponseSizeLimit(sizeLimit int) ClientOption {
 		cfg.batchResponseLimit = sizeLimit
 	})
 }
+
+// WithHealthCheckInterval configures how often the backends of a client created by
+// DialBalanced are health-checked.
+func WithHealthCheckInterval(interval time.Duration) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.healthCheckInterval = interval
+	})
+}
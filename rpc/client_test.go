@@ -476,6 +476,37 @@ func TestClientSubscribe(t *testing.T) {
 	}
 }
 
+// This test checks that the subscription closed by the server delivers its error.
+func TestClientSubscribeServerClose(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int)
+	sub, err := client.Subscribe(context.Background(), "nftest", nc, "failingSubscription", 3)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-nc:
+		case err := <-sub.Err():
+			var rpcErr Error
+			if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32000 || err.Error() != "subscription failed" {
+				t.Fatalf("unexpected subscription error: %v", err)
+			}
+			sub.Unsubscribe()
+			return
+		case <-timeout:
+			t.Fatal("subscription not closed by the server")
+		}
+	}
+}
+
 // In this test, the connection drops while Subscribe is waiting for a response.
 func TestClientSubscribeClose(t *testing.T) {
 	t.Parallel()
@@ -55,12 +55,14 @@ var (
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
 	_ Error = new(internalServerError)
+	_ Error = new(rateLimitedError)
 )
 
 const (
 	errcodeDefault          = -32000
 	errcodeTimeout          = -32002
 	errcodeResponseTooLarge = -32003
+	errcodeRateLimited      = -32005
 	errcodePanic            = -32603
 	errcodeMarshalError     = -32603
 
@@ -145,6 +147,15 @@ func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
 
+// rateLimitedError is returned when the client exceeded its rate limit budget.
+type rateLimitedError struct{ method string }
+
+func (e *rateLimitedError) ErrorCode() int { return errcodeRateLimited }
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s", e.method)
+}
+
 // internalServerError is used for server errors during request processing.
 type internalServerError struct {
 	code    int
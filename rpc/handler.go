@@ -65,6 +65,8 @@ type handler struct {
 	allowSubscribe       bool
 	batchRequestLimit    int
 	batchResponseMaxSize int
+	access               clientAccess // rate limiting and method scope of the client
+	recorder             *recorder    // optional recorder of the served calls
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -73,6 +75,13 @@ type handler struct {
 type callProc struct {
 	ctx       context.Context
 	notifiers []*Notifier
+	streamed  bool // whether the encoding of slice results may be streamed to the connection
+}
+
+// resultStreamer is implemented by the codecs which can write the responses with
+// streamed results.
+type resultStreamer interface {
+	streamsResults() bool
 }
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry, batchRequestLimit, batchResponseMaxSize int) *handler {
@@ -283,6 +292,13 @@ func (h *handler) handleNonBatchCall(cp *callProc, msg *jsonrpcMessage) {
 	cp.ctx, cancel = context.WithCancel(cp.ctx)
 	defer cancel()
 
+	// The slice results of single calls are encoded element by element while
+	// writing if supported, the results themselves are still held in memory.
+	// The batches are buffered to enforce their response size limit.
+	if streamer, ok := h.conn.(resultStreamer); ok {
+		cp.streamed = streamer.streamsResults()
+	}
+
 	// Cancel the request context after timeout and send an error response. Since the
 	// running method might not return immediately on timeout, we must wait for the
 	// timeout concurrently with processing the request.
@@ -380,6 +396,18 @@ func (h *handler) cancelServerSubscriptions(err error) {
 	}
 }
 
+// removeSubscription removes the subscription closed by the server and closes its
+// error channel.
+func (h *handler) removeSubscription(id ID) {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+
+	if s := h.serverSubs[id]; s != nil {
+		close(s.err)
+		delete(h.serverSubs, id)
+	}
+}
+
 // startCallProc runs fn in a new goroutine and starts tracking it in the h.calls wait group.
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
@@ -455,9 +483,18 @@ func (h *handler) handleSubscriptionResult(msg *jsonrpcMessage) {
 		h.log.Debug("Dropping invalid subscription message")
 		return
 	}
-	if h.clientSubs[result.ID] != nil {
-		h.clientSubs[result.ID].deliver(result.Result)
+	sub := h.clientSubs[result.ID]
+	if sub == nil {
+		return
+	}
+	// The subscription is closed by the server with an error, it's the last
+	// notification of the subscription.
+	if result.Error != nil {
+		delete(h.clientSubs, result.ID)
+		sub.close(result.Error)
+		return
 	}
+	sub.deliver(result.Result)
 }
 
 // handleCallMsg executes a call message and returns the answer.
@@ -467,10 +504,16 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 	case msg.isNotification():
 		h.handleCall(ctx, msg)
 		h.log.Debug("Served "+msg.Method, "duration", time.Since(start))
+		if h.recorder != nil {
+			h.recorder.record(PeerInfoFromContext(ctx.ctx), msg, nil, start)
+		}
 		return nil
 
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg)
+		if h.recorder != nil {
+			h.recorder.record(PeerInfoFromContext(ctx.ctx), msg, resp, start)
+		}
 		var logctx []any
 		logctx = append(logctx, "reqid", idForLog{msg.ID}, "duration", time.Since(start))
 		if resp.Error != nil {
@@ -503,16 +546,19 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	} else {
 		callb = h.reg.callback(msg.Method)
 	}
-	if callb == nil {
+	if callb == nil || (callb != h.unsubscribeCb && !h.access.scope.allows(msg.Method)) {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	if callb != h.unsubscribeCb && h.rateLimited(msg.Method) {
+		return msg.errorResponse(&rateLimitedError{method: msg.Method})
+	}
 
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	answer := h.runMethod(cp.ctx, msg, callb, args, cp.streamed)
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -543,9 +589,12 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	}
 	namespace := msg.namespace()
 	callb := h.reg.subscription(namespace, name)
-	if callb == nil {
+	if callb == nil || !h.access.scope.allows(msg.Method) {
 		return msg.errorResponse(&subscriptionNotFoundError{namespace, name})
 	}
+	if h.rateLimited(msg.Method) {
+		return msg.errorResponse(&rateLimitedError{method: msg.Method})
+	}
 
 	// Parse subscription name arg too, but remove it before calling the callback.
 	argTypes := append([]reflect.Type{stringType}, callb.argTypes...)
@@ -560,15 +609,31 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
-	return h.runMethod(ctx, msg, callb, args)
+	return h.runMethod(ctx, msg, callb, args, false)
 }
 
-// runMethod runs the Go callback for an RPC method.
-func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+// rateLimited charges the cost of the method to the budget of the client, and
+// reports whether the budget was exceeded.
+func (h *handler) rateLimited(method string) bool {
+	if h.access.limiter == nil {
+		return false
+	}
+	cost, allowed := h.access.limiter.allow(h.access.limitKey, method)
+	updateRateLimitMetrics(method, cost, allowed)
+	return !allowed
+}
+
+// runMethod runs the Go callback for an RPC method. Slice results are streamed if
+// enabled. Note the streaming only saves the buffering of the encoded result, the
+// result itself is returned by the callback in full.
+func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, stream bool) *jsonrpcMessage {
 	result, err := callb.call(ctx, msg.Method, args)
 	if err != nil {
 		return msg.errorResponse(err)
 	}
+	if stream && isStreamable(result) {
+		return msg.streamedResponse(result)
+	}
 	return msg.response(result)
 }
 
@@ -291,7 +291,11 @@ func (s *Server) newHTTPServerConn(r *http.Request, w http.ResponseWriter) Serve
 	dec := json.NewDecoder(conn)
 	dec.UseNumber()
 
-	return NewFuncCodec(conn, encoder, dec.Decode)
+	codec := NewFuncCodec(conn, encoder, dec.Decode).(*jsonCodec)
+	codec.encodeStreamed = func(msg *jsonrpcMessage) error {
+		return encodeStreamedResponse(conn, msg)
+	}
+	return codec
 }
 
 // Close does nothing and always returns nil.
@@ -316,6 +320,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	access, err := s.clientAccess(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 
 	// Create request-scoped context.
 	connInfo := PeerInfo{Transport: "http", RemoteAddr: r.RemoteAddr}
@@ -332,7 +341,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", contentType)
 	codec := s.newHTTPServerConn(r, w)
 	defer codec.close()
-	s.serveSingleRequest(ctx, codec)
+	s.serveSingleRequest(ctx, codec, access)
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -17,8 +17,10 @@
 package rpc
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -41,14 +43,21 @@ const (
 
 var null = json.RawMessage("null")
 
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
 type subscriptionResult struct {
 	ID     string          `json:"subscription"`
 	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonError      `json:"error,omitempty"`
 }
 
 type subscriptionResultEnc struct {
-	ID     string `json:"subscription"`
-	Result any    `json:"result"`
+	ID     string     `json:"subscription"`
+	Result any        `json:"result"`
+	Error  *jsonError `json:"error,omitempty"` // set in the last notification of a subscription closed by the server
 }
 
 type jsonrpcSubscriptionNotification struct {
@@ -66,6 +75,11 @@ type jsonrpcMessage struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 	Error   *jsonError      `json:"error,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
+
+	// streamed is the slice result of a response which is encoded incrementally by
+	// the codec instead of being buffered in Result. It's invalid for the other
+	// messages.
+	streamed reflect.Value
 }
 
 func (msg *jsonrpcMessage) isNotification() bool {
@@ -120,6 +134,64 @@ func (msg *jsonrpcMessage) response(result interface{}) *jsonrpcMessage {
 	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: enc}
 }
 
+// streamedResponse creates a response whose result is encoded by the codec element
+// by element while writing, so the JSON encoding of large results doesn't have to be
+// held in memory. The result value itself is still held by the response, the memory
+// use is only bounded by the method producing it. The result must be streamable.
+func (msg *jsonrpcMessage) streamedResponse(result interface{}) *jsonrpcMessage {
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, streamed: reflect.ValueOf(result)}
+}
+
+// isStreamable reports whether the result can be encoded element by element. This
+// holds for the non-nil slices, except for the byte slices and the types with custom
+// JSON encoding.
+func isStreamable(result interface{}) bool {
+	if result == nil {
+		return false
+	}
+	typ := reflect.TypeOf(result)
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	if typ.Implements(jsonMarshalerType) || typ.Implements(textMarshalerType) {
+		return false
+	}
+	return !reflect.ValueOf(result).IsNil()
+}
+
+// encodeStreamedResponse writes the response with a streamed result to w. The output
+// is the same as the JSON encoding of the buffered response, but only a single element
+// of the result is encoded at a time.
+//
+// The messages can't be interleaved with a partially written response, so the
+// codec holds its write lock until the whole result is written. Subscription
+// notifications on the same connection are delayed for that time.
+func encodeStreamedResponse(w io.Writer, msg *jsonrpcMessage) error {
+	id, err := json.Marshal(msg.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, `{"jsonrpc":"%s","id":%s,"result":[`, vsn, id); err != nil {
+		return err
+	}
+	for i := 0; i < msg.streamed.Len(); i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		elem, err := json.Marshal(msg.streamed.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(elem); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
 func errorMessage(err error) *jsonrpcMessage {
 	msg := &jsonrpcMessage{Version: vsn, ID: null, Error: &jsonError{
 		Code:    errcodeDefault,
@@ -185,6 +257,10 @@ type jsonCodec struct {
 	encMu   sync.Mutex       // guards the encoder
 	encode  encodeFunc       // encoder to allow multiple transports
 	conn    deadlineCloser
+
+	// encodeStreamed writes the responses with streamed results, nil if the
+	// transport doesn't support them.
+	encodeStreamed func(msg *jsonrpcMessage) error
 }
 
 type encodeFunc = func(v interface{}, isErrorResponse bool) error
@@ -217,7 +293,15 @@ func NewCodec(conn Conn) ServerCodec {
 	encode := func(v interface{}, isErrorResponse bool) error {
 		return enc.Encode(v)
 	}
-	return NewFuncCodec(conn, encode, dec.Decode)
+	codec := NewFuncCodec(conn, encode, dec.Decode).(*jsonCodec)
+	codec.encodeStreamed = func(msg *jsonrpcMessage) error {
+		buf := bufio.NewWriter(conn)
+		if err := encodeStreamedResponse(buf, msg); err != nil {
+			return err
+		}
+		return buf.Flush()
+	}
+	return codec
 }
 
 func (c *jsonCodec) peerInfo() PeerInfo {
@@ -256,9 +340,23 @@ func (c *jsonCodec) writeJSON(ctx context.Context, v interface{}, isErrorRespons
 		deadline = time.Now().Add(defaultWriteTimeout)
 	}
 	c.conn.SetWriteDeadline(deadline)
+	if msg, ok := v.(*jsonrpcMessage); ok && msg.streamed.IsValid() {
+		// The partially written response can't be recovered from, the connection
+		// is dropped instead.
+		if err := c.encodeStreamed(msg); err != nil {
+			c.close()
+			return err
+		}
+		return nil
+	}
 	return c.encode(v, isErrorResponse)
 }
 
+// streamsResults reports whether the codec supports the responses with streamed results.
+func (c *jsonCodec) streamsResults() bool {
+	return c.encodeStreamed != nil
+}
+
 func (c *jsonCodec) close() {
 	c.closer.Do(func() {
 		close(c.closeCh)
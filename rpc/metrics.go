@@ -32,6 +32,12 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// rateLimitedMeterName is the prefix of the per-method rate limited request meters.
+	rateLimitedMeterName = "rpc/ratelimited"
+
+	// costCounterName is the prefix of the per-method charged cost counters.
+	costCounterName = "rpc/cost"
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -48,3 +54,13 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Nanoseconds())
 }
+
+// updateRateLimitMetrics tracks the cost charged for a remote RPC call, or the
+// rejection of the call if the client exceeded its budget.
+func updateRateLimitMetrics(method string, cost float64, allowed bool) {
+	if !allowed {
+		metrics.GetOrRegisterMeter(fmt.Sprintf("%s/%s", rateLimitedMeterName, method), nil).Mark(1)
+		return
+	}
+	metrics.GetOrRegisterCounterFloat64(fmt.Sprintf("%s/%s", costCounterName, method), nil).Inc(cost)
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+const (
+	// RateLimitKeyIP identifies the clients by their IP address.
+	RateLimitKeyIP = "ip"
+
+	// RateLimitKeyJWT identifies the clients by the subject of their JWT token,
+	// it's only applicable to the authenticated endpoints.
+	RateLimitKeyJWT = "jwt"
+
+	// RateLimitKeyHeaderPrefix identifies the clients by the value of a HTTP header,
+	// e.g. "header:X-Api-Key".
+	RateLimitKeyHeaderPrefix = "header:"
+)
+
+const (
+	// rateLimitMaxClients is the maximum number of tracked clients. The budget of
+	// the least recently seen client is dropped if a new one is added above it.
+	rateLimitMaxClients = 1024
+
+	// rateLimitPruneInterval is the interval of dropping the fully replenished
+	// budgets, which are equivalent to the budget of a new client.
+	rateLimitPruneInterval = time.Minute
+)
+
+// RateLimitConfig configures the per-client rate limiting of the RPC server. Every
+// client owns a token bucket of Burst cost units which is replenished at Rate units
+// per second. Each method call costs a configurable amount of units, calls exceeding
+// the remaining budget of the client are rejected.
+//
+// Only the HTTP and WebSocket clients are limited, the in-process and IPC clients
+// are trusted.
+type RateLimitConfig struct {
+	Rate  float64            `toml:",omitempty"` // Cost units replenished per second, zero disables the limiting
+	Burst float64            `toml:",omitempty"` // Maximum budget of a client, defaults to the rate
+	Key   string             `toml:",omitempty"` // Client identification: "ip" (default), "jwt" or "header:<name>"
+	Costs map[string]float64 `toml:",omitempty"` // Cost of the methods, "debug_trace*" matches by prefix, unlisted methods cost one unit
+}
+
+// rateLimitBucket is the token bucket of a single client.
+type rateLimitBucket struct {
+	tokens float64
+	last   mclock.AbsTime
+}
+
+// rateLimiter enforces the rate limits of the clients.
+type rateLimiter struct {
+	config RateLimitConfig
+	clock  mclock.Clock
+
+	lock    sync.Mutex
+	buckets lru.BasicLRU[string, *rateLimitBucket]
+	pruner  mclock.Timer // nil once stopped
+}
+
+func newRateLimiter(config RateLimitConfig, clock mclock.Clock) (*rateLimiter, error) {
+	if config.Rate <= 0 {
+		return nil, errors.New("non-positive rate")
+	}
+	if config.Burst == 0 {
+		config.Burst = config.Rate
+	}
+	if config.Burst < 0 {
+		return nil, errors.New("negative burst")
+	}
+	switch {
+	case config.Key == "":
+		config.Key = RateLimitKeyIP
+	case config.Key == RateLimitKeyIP, config.Key == RateLimitKeyJWT:
+	case strings.HasPrefix(config.Key, RateLimitKeyHeaderPrefix) && len(config.Key) > len(RateLimitKeyHeaderPrefix):
+	default:
+		return nil, fmt.Errorf("invalid client key %q", config.Key)
+	}
+	for method, cost := range config.Costs {
+		if cost < 0 {
+			return nil, fmt.Errorf("negative cost for %s", method)
+		}
+	}
+	l := &rateLimiter{
+		config:  config,
+		clock:   clock,
+		buckets: lru.NewBasicLRU[string, *rateLimitBucket](rateLimitMaxClients),
+	}
+	l.pruner = clock.AfterFunc(rateLimitPruneInterval, l.prune)
+	return l, nil
+}
+
+// stop terminates the periodic pruning of the budgets.
+func (l *rateLimiter) stop() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.pruner != nil {
+		l.pruner.Stop()
+		l.pruner = nil
+	}
+}
+
+// clientKey returns the identifier of the client sending the request. The IP
+// address is used if the configured identifier is missing.
+func (l *rateLimiter) clientKey(r *http.Request) string {
+	switch {
+	case l.config.Key == RateLimitKeyJWT:
+		if subject := jwtSubjectFromContext(r.Context()); subject != "" {
+			return "jwt:" + subject
+		}
+	case strings.HasPrefix(l.config.Key, RateLimitKeyHeaderPrefix):
+		if value := r.Header.Get(strings.TrimPrefix(l.config.Key, RateLimitKeyHeaderPrefix)); value != "" {
+			return "header:" + value
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// cost returns the cost of the method. The exact match takes precedence over the
+// longest matching prefix.
+func (l *rateLimiter) cost(method string) float64 {
+	if cost, ok := l.config.Costs[method]; ok {
+		return cost
+	}
+	var (
+		cost    = 1.0
+		longest = -1
+	)
+	for pattern, c := range l.config.Costs {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && len(prefix) > longest && strings.HasPrefix(method, prefix) {
+			cost, longest = c, len(prefix)
+		}
+	}
+	return cost
+}
+
+// allow charges the cost of the method to the budget of the client. It reports
+// the cost and whether the client could afford the call.
+func (l *rateLimiter) allow(key string, method string) (float64, bool) {
+	cost := l.cost(method)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.clock.Now()
+	bucket, ok := l.buckets.Get(key)
+	if !ok {
+		bucket = &rateLimitBucket{tokens: l.config.Burst, last: now}
+		l.buckets.Add(key, bucket)
+	}
+	bucket.tokens = min(l.config.Burst, bucket.tokens+l.config.Rate*time.Duration(now-bucket.last).Seconds())
+	bucket.last = now
+
+	if cost > bucket.tokens {
+		return cost, false
+	}
+	bucket.tokens -= cost
+	return cost, true
+}
+
+// prune drops the budgets which are replenished fully, they are equivalent to
+// the budget of a new client. It reschedules itself until the limiter is stopped.
+func (l *rateLimiter) prune() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.pruner == nil {
+		return
+	}
+	now := l.clock.Now()
+	for _, key := range l.buckets.Keys() {
+		bucket, _ := l.buckets.Peek(key)
+		if bucket.tokens+l.config.Rate*time.Duration(now-bucket.last).Seconds() >= l.config.Burst {
+			l.buckets.Remove(key)
+		}
+	}
+	l.pruner = l.clock.AfterFunc(rateLimitPruneInterval, l.prune)
+}
+
+type jwtSubjectContextKey struct{}
+
+// NewContextWithJWTSubject wraps the given context, adding the subject of the JWT
+// token which authenticated the request. This is used by the authenticating HTTP
+// handlers to make the subject available for the rate limiting.
+func NewContextWithJWTSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, jwtSubjectContextKey{}, subject)
+}
+
+// jwtSubjectFromContext is used to extract the JWT subject from the context.
+func jwtSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(jwtSubjectContextKey{}).(string)
+	return subject
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+func TestRateLimiterBudget(t *testing.T) {
+	var clock mclock.Simulated
+	limiter, err := newRateLimiter(RateLimitConfig{
+		Rate:  2,
+		Burst: 4,
+		Costs: map[string]float64{"debug_*": 2, "debug_trace*": 3, "debug_traceCall": 4},
+	}, &clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for method, want := range map[string]float64{
+		"eth_call":           1,
+		"debug_getBadBlocks": 2,
+		"debug_traceBlock":   3,
+		"debug_traceCall":    4,
+	} {
+		if cost := limiter.cost(method); cost != want {
+			t.Errorf("cost of %s mismatch: have %v, want %v", method, cost, want)
+		}
+	}
+	// The budget is spent, the other clients are unaffected.
+	if _, ok := limiter.allow("a", "debug_traceBlock"); !ok {
+		t.Fatal("call rejected within budget")
+	}
+	if _, ok := limiter.allow("a", "debug_getBadBlocks"); ok {
+		t.Fatal("call allowed above budget")
+	}
+	if _, ok := limiter.allow("b", "debug_traceCall"); !ok {
+		t.Fatal("call of other client rejected")
+	}
+	// The budget is replenished over time, up to the burst.
+	clock.Run(500 * time.Millisecond)
+	if _, ok := limiter.allow("a", "debug_getBadBlocks"); !ok {
+		t.Fatal("call rejected after replenishment")
+	}
+	clock.Run(time.Hour)
+	if _, ok := limiter.allow("a", "debug_traceCall"); !ok {
+		t.Fatal("call rejected after full replenishment")
+	}
+	if _, ok := limiter.allow("a", "eth_call"); ok {
+		t.Fatal("budget replenished above burst")
+	}
+}
+
+func TestRateLimiterClients(t *testing.T) {
+	var clock mclock.Simulated
+	limiter, err := newRateLimiter(RateLimitConfig{Rate: 1, Burst: 2}, &clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer limiter.stop()
+
+	// The number of tracked clients is bounded, the least recently seen one
+	// is dropped first.
+	limiter.allow("first", "eth_call")
+	for i := 0; i < rateLimitMaxClients; i++ {
+		limiter.allow(fmt.Sprintf("client-%d", i), "eth_call")
+	}
+	if n := limiter.buckets.Len(); n != rateLimitMaxClients {
+		t.Fatalf("tracked client count mismatch: have %d, want %d", n, rateLimitMaxClients)
+	}
+	if limiter.buckets.Contains("first") {
+		t.Fatal("least recently seen client not dropped")
+	}
+	// The fully replenished budgets are dropped periodically.
+	limiter.allow("last", "eth_call")
+	limiter.allow("last", "eth_call")
+	clock.Run(rateLimitPruneInterval)
+	if n := limiter.buckets.Len(); n != 0 {
+		t.Fatalf("replenished budgets not pruned, %d left", n)
+	}
+	// No pruning is scheduled once stopped.
+	limiter.stop()
+	if n := clock.ActiveTimers(); n != 0 {
+		t.Fatalf("pruning scheduled after stop: %d timers", n)
+	}
+}
+
+func TestRateLimiterClientKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		header http.Header
+		jwt    string
+		want   string
+	}{
+		{key: RateLimitKeyIP, want: "ip:10.0.0.1"},
+		{key: RateLimitKeyJWT, jwt: "team", want: "jwt:team"},
+		{key: RateLimitKeyJWT, want: "ip:10.0.0.1"},
+		{key: "header:X-Api-Key", header: http.Header{"X-Api-Key": {"secret"}}, want: "header:secret"},
+		{key: "header:X-Api-Key", want: "ip:10.0.0.1"},
+	}
+	for i, test := range tests {
+		limiter, err := newRateLimiter(RateLimitConfig{Rate: 1, Key: test.key}, mclock.System{})
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "10.0.0.1:30303"
+		if test.header != nil {
+			r.Header = test.header
+		}
+		if test.jwt != "" {
+			r = r.WithContext(NewContextWithJWTSubject(r.Context(), test.jwt))
+		}
+		if key := limiter.clientKey(r); key != test.want {
+			t.Errorf("test %d: key mismatch: have %q, want %q", i, key, test.want)
+		}
+	}
+	if _, err := newRateLimiter(RateLimitConfig{Rate: 1, Key: "header:"}, mclock.System{}); err == nil {
+		t.Error("empty header name accepted")
+	}
+	if _, err := newRateLimiter(RateLimitConfig{Rate: 1, Key: "subject"}, mclock.System{}); err == nil {
+		t.Error("unknown client key accepted")
+	}
+}
+
+func TestRateLimitHTTP(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.SetRateLimit(RateLimitConfig{Rate: 0.001, Burst: 2}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := client.Call(nil, "test_null"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	err = client.Call(nil, "test_null")
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeRateLimited {
+		t.Fatalf("wrong error for exceeded budget: %v", err)
+	}
+	// Unknown methods are rejected before the rate limiting.
+	if err := client.Call(nil, "test_unknown"); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32601 {
+		t.Fatalf("wrong error for unknown method: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Recording is a method call served by the server, as written by the recorder.
+// The recordings are written as JSON lines.
+type Recording struct {
+	Time      time.Time       `json:"time"`
+	Duration  time.Duration   `json:"duration"` // Serving time in nanoseconds
+	Transport string          `json:"transport,omitempty"`
+	Client    string          `json:"client,omitempty"` // Remote address of the client
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *RecordingError `json:"error,omitempty"`
+	Redacted  bool            `json:"redacted,omitempty"` // Params and result are left out
+	Streamed  bool            `json:"streamed,omitempty"` // Result is left out, it was streamed to the client
+}
+
+// RecordingError is the error response of a recorded method call.
+type RecordingError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+const (
+	// recorderQueueSize is the number of recordings queued for writing. The
+	// recordings are dropped if the output can't keep up with the calls.
+	recorderQueueSize = 4096
+
+	// recorderDropLogInterval is the minimum time between the warnings about
+	// the dropped recordings.
+	recorderDropLogInterval = time.Minute
+)
+
+// recordRedactedMethods are the methods whose parameters and results are never
+// recorded, as they carry signing material or transactions which must not be
+// disclosed before inclusion. A trailing '*' matches by prefix.
+var recordRedactedMethods = []string{
+	"eth_sign*",
+	"eth_sendPrivateRawTransaction",
+	"eth_sendBundle",
+	"personal_*",
+	"account_*",
+}
+
+// recordRedacted reports whether the parameters and results of the method must
+// be left out of the recording.
+func recordRedacted(method string) bool {
+	for _, pattern := range recordRedactedMethods {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if method == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder writes the served method calls to the output. The recordings are
+// encoded and written in the background, so the serving of the calls is never
+// blocked by the output.
+type recorder struct {
+	enc     *json.Encoder
+	queue   chan *Recording
+	dropped atomic.Uint64 // Number of recordings dropped since the last warning
+	quit    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newRecorder(w io.Writer) *recorder {
+	r := &recorder{
+		enc:   json.NewEncoder(w),
+		queue: make(chan *Recording, recorderQueueSize),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// record queues the served call and its response for writing. The recording is
+// dropped if the queue is full.
+func (r *recorder) record(info PeerInfo, msg *jsonrpcMessage, resp *jsonrpcMessage, start time.Time) {
+	rec := &Recording{
+		Time:      start,
+		Duration:  time.Since(start),
+		Transport: info.Transport,
+		Client:    info.RemoteAddr,
+		Method:    msg.Method,
+	}
+	redacted := recordRedacted(msg.Method)
+	rec.Redacted = redacted
+	if !redacted {
+		rec.Params = msg.Params
+	}
+	if resp != nil {
+		// The streamed results aren't buffered, recording them would defeat
+		// the streaming.
+		rec.Streamed = resp.streamed.IsValid()
+		if !redacted {
+			rec.Result = resp.Result
+		}
+		if resp.Error != nil {
+			rec.Error = &RecordingError{Code: resp.Error.Code, Message: resp.Error.Message}
+			if resp.Error.Data != nil && !redacted {
+				rec.Error.Data, _ = json.Marshal(resp.Error.Data)
+			}
+		}
+	}
+	select {
+	case <-r.quit:
+	case r.queue <- rec:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// loop writes the queued recordings to the output until the recorder is closed.
+func (r *recorder) loop() {
+	defer close(r.done)
+
+	var lastDropLog time.Time
+	write := func(rec *Recording) {
+		if err := r.enc.Encode(rec); err != nil {
+			log.Warn("Failed to record RPC call", "method", rec.Method, "err", err)
+		}
+		if time.Since(lastDropLog) > recorderDropLogInterval {
+			if dropped := r.dropped.Swap(0); dropped > 0 {
+				log.Warn("Dropped RPC call recordings", "count", dropped)
+				lastDropLog = time.Now()
+			}
+		}
+	}
+	for {
+		select {
+		case rec := <-r.queue:
+			write(rec)
+		case <-r.quit:
+			// Flush the recordings queued before closing.
+			for {
+				select {
+				case rec := <-r.queue:
+					write(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// close flushes the queued recordings and stops the recorder.
+func (r *recorder) close() {
+	r.once.Do(func() { close(r.quit) })
+	<-r.done
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerRecorder(t *testing.T) {
+	var (
+		server = newTestServer()
+		output = new(bytes.Buffer)
+	)
+	defer server.Stop()
+	server.SetRecorder(output)
+	server.RegisterName("stream", streamTestService{})
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	var items []echoResult
+	if err := client.Call(&items, "stream_items", 3); err != nil {
+		t.Fatal(err)
+	}
+	// Flush the recordings written in the background.
+	server.Stop()
+
+	var recs []*Recording
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		rec := new(Recording)
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			t.Fatalf("invalid recording %q: %v", scanner.Text(), err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("wrong number of recordings: %d", len(recs))
+	}
+	if rec := recs[0]; rec.Method != "test_echo" || rec.Transport != "http" || rec.Client == "" ||
+		string(rec.Params) != `["hello",10,{"S":"world"}]` || string(rec.Result) != `{"String":"hello","Int":10,"Args":{"S":"world"}}` || rec.Error != nil {
+		t.Errorf("wrong call recording: %+v", rec)
+	}
+	if rec := recs[1]; rec.Method != "test_returnError" || rec.Result != nil || rec.Error == nil ||
+		rec.Error.Code != 444 || rec.Error.Message != "testError" || string(rec.Error.Data) != `"testError data"` {
+		t.Errorf("wrong error recording: %+v", rec)
+	}
+	if rec := recs[2]; rec.Method != "stream_items" || !rec.Streamed || rec.Result != nil || rec.Error != nil {
+		t.Errorf("wrong streamed recording: %+v", rec)
+	}
+}
+
+func TestRecordRedacted(t *testing.T) {
+	tests := []struct {
+		method   string
+		redacted bool
+	}{
+		{"eth_sign", true},
+		{"eth_signTransaction", true},
+		{"eth_sendPrivateRawTransaction", true},
+		{"eth_sendBundle", true},
+		{"personal_unlockAccount", true},
+		{"eth_sendRawTransaction", false},
+		{"eth_call", false},
+		{"test_echo", false},
+	}
+	for _, test := range tests {
+		if redacted := recordRedacted(test.method); redacted != test.redacted {
+			t.Errorf("%s: redacted %v, want %v", test.method, redacted, test.redacted)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -54,6 +55,9 @@ type Server struct {
 	batchItemLimit     int
 	batchResponseLimit int
 	httpBodyLimit      int
+	rateLimiter        *rateLimiter
+	access             *accessPolicy
+	recorder           *recorder
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -89,6 +93,48 @@ func (s *Server) SetHTTPBodyLimit(limit int) {
 	s.httpBodyLimit = limit
 }
 
+// SetRateLimit enables the per-client rate limiting of the HTTP and WebSocket
+// requests. The calls exceeding the budget of the client are rejected with a
+// dedicated error code.
+//
+// This method should be called before processing any requests via ServeHTTP or
+// WebsocketHandler.
+func (s *Server) SetRateLimit(config RateLimitConfig) error {
+	limiter, err := newRateLimiter(config, mclock.System{})
+	if err != nil {
+		return err
+	}
+	s.rateLimiter = limiter
+	return nil
+}
+
+// SetRecorder enables the recording of the served method calls. Every call is
+// written to w as a JSON line, along with its response, timing and client. The
+// recordings are written in the background and dropped if w can't keep up. The
+// parameters and results of the methods handling secrets, e.g. eth_sign*, are
+// never recorded, and neither are the results streamed to the clients.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetRecorder(w io.Writer) {
+	s.recorder = newRecorder(w)
+}
+
+// SetAccess enables the API key based scoping of the methods available to the HTTP
+// and WebSocket clients. The API keys with a rate limit use the method costs of the
+// rate limiting of the server.
+//
+// This method should be called after SetRateLimit and before processing any requests
+// via ServeHTTP or WebsocketHandler.
+func (s *Server) SetAccess(config AccessConfig) error {
+	policy, err := newAccessPolicy(config, s.rateLimiter)
+	if err != nil {
+		return err
+	}
+	s.access = policy
+	return nil
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either an RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -103,6 +149,12 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, clientAccess{})
+}
+
+// serveCodec serves the codec like ServeCodec, applying the given restrictions
+// of the client to the requests.
+func (s *Server) serveCodec(codec ServerCodec, access clientAccess) {
 	defer codec.close()
 
 	if !s.trackCodec(codec) {
@@ -114,6 +166,8 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		recorder:           s.recorder,
+		access:             access,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -140,8 +194,8 @@ func (s *Server) untrackCodec(codec ServerCodec) {
 
 // serveSingleRequest reads and processes a single RPC request from the given codec. This
 // is used to serve HTTP connections. Subscriptions and reverse calls are not allowed in
-// this mode.
-func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
+// this mode. The given restrictions of the client are applied to the request.
+func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, access clientAccess) {
 	// Don't serve if server is stopped.
 	if !s.run.Load() {
 		return
@@ -149,6 +203,8 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
 	h.allowSubscribe = false
+	h.recorder = s.recorder
+	h.access = access
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -192,6 +248,15 @@ func (s *Server) Stop() {
 		for codec := range s.codecs {
 			codec.close()
 		}
+		if s.recorder != nil {
+			s.recorder.close()
+		}
+		if s.rateLimiter != nil {
+			s.rateLimiter.stop()
+		}
+		if s.access != nil {
+			s.access.stop()
+		}
 	}
 }
 
@@ -220,7 +285,7 @@ func (s *RPCService) Modules() map[string]string {
 // the current method call.
 type PeerInfo struct {
 	// Transport is name of the protocol used by the client.
-	// This can be "http", "ws" or "ipc".
+	// This can be "http", "ws", "ipc", "binary" or "sse".
 	Transport string
 
 	// Address of client. This will usually contain the IP address and port.
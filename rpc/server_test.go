@@ -19,8 +19,12 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
+	"math"
 	"net"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -202,3 +206,94 @@ func TestServerBatchResponseSizeLimit(t *testing.T) {
 		}
 	}
 }
+
+type streamTestService struct{}
+
+func (streamTestService) Items(n int) []echoResult {
+	items := make([]echoResult, n)
+	for i := range items {
+		items[i] = echoResult{String: "<item>", Int: i}
+	}
+	return items
+}
+
+func (streamTestService) Nil() []string  { return nil }
+func (streamTestService) Bytes() []byte  { return []byte{1, 2} }
+func (streamTestService) Bad() []float64 { return []float64{1, math.NaN()} }
+
+// This test checks that streamed slice results are encoded like buffered ones.
+func TestServerStreamedResults(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer()
+	defer server.Stop()
+	server.RegisterName("test", streamTestService{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("can't listen:", err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var (
+		input = bufio.NewReader(conn)
+		items = streamTestService{}.Items(3)
+	)
+	for _, test := range []struct {
+		request string
+		result  any
+	}{
+		{`{"jsonrpc":"2.0","id":"<1>","method":"test_items","params":[3]}`, items},
+		{`{"jsonrpc":"2.0","id":2,"method":"test_items","params":[0]}`, []echoResult{}},
+		{`{"jsonrpc":"2.0","id":3,"method":"test_nil"}`, nil},
+		{`{"jsonrpc":"2.0","id":4,"method":"test_bytes"}`, []byte{1, 2}},
+	} {
+		var msg jsonrpcMessage
+		json.Unmarshal([]byte(test.request), &msg)
+		want, _ := json.Marshal(msg.response(test.result))
+
+		conn.Write([]byte(test.request + "\n"))
+		resp, err := input.ReadBytes('\n')
+		if err != nil {
+			t.Fatal("read error:", err)
+		}
+		if !bytes.Equal(bytes.TrimSpace(resp), want) {
+			t.Fatalf("wrong response: %s\nwant: %s", resp, want)
+		}
+	}
+
+	// The results are streamed over HTTP and WebSocket too.
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	wssrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wssrv.Close()
+	for _, url := range []string{httpsrv.URL, "ws:" + strings.TrimPrefix(wssrv.URL, "http:")} {
+		client, err := DialContext(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []echoResult
+		err = client.Call(&result, "test_items", 1000)
+		client.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", url, err)
+		}
+		if len(result) != 1000 || result[999].Int != 999 {
+			t.Fatalf("%s: wrong result", url)
+		}
+	}
+
+	// Encoding failures after the response was partially written drop the connection.
+	conn.Write([]byte(`{"jsonrpc":"2.0","id":5,"method":"test_bad"}` + "\n"))
+	if _, err := io.ReadAll(input); err != nil {
+		t.Fatal("connection not closed:", err)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sseContentType       = "text/event-stream"
+	sseKeepaliveInterval = 30 * time.Second
+)
+
+var errSSEClosed = errors.New("event stream closed")
+
+// SSEHandler returns a handler that serves subscriptions as Server-Sent Events
+// streams over plain HTTP.
+//
+// The subscription is created by a GET request carrying the subscribe method and
+// its parameters in the query, e.g.
+//
+//	GET /?method=eth_subscribe&params=["logs",{"address":"0x..."}]
+//
+// On success, the stream starts with a 'subscription' event carrying the ID of the
+// subscription, followed by a data event for every notification. The subscription
+// is canceled when the client closes the request. If the server ends the
+// subscription, an 'error' event is sent and the stream is closed. If the subscription can't be
+// created, the JSON-RPC error response is returned with status 400.
+func (s *Server) SSEHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		access, err := s.clientAccess(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		msg, err := parseSSERequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		codec := newSSECodec(w, r, msg)
+		// The stream outlives the read timeout of the HTTP server, which would
+		// otherwise cancel the request.
+		codec.rc.SetReadDeadline(time.Time{})
+		go codec.keepalive()
+		s.serveCodec(codec, access)
+	})
+}
+
+// IsSSERequest reports whether the HTTP request asks for a Server-Sent Events stream.
+func IsSSERequest(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), sseContentType)
+}
+
+// parseSSERequest creates the subscription call from the query of the request.
+func parseSSERequest(r *http.Request) (*jsonrpcMessage, error) {
+	query := r.URL.Query()
+	method := query.Get("method")
+	if !strings.HasSuffix(method, subscribeMethodSuffix) {
+		return nil, fmt.Errorf("invalid subscription method %q", method)
+	}
+	params := json.RawMessage(query.Get("params"))
+	if !json.Valid(params) {
+		return nil, errors.New("invalid subscription params")
+	}
+	return &jsonrpcMessage{Version: vsn, ID: json.RawMessage("1"), Method: method, Params: params}, nil
+}
+
+// sseCodec serves a single subscription as an event stream. The only message
+// read from the codec is the subscription call, the responses are written as
+// events to the HTTP response.
+type sseCodec struct {
+	req  *http.Request
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	info PeerInfo
+
+	call      *jsonrpcMessage // subscription call, nil once read
+	closeCh   chan interface{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex // guards the writes to w
+	started bool       // whether the stream headers were written
+	done    bool       // whether the codec was closed, no writes are allowed afterwards
+}
+
+func newSSECodec(w http.ResponseWriter, r *http.Request, call *jsonrpcMessage) *sseCodec {
+	info := PeerInfo{Transport: "sse", RemoteAddr: r.RemoteAddr}
+	info.HTTP.Version = r.Proto
+	info.HTTP.Host = r.Host
+	info.HTTP.Origin = r.Header.Get("Origin")
+	info.HTTP.UserAgent = r.Header.Get("User-Agent")
+	return &sseCodec{
+		req:     r,
+		w:       w,
+		rc:      http.NewResponseController(w),
+		info:    info,
+		call:    call,
+		closeCh: make(chan interface{}),
+	}
+}
+
+func (c *sseCodec) peerInfo() PeerInfo {
+	return c.info
+}
+
+func (c *sseCodec) remoteAddr() string {
+	return c.info.RemoteAddr
+}
+
+func (c *sseCodec) readBatch() ([]*jsonrpcMessage, bool, error) {
+	if call := c.call; call != nil {
+		c.call = nil
+		return []*jsonrpcMessage{call}, false, nil
+	}
+	// There is nothing else to read, block until the client goes away.
+	select {
+	case <-c.req.Context().Done():
+		return nil, false, io.EOF
+	case <-c.closeCh:
+		return nil, false, errSSEClosed
+	}
+}
+
+func (c *sseCodec) writeJSON(ctx context.Context, v interface{}, isErrorResponse bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done {
+		return errSSEClosed
+	}
+	switch v := v.(type) {
+	case *jsonrpcMessage:
+		if c.started {
+			return nil // stray response, e.g. of a timed out call
+		}
+		if v.Error != nil {
+			// The subscription failed, respond with the error and end the request.
+			c.w.Header().Set("content-type", contentType)
+			c.w.WriteHeader(http.StatusBadRequest)
+			err := json.NewEncoder(c.w).Encode(v)
+			c.closeLocked()
+			return err
+		}
+		hdr := c.w.Header()
+		hdr.Set("content-type", sseContentType)
+		hdr.Set("cache-control", "no-cache")
+		hdr.Set("x-accel-buffering", "no")
+		c.w.WriteHeader(http.StatusOK)
+		c.started = true
+		return c.writeEvent("event: subscription\ndata: %s\n\n", v.Result)
+
+	case *jsonrpcSubscriptionNotification:
+		if v.Params.Error != nil {
+			// The subscription is closed by the server, report the error
+			// and end the stream.
+			blob, err := json.Marshal(v.Params.Error)
+			if err != nil {
+				return err
+			}
+			if err := c.writeEvent("event: error\ndata: %s\n\n", blob); err != nil {
+				return err
+			}
+			c.closeLocked()
+			return nil
+		}
+		result, err := json.Marshal(v.Params.Result)
+		if err != nil {
+			return err
+		}
+		return c.writeEvent("data: %s\n\n", result)
+
+	default:
+		return fmt.Errorf("unsupported event stream message type %T", v)
+	}
+}
+
+// writeEvent writes an event to the stream and flushes it to the client. The
+// caller must hold c.mu.
+func (c *sseCodec) writeEvent(format string, args ...interface{}) error {
+	c.rc.SetWriteDeadline(time.Now().Add(defaultWriteTimeout))
+	if _, err := fmt.Fprintf(c.w, format, args...); err != nil {
+		c.closeLocked()
+		return err
+	}
+	if err := c.rc.Flush(); err != nil {
+		c.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// keepalive periodically sends a comment on the stream, preventing the proxies
+// from closing the idle connections.
+func (c *sseCodec) keepalive() {
+	ticker := time.NewTicker(sseKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.started && !c.done {
+				c.writeEvent(": keepalive\n\n")
+			}
+			c.mu.Unlock()
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+func (c *sseCodec) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+// closeLocked marks the codec closed. No writes to the response happen afterwards,
+// allowing the HTTP handler to return.
+func (c *sseCodec) closeLocked() {
+	c.done = true
+	c.closeOnce.Do(func() { close(c.closeCh) })
+}
+
+func (c *sseCodec) closed() <-chan interface{} {
+	return c.closeCh
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sseRequest(t *testing.T, base, method, params string) *http.Response {
+	t.Helper()
+	query := url.Values{"method": {method}, "params": {params}}
+	req, err := http.NewRequest(http.MethodGet, base+"?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("accept", sseContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSSESubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		server  = newTestServer()
+		service = &notificationTestService{unsubscribed: make(chan string, 1)}
+	)
+	defer server.Stop()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server.SSEHandler())
+	defer httpsrv.Close()
+
+	resp := sseRequest(t, httpsrv.URL, "eth_subscribe", `["someSubscription",3,10]`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("content-type"); ct != sseContentType {
+		t.Fatalf("wrong content type %q", ct)
+	}
+	var (
+		lines = bufio.NewScanner(resp.Body)
+		subid string
+		items []int
+	)
+	for len(items) < 3 && lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "event: subscription":
+			if !lines.Scan() || !strings.HasPrefix(lines.Text(), "data: ") {
+				t.Fatalf("missing subscription ID, got %q", lines.Text())
+			}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(lines.Text(), "data: ")), &subid); err != nil {
+				t.Fatal(err)
+			}
+		case strings.HasPrefix(line, "data: "):
+			if subid == "" {
+				t.Fatal("notification before subscription event")
+			}
+			var item int
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &item); err != nil {
+				t.Fatal(err)
+			}
+			items = append(items, item)
+		}
+	}
+	if len(items) != 3 || items[0] != 10 || items[1] != 11 || items[2] != 12 {
+		t.Fatalf("wrong notifications %v", items)
+	}
+
+	// Closing the stream must cancel the subscription.
+	resp.Body.Close()
+	select {
+	case id := <-service.unsubscribed:
+		if id != subid {
+			t.Fatalf("wrong subscription canceled %q, want %q", id, subid)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not canceled after closing the stream")
+	}
+}
+
+func TestSSESubscriptionErrors(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	defer server.Stop()
+	httpsrv := httptest.NewServer(server.SSEHandler())
+	defer httpsrv.Close()
+
+	tests := []struct {
+		method, params string
+		wantCode       int // JSON-RPC error code, zero if rejected before subscribing
+	}{
+		{"test_echo", `[]`, 0},
+		{"nftest_subscribe", `[`, 0},
+		{"nftest_subscribe", `["noSuchSubscription"]`, -32601},
+		{"nftest_subscribe", `["someSubscription","x"]`, -32602},
+	}
+	for i, test := range tests {
+		resp := sseRequest(t, httpsrv.URL, test.method, test.params)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("test %d: wrong status %d", i, resp.StatusCode)
+		}
+		if test.wantCode != 0 {
+			var msg jsonrpcMessage
+			if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+				t.Fatalf("test %d: invalid error response: %v", i, err)
+			}
+			if msg.Error == nil || msg.Error.Code != test.wantCode {
+				t.Errorf("test %d: wrong error %+v", i, msg.Error)
+			}
+		}
+		resp.Body.Close()
+	}
+}
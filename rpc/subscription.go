@@ -108,6 +108,8 @@ type Notifier struct {
 	buffer       []any
 	callReturned bool
 	activated    bool
+	closed       bool
+	closeErr     error
 }
 
 // CreateSubscription returns a new subscription that is coupled to the
@@ -138,6 +140,9 @@ func (n *Notifier) Notify(id ID, data any) error {
 	} else if n.sub.ID != id {
 		panic("Notify with wrong ID")
 	}
+	if n.closed {
+		return nil
+	}
 	if n.activated {
 		return n.send(n.sub, data)
 	}
@@ -145,12 +150,48 @@ func (n *Notifier) Notify(id ID, data any) error {
 	return nil
 }
 
+// Close ends the subscription with the given error, which is delivered to the client
+// in the last notification of the subscription. Notifications sent after closing the
+// subscription are dropped.
+//
+// Server callbacks should close the subscription if they can't continue serving it,
+// rather than silently stopping the notifications.
+func (n *Notifier) Close(id ID, err error) error {
+	n.mu.Lock()
+	if n.sub == nil {
+		n.mu.Unlock()
+		panic("can't Close before subscription is created")
+	} else if n.sub.ID != id {
+		n.mu.Unlock()
+		panic("Close with wrong ID")
+	}
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed, n.closeErr = true, err
+
+	var sendErr error
+	if n.activated {
+		sendErr = n.sendError(n.sub, err)
+	}
+	n.mu.Unlock()
+
+	// The handler takes the notifier lock while holding the subscription
+	// lock, so the subscription is removed after releasing it.
+	n.h.removeSubscription(id)
+	return sendErr
+}
+
 // takeSubscription returns the subscription (if one has been created). No subscription can
 // be created after this call.
 func (n *Notifier) takeSubscription() *Subscription {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 	n.callReturned = true
+	if n.closed {
+		return nil
+	}
 	return n.sub
 }
 
@@ -167,6 +208,9 @@ func (n *Notifier) activate() error {
 		}
 	}
 	n.activated = true
+	if n.closed {
+		return n.sendError(n.sub, n.closeErr)
+	}
 	return nil
 }
 
@@ -182,6 +226,18 @@ func (n *Notifier) send(sub *Subscription, data any) error {
 	return n.h.conn.writeJSON(context.Background(), &msg, false)
 }
 
+func (n *Notifier) sendError(sub *Subscription, err error) error {
+	msg := jsonrpcSubscriptionNotification{
+		Version: vsn,
+		Method:  n.namespace + notificationMethodSuffix,
+		Params: subscriptionResultEnc{
+			ID:    string(sub.ID),
+			Error: errorMessage(err).Error,
+		},
+	}
+	return n.h.conn.writeJSON(context.Background(), &msg, false)
+}
+
 // A Subscription is created by a notifier and tied to that notifier. The client can use
 // this subscription to wait for an unsubscribe request for the client, see Err().
 type Subscription struct {
@@ -55,13 +55,18 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 		CheckOrigin:     wsHandshakeValidator(allowedOrigins),
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access, err := s.clientAccess(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
 		codec := newWebsocketCodec(conn, r.Host, r.Header, wsDefaultReadLimit)
-		s.ServeCodec(codec, 0)
+		s.serveCodec(codec, access)
 	})
 }
 
@@ -296,8 +301,20 @@ func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readL
 	encode := func(v interface{}, isErrorResponse bool) error {
 		return conn.WriteJSON(v)
 	}
+	codec := NewFuncCodec(conn, encode, conn.ReadJSON).(*jsonCodec)
+	codec.encodeStreamed = func(msg *jsonrpcMessage) error {
+		w, err := conn.NextWriter(websocket.TextMessage)
+		if err != nil {
+			return err
+		}
+		if err := encodeStreamedResponse(w, msg); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}
 	wc := &websocketCodec{
-		jsonCodec:    NewFuncCodec(conn, encode, conn.ReadJSON).(*jsonCodec),
+		jsonCodec:    codec,
 		conn:         conn,
 		pingReset:    make(chan struct{}, 1),
 		pongReceived: make(chan struct{}),
@@ -153,6 +153,7 @@ type Database struct {
 	diskdb  ethdb.Database               // Persistent storage for matured trie nodes
 	tree    *layerTree                   // The group for all known layers
 	freezer ethdb.ResettableAncientStore // Freezer for storing trie histories, nil possible in tests
+	indexer *historyIndexer              // Indexer of state histories, nil possible in tests and verkle
 	lock    sync.RWMutex                 // Lock to prevent mutations from happening at the same time
 }
 
@@ -221,6 +222,11 @@ func (db *Database) repairHistory() error {
 	}
 	db.freezer = freezer
 
+	// Index the state histories for direct historic state lookup. The index
+	// is not maintained in verkle mode as historic state is not supported.
+	if !db.isVerkle {
+		db.indexer = newHistoryIndexer(db.diskdb, db.freezer, db.readOnly)
+	}
 	// Reset the entire state histories if the trie database is not initialized
 	// yet. This action is necessary because these state histories are not
 	// expected to exist without an initialized trie database.
@@ -231,7 +237,7 @@ func (db *Database) repairHistory() error {
 			log.Crit("Failed to retrieve head of state history", "err", err)
 		}
 		if frozen != 0 {
-			err := db.freezer.Reset()
+			err := db.resetHistory()
 			if err != nil {
 				log.Crit("Failed to reset state histories", "err", err)
 			}
@@ -241,7 +247,7 @@ func (db *Database) repairHistory() error {
 	}
 	// Truncate the extra state histories above in freezer in case it's not
 	// aligned with the disk layer. It might happen after a unclean shutdown.
-	pruned, err := truncateFromHead(db.diskdb, db.freezer, id)
+	pruned, err := db.truncateHistoryFromHead(id)
 	if err != nil {
 		log.Crit("Failed to truncate extra state histories", "err", err)
 	}
@@ -352,7 +358,7 @@ func (db *Database) Enable(root common.Hash) error {
 	// mappings can be huge and might take a while to clear
 	// them, just leave them in disk and wait for overwriting.
 	if db.freezer != nil {
-		if err := db.freezer.Reset(); err != nil {
+		if err := db.resetHistory(); err != nil {
 			return err
 		}
 	}
@@ -406,7 +412,7 @@ func (db *Database) Recover(root common.Hash) error {
 		db.tree.reset(dl)
 	}
 	rawdb.DeleteTrieJournal(db.diskdb)
-	_, err := truncateFromHead(db.diskdb, db.freezer, dl.stateID())
+	_, err := db.truncateHistoryFromHead(dl.stateID())
 	if err != nil {
 		return err
 	}
@@ -463,9 +469,38 @@ func (db *Database) Close() error {
 	if db.freezer == nil {
 		return nil
 	}
+	if db.indexer != nil {
+		db.indexer.close()
+	}
 	return db.freezer.Close()
 }
 
+// truncateHistoryFromHead removes the extra state histories from the head
+// along with their index entries. It returns the number of items removed.
+func (db *Database) truncateHistoryFromHead(nhead uint64) (int, error) {
+	if db.indexer != nil {
+		return db.indexer.truncateFromHead(nhead)
+	}
+	return truncateFromHead(db.diskdb, db.freezer, nhead)
+}
+
+// truncateHistoryFromTail removes the extra state histories from the tail
+// along with their index entries. It returns the number of items removed.
+func (db *Database) truncateHistoryFromTail(ntail uint64) (int, error) {
+	if db.indexer != nil {
+		return db.indexer.truncateFromTail(ntail)
+	}
+	return truncateFromTail(db.diskdb, db.freezer, ntail)
+}
+
+// resetHistory discards the entire state histories along with their index.
+func (db *Database) resetHistory() error {
+	if db.indexer != nil {
+		return db.indexer.reset()
+	}
+	return db.freezer.Reset()
+}
+
 // Size returns the current storage size of the memory cache in front of the
 // persistent database layer.
 func (db *Database) Size() (diffs common.StorageSize, nodes common.StorageSize) {
@@ -515,7 +550,7 @@ func (db *Database) modifyAllowed() error {
 // End: State ID of the last history for the query. 0 implies the last available
 // object is selected as the ending point. Note end is included in the query.
 func (db *Database) AccountHistory(address common.Address, start, end uint64) (*HistoryStats, error) {
-	return accountHistory(db.freezer, address, start, end)
+	return accountHistory(db.freezer, db.indexer, address, start, end)
 }
 
 // StorageHistory inspects the storage history within the specified range.
@@ -528,7 +563,7 @@ func (db *Database) AccountHistory(address common.Address, start, end uint64) (*
 //
 // Note, slot refers to the hash of the raw slot key.
 func (db *Database) StorageHistory(address common.Address, slot common.Hash, start uint64, end uint64) (*HistoryStats, error) {
-	return storageHistory(db.freezer, address, slot, start, end)
+	return storageHistory(db.freezer, db.indexer, address, slot, start, end)
 }
 
 // HistoryRange returns the block numbers associated with earliest and latest
@@ -39,4 +39,8 @@ var (
 	// errStateUnrecoverable is returned if state is required to be reverted to
 	// a destination without associated state history available.
 	errStateUnrecoverable = errors.New("state is unrecoverable")
+
+	// errStateHistoryPruned is returned if a historic state is requested but
+	// the associated state histories have already been pruned.
+	errStateHistoryPruned = errors.New("state history pruned")
 )
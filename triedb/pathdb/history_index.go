@@ -0,0 +1,373 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// historyIndexBatch is the maximum number of state histories indexed in a
+// single database batch during the background indexing.
+const historyIndexBatch = 1000
+
+// historyIndexer maintains a secondary index of the state histories, which
+// maps each mutated account and storage slot to the list of state histories
+// (and the associated block numbers) in which it was mutated:
+//
+//	address -> [id_1, id_2, ..., id_n]
+//	address + slot hash -> [id_1, id_2, ..., id_n]
+//
+// Every index entry is stored as an individual database key, suffixed by the
+// big-endian encoded state id. The entries belonging to a single state are
+// therefore sorted by id, allowing the history of a state within a range to be
+// located directly by iteration, instead of decoding every history object.
+//
+// The index is built incrementally as the state histories are written, and is
+// truncated together with them. The histories that exist before the indexer
+// is enabled (or that are skipped due to an unclean shutdown) are indexed in
+// the background, the unindexed part is served by the linear scan meanwhile.
+type historyIndexer struct {
+	disk     ethdb.KeyValueStore
+	freezer  ethdb.ResettableAncientStore
+	readOnly bool
+
+	head uint64       // The id of the latest indexed state history
+	lock sync.RWMutex // Lock for protecting the index mutation
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newHistoryIndexer constructs the indexer for state histories and starts the
+// background indexing for the histories not yet indexed.
+func newHistoryIndexer(disk ethdb.KeyValueStore, freezer ethdb.ResettableAncientStore, readOnly bool) *historyIndexer {
+	indexer := &historyIndexer{
+		disk:     disk,
+		freezer:  freezer,
+		readOnly: readOnly,
+		closeCh:  make(chan struct{}),
+	}
+	if head := rawdb.ReadStateHistoryIndexHead(disk); head != nil {
+		indexer.head = *head
+	}
+	if !readOnly {
+		indexer.wg.Add(1)
+		go indexer.loop()
+	}
+	return indexer
+}
+
+// close terminates the background indexing and waits for it to exit.
+func (i *historyIndexer) close() {
+	select {
+	case <-i.closeCh:
+	default:
+		close(i.closeCh)
+	}
+	i.wg.Wait()
+}
+
+// indexed returns the id of the latest indexed state history. All the state
+// histories in range [tail+1, indexed] are guaranteed to be indexed.
+func (i *historyIndexer) indexed() uint64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.head
+}
+
+// loop indexes the state histories not yet indexed in the background, until
+// the index is aligned with the state histories in the freezer.
+func (i *historyIndexer) loop() {
+	defer i.wg.Done()
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		count  uint64
+	)
+	for {
+		select {
+		case <-i.closeCh:
+			return
+		default:
+		}
+		done, n, err := i.indexBatch()
+		if err != nil {
+			log.Error("Failed to index state history", "err", err)
+			return
+		}
+		count += n
+		if done {
+			if count != 0 {
+				log.Info("Indexed state history", "histories", count, "elapsed", common.PrettyDuration(time.Since(start)))
+			}
+			return
+		}
+		if time.Since(logged) > time.Second*8 {
+			log.Info("Indexing state history", "indexed", count, "head", i.indexed(), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+}
+
+// indexBatch indexes a batch of state histories on top of the current index
+// head. The returned flag indicates whether the index is fully aligned with
+// the state histories in freezer.
+func (i *historyIndexer) indexBatch() (bool, uint64, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	tail, err := i.freezer.Tail()
+	if err != nil {
+		return false, 0, err
+	}
+	head, err := i.freezer.Ancients()
+	if err != nil {
+		return false, 0, err
+	}
+	// The histories below the tail are already pruned, nothing to index.
+	batch := i.disk.NewBatch()
+	if i.head < tail {
+		i.head = tail
+		rawdb.WriteStateHistoryIndexHead(batch, tail)
+	}
+	var n uint64
+	for id := i.head + 1; id <= head && n < historyIndexBatch && batch.ValueSize() < ethdb.IdealBatchSize; id++ {
+		h, err := readHistory(i.freezer, id)
+		if err != nil {
+			return false, 0, err
+		}
+		writeHistoryIndex(batch, id, h)
+		i.head = id
+		n += 1
+	}
+	rawdb.WriteStateHistoryIndexHead(batch, i.head)
+	if err := batch.Write(); err != nil {
+		return false, 0, err
+	}
+	return i.head >= head, n, nil
+}
+
+// index adds the state history with the given id into the index. The history
+// is only indexed if it's the successor of the current index head, otherwise
+// it's left for the background indexing.
+func (i *historyIndexer) index(id uint64, h *history) error {
+	if i.readOnly {
+		return nil
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if id != i.head+1 {
+		return nil
+	}
+	batch := i.disk.NewBatch()
+	writeHistoryIndex(batch, id, h)
+	rawdb.WriteStateHistoryIndexHead(batch, id)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	i.head = id
+	return nil
+}
+
+// truncateFromHead removes the state histories above the given new head from
+// the freezer, along with their index entries. It returns the number of items
+// removed from the head.
+func (i *historyIndexer) truncateFromHead(nhead uint64) (int, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.head > nhead {
+		// The histories above the freezer head are already gone (e.g. due
+		// to an unclean shutdown), their index entries are left in the
+		// database and will be overwritten by the new histories.
+		ohead, err := i.freezer.Ancients()
+		if err != nil {
+			return 0, err
+		}
+		batch := i.disk.NewBatch()
+		for id := min(i.head, ohead); id > nhead; id-- {
+			h, err := readHistory(i.freezer, id)
+			if err != nil {
+				return 0, err
+			}
+			deleteHistoryIndex(batch, id, h)
+			if err := flushBatch(batch, false); err != nil {
+				return 0, err
+			}
+		}
+		rawdb.WriteStateHistoryIndexHead(batch, nhead)
+		if err := flushBatch(batch, true); err != nil {
+			return 0, err
+		}
+		i.head = nhead
+	}
+	return truncateFromHead(i.disk, i.freezer, nhead)
+}
+
+// truncateFromTail removes the state histories below or equal to the given new
+// tail from the freezer, along with their index entries. It returns the number
+// of items removed from the tail.
+func (i *historyIndexer) truncateFromTail(ntail uint64) (int, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	otail, err := i.freezer.Tail()
+	if err != nil {
+		return 0, err
+	}
+	batch := i.disk.NewBatch()
+	for id := otail + 1; id <= min(ntail, i.head); id++ {
+		h, err := readHistory(i.freezer, id)
+		if err != nil {
+			return 0, err
+		}
+		deleteHistoryIndex(batch, id, h)
+		if err := flushBatch(batch, false); err != nil {
+			return 0, err
+		}
+	}
+	if err := flushBatch(batch, true); err != nil {
+		return 0, err
+	}
+	return truncateFromTail(i.disk, i.freezer, ntail)
+}
+
+// reset discards the entire state histories in the freezer, along with all
+// the index entries and the index head.
+func (i *historyIndexer) reset() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if err := i.clear(); err != nil {
+		return err
+	}
+	return i.freezer.Reset()
+}
+
+// clear removes all the index entries along with the index head. This function
+// assumes the lock is already held.
+func (i *historyIndexer) clear() error {
+	batch := i.disk.NewBatch()
+	for _, prefix := range [][]byte{rawdb.StateHistoryAccountIndexPrefix, rawdb.StateHistoryStorageIndexPrefix} {
+		it := i.disk.NewIterator(prefix, nil)
+		for it.Next() {
+			batch.Delete(it.Key())
+			if err := flushBatch(batch, false); err != nil {
+				it.Release()
+				return err
+			}
+		}
+		it.Release()
+	}
+	rawdb.DeleteStateHistoryIndexHead(batch)
+	if err := flushBatch(batch, true); err != nil {
+		return err
+	}
+	i.head = 0
+	return nil
+}
+
+// accountIDs returns the ids of the state histories in range [start, end] in
+// which the specified account was mutated, along with the associated block
+// numbers. At most limit entries are returned if limit is non-zero.
+//
+// The range beyond the index head is not covered.
+func (i *historyIndexer) accountIDs(address common.Address, start, end uint64, limit int) ([]uint64, []uint64, error) {
+	return collectIndex(rawdb.IterateAccountHistoryIndex(i.disk, address, start), end, limit)
+}
+
+// storageIDs returns the ids of the state histories in range [start, end] in
+// which the specified storage slot was mutated, along with the associated block
+// numbers. At most limit entries are returned if limit is non-zero.
+//
+// The range beyond the index head is not covered.
+func (i *historyIndexer) storageIDs(address common.Address, slot common.Hash, start, end uint64, limit int) ([]uint64, []uint64, error) {
+	return collectIndex(rawdb.IterateStorageHistoryIndex(i.disk, address, slot, start), end, limit)
+}
+
+// collectIndex collects the state ids and block numbers from the given index
+// iterator until the id exceeds the end or the limit is reached. An error is
+// returned if the iteration is aborted, as the collected entries might be
+// incomplete.
+func collectIndex(it ethdb.Iterator, end uint64, limit int) ([]uint64, []uint64, error) {
+	defer it.Release()
+
+	var ids, blocks []uint64
+	for it.Next() {
+		key := it.Key()
+		id := binary.BigEndian.Uint64(key[len(key)-8:])
+		if id > end {
+			break
+		}
+		ids = append(ids, id)
+		blocks = append(blocks, binary.BigEndian.Uint64(it.Value()))
+		if limit != 0 && len(ids) >= limit {
+			break
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, nil, err
+	}
+	return ids, blocks, nil
+}
+
+// flushBatch writes the batch into the database if it's large enough, or if
+// force is specified.
+func flushBatch(batch ethdb.Batch, force bool) error {
+	if !force && batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	batch.Reset()
+	return nil
+}
+
+// writeHistoryIndex writes the index entries of the given state history.
+func writeHistoryIndex(db ethdb.KeyValueWriter, id uint64, h *history) {
+	for _, addr := range h.accountList {
+		rawdb.WriteAccountHistoryIndex(db, addr, id, h.meta.block)
+	}
+	for addr, slots := range h.storageList {
+		for _, slot := range slots {
+			rawdb.WriteStorageHistoryIndex(db, addr, slot, id, h.meta.block)
+		}
+	}
+}
+
+// deleteHistoryIndex deletes the index entries of the given state history.
+func deleteHistoryIndex(db ethdb.KeyValueWriter, id uint64, h *history) {
+	for _, addr := range h.accountList {
+		rawdb.DeleteAccountHistoryIndex(db, addr, id)
+	}
+	for addr, slots := range h.storageList {
+		for _, slot := range slots {
+			rawdb.DeleteStorageHistoryIndex(db, addr, slot, id)
+		}
+	}
+}
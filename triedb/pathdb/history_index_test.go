@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// checkHistoryIndex ensures the index entries of all the mutated states are
+// exactly matched with the state histories in the freezer.
+func checkHistoryIndex(indexer *historyIndexer, freezer ethdb.ResettableAncientStore, head uint64) error {
+	if indexed := indexer.indexed(); indexed != head {
+		return fmt.Errorf("unexpected index head, want: %d, got: %d", head, indexed)
+	}
+	tail, err := freezer.Tail()
+	if err != nil {
+		return err
+	}
+	var (
+		accounts = make(map[string][]uint64)
+		storages = make(map[string][]uint64)
+	)
+	for id := tail + 1; id <= head; id++ {
+		h, err := readHistory(freezer, id)
+		if err != nil {
+			return err
+		}
+		for _, addr := range h.accountList {
+			accounts[string(addr.Bytes())] = append(accounts[string(addr.Bytes())], id)
+		}
+		for addr, slots := range h.storageList {
+			for _, slot := range slots {
+				key := string(addr.Bytes()) + string(slot.Bytes())
+				storages[key] = append(storages[key], id)
+			}
+		}
+	}
+	for id := tail + 1; id <= head; id++ {
+		h, err := readHistory(freezer, id)
+		if err != nil {
+			return err
+		}
+		for _, addr := range h.accountList {
+			ids, blocks, err := indexer.accountIDs(addr, 0, head+1, 0)
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(ids, accounts[string(addr.Bytes())]) {
+				return fmt.Errorf("account %x index is mismatched, want: %v, got: %v", addr, accounts[string(addr.Bytes())], ids)
+			}
+			for i, id := range ids {
+				if number, _ := historyBlock(freezer, id); number != blocks[i] {
+					return fmt.Errorf("account %x index block is mismatched, want: %d, got: %d", addr, number, blocks[i])
+				}
+			}
+		}
+		for addr, slots := range h.storageList {
+			for _, slot := range slots {
+				key := string(addr.Bytes()) + string(slot.Bytes())
+				ids, _, err := indexer.storageIDs(addr, slot, 0, head+1, 0)
+				if err != nil {
+					return err
+				}
+				if !slices.Equal(ids, storages[key]) {
+					return fmt.Errorf("slot %x:%x index is mismatched, want: %v, got: %v", addr, slot, storages[key], ids)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestHistoryIndex(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	head := tester.db.tree.bottom().stateID()
+	if err := checkHistoryIndex(tester.db.indexer, tester.db.freezer, head); err != nil {
+		t.Fatalf("Invalid history index, err: %v", err)
+	}
+	// Revert the database, the index entries of the reverted histories
+	// should be removed as well.
+	if err := tester.db.Recover(tester.roots[tester.bottomIndex()-2]); err != nil {
+		t.Fatalf("Failed to revert db, err: %v", err)
+	}
+	if err := checkHistoryIndex(tester.db.indexer, tester.db.freezer, head-2); err != nil {
+		t.Fatalf("Invalid history index, err: %v", err)
+	}
+}
+
+func TestHistoryIndexTailTruncation(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 2)
+	defer tester.release()
+
+	head := tester.db.tree.bottom().stateID()
+	if err := checkHistoryIndex(tester.db.indexer, tester.db.freezer, head); err != nil {
+		t.Fatalf("Invalid history index, err: %v", err)
+	}
+	// All the index entries of the pruned histories should be removed
+	it := tester.db.diskdb.NewIterator(rawdb.StateHistoryAccountIndexPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if id := binary.BigEndian.Uint64(key[len(key)-8:]); id <= head-2 {
+			t.Fatalf("Unexpected index entry of pruned history %d", id)
+		}
+	}
+}
+
+func TestHistoryIndexBackground(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Drop the entire index and re-index the histories in the background
+	tester.db.indexer.close()
+	if err := tester.db.indexer.clear(); err != nil {
+		t.Fatalf("Failed to drop history index, err: %v", err)
+	}
+	indexer := newHistoryIndexer(tester.db.diskdb, tester.db.freezer, false)
+	indexer.wg.Wait()
+
+	head := tester.db.tree.bottom().stateID()
+	if err := checkHistoryIndex(indexer, tester.db.freezer, head); err != nil {
+		t.Fatalf("Invalid history index, err: %v", err)
+	}
+	tester.db.indexer = indexer
+}
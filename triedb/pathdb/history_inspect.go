@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -49,12 +50,12 @@ func sanitizeRange(start, end uint64, freezer ethdb.AncientReader) (uint64, uint
 	if err != nil {
 		return 0, 0, err
 	}
-	last := head - 1
+	last := head
 	if end != 0 && end < last {
 		last = end
 	}
 	// Make sure the range is valid
-	if first >= last {
+	if first > last {
 		return 0, 0, fmt.Errorf("range is invalid, first: %d, last: %d", first, last)
 	}
 	return first, last, nil
@@ -95,8 +96,69 @@ func inspectHistory(freezer ethdb.AncientReader, start, end uint64, onHistory fu
 	return stats, nil
 }
 
-// accountHistory inspects the account history within the range.
-func accountHistory(freezer ethdb.AncientReader, address common.Address, start, end uint64) (*HistoryStats, error) {
+// indexedHistory inspects the state history within the range with the help of
+// the history index, the histories in which the state was not mutated are not
+// touched at all. The range must be fully covered by the index.
+func indexedHistory(freezer ethdb.AncientReader, start, end uint64, search func(start, end uint64) ([]uint64, []uint64, error), resolve func(id uint64) ([]byte, bool, error)) (*HistoryStats, error) {
+	var (
+		err   error
+		stats = &HistoryStats{}
+	)
+	if stats.Start, err = historyBlock(freezer, start); err != nil {
+		return nil, err
+	}
+	if stats.End, err = historyBlock(freezer, end); err != nil {
+		return nil, err
+	}
+	ids, blocks, err := search(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		blob, found, err := resolve(id)
+		if err != nil {
+			return nil, err
+		}
+		// Skip the index entry if it's a leftover from an unclean shutdown.
+		if !found {
+			continue
+		}
+		stats.Blocks = append(stats.Blocks, blocks[i])
+		stats.Origins = append(stats.Origins, blob)
+	}
+	return stats, nil
+}
+
+// historyBlock returns the block number associated with the specified history.
+func historyBlock(freezer ethdb.AncientReader, id uint64) (uint64, error) {
+	blob := rawdb.ReadStateHistoryMeta(freezer, id)
+	if len(blob) == 0 {
+		return 0, fmt.Errorf("state history not found %d", id)
+	}
+	var m meta
+	if err := m.decode(blob); err != nil {
+		return 0, err
+	}
+	return m.block, nil
+}
+
+// accountHistory inspects the account history within the range. The history
+// index is used if it's available and covers the entire range.
+func accountHistory(freezer ethdb.AncientReader, indexer *historyIndexer, address common.Address, start, end uint64) (*HistoryStats, error) {
+	if indexer != nil {
+		first, last, err := sanitizeRange(start, end, freezer)
+		if err != nil {
+			return nil, err
+		}
+		if last <= indexer.indexed() {
+			search := func(start, end uint64) ([]uint64, []uint64, error) {
+				return indexer.accountIDs(address, start, end, 0)
+			}
+			return indexedHistory(freezer, first, last, search, func(id uint64) ([]byte, bool, error) {
+				return readAccountHistory(freezer, id, address)
+			})
+		}
+	}
 	return inspectHistory(freezer, start, end, func(h *history, stats *HistoryStats) {
 		blob, exists := h.accounts[address]
 		if !exists {
@@ -107,8 +169,23 @@ func accountHistory(freezer ethdb.AncientReader, address common.Address, start,
 	})
 }
 
-// storageHistory inspects the storage history within the range.
-func storageHistory(freezer ethdb.AncientReader, address common.Address, slot common.Hash, start uint64, end uint64) (*HistoryStats, error) {
+// storageHistory inspects the storage history within the range. The history
+// index is used if it's available and covers the entire range.
+func storageHistory(freezer ethdb.AncientReader, indexer *historyIndexer, address common.Address, slot common.Hash, start uint64, end uint64) (*HistoryStats, error) {
+	if indexer != nil {
+		first, last, err := sanitizeRange(start, end, freezer)
+		if err != nil {
+			return nil, err
+		}
+		if last <= indexer.indexed() {
+			search := func(start, end uint64) ([]uint64, []uint64, error) {
+				return indexer.storageIDs(address, slot, start, end, 0)
+			}
+			return indexedHistory(freezer, first, last, search, func(id uint64) ([]byte, bool, error) {
+				return readStorageHistory(freezer, id, address, slot)
+			})
+		}
+	}
 	return inspectHistory(freezer, start, end, func(h *history, stats *HistoryStats) {
 		slots, exists := h.storages[address]
 		if !exists {
@@ -137,7 +214,7 @@ func historyRange(freezer ethdb.AncientReader) (uint64, uint64, error) {
 	if err != nil {
 		return 0, 0, err
 	}
-	last := head - 1
+	last := head
 
 	fh, err := readHistory(freezer, first)
 	if err != nil {
@@ -0,0 +1,354 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// readAccountHistory looks up the original value of the specified account in
+// the state history with the given id. The returned flag indicates whether the
+// account was mutated in the corresponding state transition at all.
+//
+// Only the account index and account data are loaded, the rest of the history
+// object is left untouched.
+func readAccountHistory(reader ethdb.AncientReader, id uint64, address common.Address) ([]byte, bool, error) {
+	index, found, err := searchAccountIndex(reader, id, address)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	data := rawdb.ReadStateAccountHistory(reader, id)
+	last := index.offset + uint32(index.length)
+	if uint32(len(data)) < last {
+		return nil, false, fmt.Errorf("account data buffer is corrupted, id: %d", id)
+	}
+	return data[index.offset:last], true, nil
+}
+
+// readStorageHistory looks up the original value of the specified storage slot
+// in the state history with the given id. The returned flag indicates whether
+// the slot was mutated in the corresponding state transition at all.
+//
+// Note, slot refers to the hash of the raw slot key.
+func readStorageHistory(reader ethdb.AncientReader, id uint64, address common.Address, slot common.Hash) ([]byte, bool, error) {
+	accIndex, found, err := searchAccountIndex(reader, id, address)
+	if err != nil || !found || accIndex.storageSlots == 0 {
+		return nil, false, err
+	}
+	var (
+		indexes = rawdb.ReadStateStorageIndex(reader, id)
+		start   = int(accIndex.storageOffset) * slotIndexSize
+		end     = int(accIndex.storageOffset+accIndex.storageSlots) * slotIndexSize
+	)
+	if len(indexes) < end {
+		return nil, false, fmt.Errorf("storage index buffer is corrupted, id: %d", id)
+	}
+	indexes = indexes[start:end]
+
+	// The slot indexes belonging to a single account are sorted, binary search
+	// can be performed to locate the requested one.
+	n := int(accIndex.storageSlots)
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(indexes[i*slotIndexSize:i*slotIndexSize+common.HashLength], slot.Bytes()) >= 0
+	})
+	if pos == n {
+		return nil, false, nil
+	}
+	var index slotIndex
+	index.decode(indexes[pos*slotIndexSize : (pos+1)*slotIndexSize])
+	if index.hash != slot {
+		return nil, false, nil
+	}
+	data := rawdb.ReadStateStorageHistory(reader, id)
+	last := index.offset + uint32(index.length)
+	if uint32(len(data)) < last {
+		return nil, false, fmt.Errorf("storage data buffer is corrupted, id: %d", id)
+	}
+	return data[index.offset:last], true, nil
+}
+
+// searchAccountIndex locates the index of the specified account in the state
+// history with the given id. The account indexes are fixed-size and sorted,
+// binary search is performed to find it.
+func searchAccountIndex(reader ethdb.AncientReader, id uint64, address common.Address) (accountIndex, bool, error) {
+	indexes := rawdb.ReadStateAccountIndex(reader, id)
+	if len(indexes) == 0 || len(indexes)%accountIndexSize != 0 {
+		return accountIndex{}, false, fmt.Errorf("invalid account index, id: %d, len: %d", id, len(indexes))
+	}
+	n := len(indexes) / accountIndexSize
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(indexes[i*accountIndexSize:i*accountIndexSize+common.AddressLength], address.Bytes()) >= 0
+	})
+	if pos == n {
+		return accountIndex{}, false, nil
+	}
+	var index accountIndex
+	index.decode(indexes[pos*accountIndexSize : (pos+1)*accountIndexSize])
+	if index.address != address {
+		return accountIndex{}, false, nil
+	}
+	return index, true, nil
+}
+
+// HistoricalStateReader provides access to a historical state which is no
+// longer maintained by the layer tree, by resolving the original values of
+// the mutated states from the state histories.
+//
+// The state at id N is resolved by searching the state histories in range
+// [N+1, disk layer id]: the original value recorded in the first history that
+// mutated the requested state is the value at state N. If the state was not
+// mutated in the range at all, the value in the disk layer is returned.
+type HistoricalStateReader struct {
+	id   uint64      // The state id of the target state
+	root common.Hash // The state root of the target state
+	db   *Database
+}
+
+// HistoricReader constructs a reader for accessing the requested historic
+// state. An error is returned if the state is unknown, or the associated
+// state histories are not (or no longer) available.
+func (db *Database) HistoricReader(root common.Hash) (*HistoricalStateReader, error) {
+	// Historic state access is not supported in verkle mode.
+	if db.isVerkle {
+		return nil, errors.New("historic state is not supported in verkle")
+	}
+	if db.freezer == nil {
+		return nil, errors.New("state history is not available")
+	}
+	root = types.TrieRootHash(root)
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("state %#x is not available", root)
+	}
+	r := &HistoricalStateReader{
+		id:   *id,
+		root: root,
+		db:   db,
+	}
+	if _, err := r.historyRange(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// historyRange returns the current disk layer, ensuring the state histories
+// in range [r.id+1, disk layer id] are present at the time of the call.
+func (r *HistoricalStateReader) historyRange() (*diskLayer, error) {
+	dl := r.db.tree.bottom()
+	if dl.isStale() {
+		return nil, errSnapshotStale
+	}
+	if r.id > dl.stateID() {
+		return nil, fmt.Errorf("state %#x is not historic, id: %d, disk: %d", r.root, r.id, dl.stateID())
+	}
+	if err := r.checkTail(); err != nil {
+		return nil, err
+	}
+	return dl, nil
+}
+
+// checkTail ensures the state histories required for serving the target state
+// are not pruned yet.
+func (r *HistoricalStateReader) checkTail() error {
+	tail, err := r.db.freezer.Tail()
+	if err != nil {
+		return err
+	}
+	// The history with id tail+1 is the oldest available one, which is
+	// required for serving the state with id tail.
+	if r.id < tail {
+		return fmt.Errorf("%w, id: %d, tail: %d", errStateHistoryPruned, r.id, tail)
+	}
+	return nil
+}
+
+// lookup searches the original value of a state from the state histories in
+// range [r.id+1, head]. The candidate histories are located via the index
+// first, the resolve callback is then invoked on each of them until the state
+// is found. The part of range not yet indexed is searched linearly.
+func (r *HistoricalStateReader) lookup(head uint64, search func(start, end uint64) ([]uint64, error), resolve func(id uint64) ([]byte, bool, error)) ([]byte, bool, error) {
+	start := r.id + 1
+	if r.db.indexer != nil {
+		indexed := min(r.db.indexer.indexed(), head)
+		for start <= indexed {
+			ids, err := search(start, indexed)
+			if err != nil {
+				return nil, false, err
+			}
+			if len(ids) == 0 {
+				start = indexed + 1
+				break
+			}
+			// The index entry is double-checked against the history in
+			// case it's a leftover from an unclean shutdown.
+			blob, found, err := resolve(ids[0])
+			if err != nil {
+				return nil, false, err
+			}
+			if found {
+				return blob, true, nil
+			}
+			start = ids[0] + 1
+		}
+	}
+	for id := start; id <= head; id++ {
+		blob, found, err := resolve(id)
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			return blob, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// diskAccount retrieves the account from the trie of the given disk layer.
+func (r *HistoricalStateReader) diskAccount(dl *diskLayer, address common.Address) (*types.StateAccount, error) {
+	tr, err := trie.New(trie.StateTrieID(dl.rootHash()), r.db)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.Get(crypto.Keccak256(address.Bytes()))
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	account := new(types.StateAccount)
+	if err := rlp.DecodeBytes(blob, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// diskStorage retrieves the storage slot from the trie of the given disk layer.
+func (r *HistoricalStateReader) diskStorage(dl *diskLayer, address common.Address, slot common.Hash) ([]byte, error) {
+	account, err := r.diskAccount(dl, address)
+	if err != nil || account == nil || account.Root == types.EmptyRootHash {
+		return nil, err
+	}
+	tr, err := trie.New(trie.StorageTrieID(dl.rootHash(), crypto.Keccak256Hash(address.Bytes()), account.Root), r.db)
+	if err != nil {
+		return nil, err
+	}
+	return tr.Get(slot.Bytes())
+}
+
+// Account retrieves the account associated with a particular address in the
+// historic state. The returned account is in the slim format and nil will be
+// returned if the account is not existent.
+func (r *HistoricalStateReader) Account(address common.Address) (*types.SlimAccount, error) {
+	for {
+		dl, err := r.historyRange()
+		if err != nil {
+			return nil, err
+		}
+		search := func(start, end uint64) ([]uint64, error) {
+			ids, _, err := r.db.indexer.accountIDs(address, start, end, 1)
+			return ids, err
+		}
+		blob, found, err := r.lookup(dl.stateID(), search, func(id uint64) ([]byte, bool, error) {
+			return readAccountHistory(r.db.freezer, id, address)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			// Ensure the state histories are not pruned during the lookup,
+			// otherwise the located history might not be the first one.
+			if err := r.checkTail(); err != nil {
+				return nil, err
+			}
+			if len(blob) == 0 {
+				return nil, nil
+			}
+			account := new(types.SlimAccount)
+			if err := rlp.DecodeBytes(blob, account); err != nil {
+				return nil, err
+			}
+			return account, nil
+		}
+		// The account is not mutated since the target state, resolve it
+		// from the disk layer instead. Retry if the disk layer is advanced
+		// in the meantime, as further state histories may be relevant.
+		account, err := r.diskAccount(dl, address)
+		if dl.isStale() {
+			continue
+		}
+		if err != nil || account == nil {
+			return nil, err
+		}
+		slim := &types.SlimAccount{
+			Nonce:   account.Nonce,
+			Balance: account.Balance,
+		}
+		if account.Root != types.EmptyRootHash {
+			slim.Root = account.Root.Bytes()
+		}
+		if !bytes.Equal(account.CodeHash, types.EmptyCodeHash.Bytes()) {
+			slim.CodeHash = account.CodeHash
+		}
+		return slim, nil
+	}
+}
+
+// Storage retrieves the storage slot associated with a particular address and
+// slot hash in the historic state. The returned value is RLP-encoded with the
+// leading zeros trimmed, nil will be returned if the slot is not existent.
+//
+// Note, slot refers to the hash of the raw slot key.
+func (r *HistoricalStateReader) Storage(address common.Address, slot common.Hash) ([]byte, error) {
+	for {
+		dl, err := r.historyRange()
+		if err != nil {
+			return nil, err
+		}
+		search := func(start, end uint64) ([]uint64, error) {
+			ids, _, err := r.db.indexer.storageIDs(address, slot, start, end, 1)
+			return ids, err
+		}
+		blob, found, err := r.lookup(dl.stateID(), search, func(id uint64) ([]byte, bool, error) {
+			return readStorageHistory(r.db.freezer, id, address, slot)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			// Ensure the state histories are not pruned during the lookup,
+			// otherwise the located history might not be the first one.
+			if err := r.checkTail(); err != nil {
+				return nil, err
+			}
+			return blob, nil
+		}
+		blob, err = r.diskStorage(dl, address, slot)
+		if dl.isStale() {
+			continue
+		}
+		return blob, err
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func checkHistoricState(t *tester, root common.Hash) error {
+	reader, err := t.db.HistoricReader(root)
+	if err != nil {
+		return err
+	}
+	for addrHash, addr := range t.preimages {
+		account, err := reader.Account(addr)
+		if err != nil {
+			return err
+		}
+		want := t.snapAccounts[root][addrHash]
+		if len(want) == 0 {
+			if account != nil {
+				return fmt.Errorf("unexpected account %x", addr)
+			}
+			continue
+		}
+		blob, err := rlp.EncodeToBytes(account)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(blob, want) {
+			return fmt.Errorf("account %x is mismatched, want: %x, got: %x", addr, want, blob)
+		}
+		for slot, want := range t.snapStorages[root][addrHash] {
+			blob, err := reader.Storage(addr, slot)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(blob, want) {
+				return fmt.Errorf("slot %x:%x is mismatched, want: %x, got: %x", addr, slot, want, blob)
+			}
+		}
+	}
+	return nil
+}
+
+func TestHistoricReader(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	for i := 0; i <= tester.bottomIndex(); i++ {
+		if err := checkHistoricState(tester, tester.roots[i]); err != nil {
+			t.Fatalf("Failed to read historic state %d, err: %v", i, err)
+		}
+	}
+	// The states above the disk layer are not historic.
+	if _, err := tester.db.HistoricReader(tester.lastHash()); err == nil {
+		t.Fatal("Unexpected historic reader for in-memory state")
+	}
+}
+
+func TestHistoricReaderPruned(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, 2)
+	defer tester.release()
+
+	var (
+		bottom = tester.bottomIndex()
+		tail   = uint64(bottom + 1 - 2) // the root mapping of tail state is pruned as well
+	)
+	for i := 0; i <= bottom; i++ {
+		_, err := tester.db.HistoricReader(tester.roots[i])
+		if uint64(i+1) <= tail {
+			if err == nil {
+				t.Fatalf("Unexpected historic reader for pruned state %d", i)
+			}
+			continue
+		}
+		if err := checkHistoricState(tester, tester.roots[i]); err != nil {
+			t.Fatalf("Failed to read historic state %d, err: %v", i, err)
+		}
+	}
+}
@@ -328,3 +328,53 @@ func compareStorageList(a, b map[common.Address][]common.Hash) bool {
 	}
 	return true
 }
+
+func TestHistoryInspectRange(t *testing.T) {
+	var (
+		hs         = makeHistories(10)
+		freezer, _ = rawdb.NewStateFreezer(t.TempDir(), false, false)
+	)
+	defer freezer.Close()
+
+	for i := 0; i < len(hs); i++ {
+		accountData, storageData, accountIndex, storageIndex := hs[i].encode()
+		rawdb.WriteStateHistory(freezer, uint64(i+1), hs[i].meta.encode(), accountIndex, storageIndex, accountData, storageData)
+	}
+	var cases = []struct {
+		start, end  uint64
+		first, last uint64
+		err         bool
+	}{
+		{0, 0, 1, 10, false},
+		{0, 5, 1, 5, false},
+		{3, 0, 3, 10, false},
+		{10, 0, 10, 10, false},
+		{10, 10, 10, 10, false},
+		{5, 5, 5, 5, false},
+		{11, 0, 0, 0, true},
+		{6, 5, 0, 0, true},
+	}
+	for _, c := range cases {
+		first, last, err := sanitizeRange(c.start, c.end, freezer)
+		if c.err {
+			if err == nil {
+				t.Errorf("Expected error for range [%d, %d]", c.start, c.end)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for range [%d, %d]: %v", c.start, c.end, err)
+			continue
+		}
+		if first != c.first || last != c.last {
+			t.Errorf("Unexpected range for [%d, %d], want [%d, %d], got [%d, %d]", c.start, c.end, c.first, c.last, first, last)
+		}
+	}
+	first, last, err := historyRange(freezer)
+	if err != nil {
+		t.Fatalf("Failed to resolve history range: %v", err)
+	}
+	if first != hs[0].meta.block || last != hs[len(hs)-1].meta.block {
+		t.Fatalf("Unexpected history range, want [%d, %d], got [%d, %d]", hs[0].meta.block, hs[len(hs)-1].meta.block, first, last)
+	}
+}